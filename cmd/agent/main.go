@@ -3,30 +3,55 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"starless/kadath/configs"
 	"starless/kadath/internal/agent"
 	"starless/kadath/internal/engine"
 	"starless/kadath/internal/loops"
+	"starless/kadath/internal/outbox"
 	"starless/kadath/internal/types"
+	"starless/kadath/internal/utils"
 
 	pb "starless/kadath/gen/proto"
 )
 
+// classifyError wraps err with the error code the engine gives it, or with
+// code when the engine does not know it
+func classifyError(code types.ErrorCode, err error) *types.QueryError {
+	classified := &types.QueryError{Code: code, Err: err}
+	if queryErr := engine.ClassifyError(err); queryErr != nil {
+		classified.Code = queryErr.Code
+		classified.NativeCode = queryErr.NativeCode
+		classified.NativeMessage = queryErr.NativeMessage
+	}
+	return classified
+}
+
+// failedResult returns the result of a job that failed with err, described
+// by message
+func failedResult(code types.ErrorCode, message string, err error) agent.JobResult {
+	classified := classifyError(code, err)
+	return agent.JobResult{
+		Success:       false,
+		ResultJSON:    "{}",
+		ErrorMessage:  fmt.Sprintf("%s: %v", message, err),
+		ErrorCode:     classified.Code,
+		NativeCode:    classified.NativeCode,
+		NativeMessage: classified.NativeMessage,
+	}
+}
 
 func handlePing(ctx context.Context, eng types.Engine) agent.JobResult {
 	err := eng.Ping(ctx)
 	if err != nil {
-		return agent.JobResult{
-			Success:      false,
-			ResultJSON:   "{}",
-			ErrorMessage: fmt.Sprintf("Ping failed: %v", err),
-		}
+		return failedResult(types.ErrorConnection, "Ping failed", err)
 	}
 	return agent.JobResult{
 		Success:      true,
@@ -35,96 +60,290 @@ func handlePing(ctx context.Context, eng types.Engine) agent.JobResult {
 	}
 }
 
-func handleDslQuery(ctx context.Context, eng types.Engine, payload map[string]interface{}) agent.JobResult {
-	logger := slog.Default()
+// successResult returns the result of a successful job. Servers without
+// typed results get it in JSON, encoded here so that a result that cannot
+// be encoded fails the job.
+func successResult(client *agent.Agent, result agent.JobResult, v interface{}) agent.JobResult {
+	if client.TypedResults() {
+		return result
+	}
 
-	// Convert payload map to JSON string for parsing
-	payloadJSON, err := json.Marshal(payload)
+	resultJSON, err := json.Marshal(v)
 	if err != nil {
-		logger.Error("Failed to marshal payload", "error", err)
-		return agent.JobResult{
-			Success:      false,
-			ResultJSON:   "{}",
-			ErrorMessage: fmt.Sprintf("Invalid payload format: %v", err),
-		}
+		slog.Default().Error("Failed to marshal result", "error", err)
+		return failedResult(types.ErrorInternal, "Failed to serialize result", err)
+	}
+	result.ResultJSON = string(resultJSON)
+	return result
+}
+
+// queryResult returns the result of a successful query in the format the
+// job asked for
+func queryResult(client *agent.Agent, format types.ResultFormat, result *types.QueryResponse) agent.JobResult {
+	if format == types.ResultFormatColumnar {
+		columnar := result.Columnar()
+		return successResult(client, agent.JobResult{Success: true, Columnar: columnar}, columnar)
+	}
+	return successResult(client, agent.JobResult{Success: true, Query: result}, result)
+}
+
+// dslQueryParams returns the DSL query of a job from its typed payload, or
+// from its JSON payload for servers speaking protocol version 1
+func dslQueryParams(job *agent.JobResponse) (*types.QueryParams, error) {
+	if job.DslQuery == nil {
+		return types.ParseQueryParams(job.PayloadJSON)
+	}
+	if err := job.DslQuery.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid query params: %w", err)
+	}
+	return job.DslQuery, nil
+}
+
+// rawQueryParams is the raw query form of dslQueryParams
+func rawQueryParams(job *agent.JobResponse) (*types.RawQueryParams, error) {
+	if job.RawQuery == nil {
+		return types.ParseRawQueryParams(job.PayloadJSON)
+	}
+	if err := job.RawQuery.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid raw query params: %w", err)
 	}
+	return job.RawQuery, nil
+}
+
+// fetchColumnsParams is the fetch columns form of dslQueryParams
+func fetchColumnsParams(job *agent.JobResponse) (*types.FetchColumnsParams, error) {
+	if job.FetchColumns == nil {
+		return types.ParseFetchColumnsParams(job.PayloadJSON)
+	}
+	if err := job.FetchColumns.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fetch columns params: %w", err)
+	}
+	return job.FetchColumns, nil
+}
+
+func handleDslQuery(ctx context.Context, client *agent.Agent, eng types.Engine, job *agent.JobResponse) agent.JobResult {
+	logger := slog.Default()
 
 	// Parse query parameters
-	queryParams, err := types.ParseQueryParams(string(payloadJSON))
+	queryParams, err := dslQueryParams(job)
 	if err != nil {
 		logger.Error("Failed to parse query params", "error", err)
-		return agent.JobResult{
-			Success:      false,
-			ResultJSON:   "{}",
-			ErrorMessage: fmt.Sprintf("Invalid query parameters: %v", err),
-		}
+		return failedResult(types.ErrorValidation, "Invalid query parameters", err)
+	}
+
+	// Arrow results are binary and only delivered through a result upload
+	if queryParams.ResultFormat == types.ResultFormatArrow {
+		return streamJobResult(ctx, client, job.Id, queryParams.ResultFormat, func(w types.RowWriter) (*types.StreamSummary, error) {
+			return eng.StreamQuery(ctx, queryParams, w)
+		})
 	}
 
 	// Execute query
 	result, err := eng.ExecuteQuery(ctx, queryParams)
 	if err != nil {
 		logger.Error("Failed to execute query", "error", err)
-		return agent.JobResult{
-			Success:      false,
-			ResultJSON:   "{}",
-			ErrorMessage: fmt.Sprintf("Query execution failed: %v", err),
-		}
+		return failedResult(types.ErrorInternal, "Query execution failed", err)
+	}
+
+	logger.Info("Query executed successfully", "row_count", result.RowCount)
+	return queryResult(client, queryParams.ResultFormat, result)
+}
+
+func handleQuery(ctx context.Context, client *agent.Agent, eng types.Engine, job *agent.JobResponse) agent.JobResult {
+	logger := slog.Default()
+
+	params, err := rawQueryParams(job)
+	if err != nil {
+		logger.Error("Failed to parse raw query params", "error", err)
+		return failedResult(types.ErrorValidation, "Invalid query parameters", err)
+	}
+
+	if params.ResultFormat == types.ResultFormatArrow {
+		return streamJobResult(ctx, client, job.Id, params.ResultFormat, func(w types.RowWriter) (*types.StreamSummary, error) {
+			return eng.StreamRawQuery(ctx, params, w)
+		})
+	}
+
+	result, err := eng.ExecuteRawQuery(ctx, params)
+	if err != nil {
+		logger.Error("Failed to execute raw query", "error", err)
+		return failedResult(types.ErrorInternal, "Query execution failed", err)
+	}
+
+	logger.Info("Raw query executed successfully", "row_count", result.RowCount)
+	return queryResult(client, params.ResultFormat, result)
+}
+
+// streamJobResult runs a query writing its rows to the result stream of the
+// job. The summary sent at the end of the stream completes the job, so
+// UpdateJob is only used when the upload itself fails.
+func streamJobResult(ctx context.Context, client *agent.Agent, jobID string, format types.ResultFormat, run func(types.RowWriter) (*types.StreamSummary, error)) agent.JobResult {
+	logger := slog.Default()
+
+	stream, err := client.OpenResultStream(ctx, jobID, format)
+	if err != nil {
+		logger.Error("Failed to open result stream", "error", err)
+		return failedResult(types.ErrorInternal, "Result upload failed", err)
+	}
+
+	summary, err := run(stream)
+	if err != nil {
+		logger.Error("Failed to execute query", "error", err)
+		err = classifyError(types.ErrorInternal, fmt.Errorf("Query execution failed: %w", err))
+	}
+
+	if uploadErr := stream.Finish(summary, err); uploadErr != nil {
+		logger.Error("Failed to upload result", "error", uploadErr)
+		return failedResult(types.ErrorInternal, "Result upload failed", uploadErr)
 	}
 
-	// Serialize result to JSON
-	resultJSON, err := json.Marshal(result)
 	if err != nil {
-		logger.Error("Failed to marshal result", "error", err)
 		return agent.JobResult{
 			Success:      false,
-			ResultJSON:   "{}",
-			ErrorMessage: fmt.Sprintf("Failed to serialize result: %v", err),
+			ErrorMessage: err.Error(),
+			Streamed:     true,
 		}
 	}
 
-	logger.Info("Query executed successfully", "row_count", result.RowCount)
+	logger.Info("Query result streamed successfully", "row_count", summary.RowCount)
 	return agent.JobResult{
-		Success:      true,
-		ResultJSON:   string(resultJSON),
-		ErrorMessage: "",
+		Success:  true,
+		Streamed: true,
 	}
 }
 
+func handleDslQueryStream(ctx context.Context, client *agent.Agent, eng types.Engine, job *agent.JobResponse) agent.JobResult {
+	logger := slog.Default()
 
+	queryParams, err := dslQueryParams(job)
+	if err != nil {
+		logger.Error("Failed to parse query params", "error", err)
+		return failedResult(types.ErrorValidation, "Invalid query parameters", err)
+	}
+
+	return streamJobResult(ctx, client, job.Id, queryParams.ResultFormat, func(w types.RowWriter) (*types.StreamSummary, error) {
+		return eng.StreamQuery(ctx, queryParams, w)
+	})
+}
 
-func handleJob(ctx context.Context, client *agent.Agent, job *agent.JobResponse) agent.JobResult {
+func handleQueryStream(ctx context.Context, client *agent.Agent, eng types.Engine, job *agent.JobResponse) agent.JobResult {
 	logger := slog.Default()
-	logger.Info("Handling job", "job_id", job.Id, "kind", job.Kind, "payload", job.Payload)
 
-	cfg, err := configs.LoadConfig()
+	params, err := rawQueryParams(job)
 	if err != nil {
-		panic(err)
+		logger.Error("Failed to parse raw query params", "error", err)
+		return failedResult(types.ErrorValidation, "Invalid query parameters", err)
 	}
 
-	eng, err := engine.NewEngine(cfg)
+	return streamJobResult(ctx, client, job.Id, params.ResultFormat, func(w types.RowWriter) (*types.StreamSummary, error) {
+		return eng.StreamRawQuery(ctx, params, w)
+	})
+}
+
+func handleSchemaRefresh(ctx context.Context, client *agent.Agent, eng types.Engine) agent.JobResult {
+	logger := slog.Default()
 
+	schema, err := eng.DescribeSchema(ctx)
 	if err != nil {
-		return agent.JobResult{
-			Success:      false,
-			ResultJSON:   "{}",
-			ErrorMessage: "Unable to initialize Engine",
-		}
+		logger.Error("Failed to describe schema", "error", err)
+		return failedResult(types.ErrorInternal, "Schema refresh failed", err)
 	}
 
+	logger.Info("Schema refreshed successfully", "schema_count", len(schema.Schemas))
+	return successResult(client, agent.JobResult{Success: true, Schema: schema}, schema)
+}
+
+func handleFetchColumns(ctx context.Context, client *agent.Agent, eng types.Engine, job *agent.JobResponse) agent.JobResult {
+	logger := slog.Default()
+
+	params, err := fetchColumnsParams(job)
+	if err != nil {
+		logger.Error("Failed to parse fetch columns params", "error", err)
+		return failedResult(types.ErrorValidation, "Invalid fetch columns parameters", err)
+	}
+
+	columns, err := eng.FetchColumns(ctx, params)
+	if err != nil {
+		logger.Error("Failed to fetch columns", "error", err)
+		return failedResult(types.ErrorInternal, "Fetch columns failed", err)
+	}
+
+	logger.Info("Columns fetched successfully", "table", params.Table, "column_count", len(columns.Columns))
+	return successResult(client, agent.JobResult{Success: true, Columns: columns}, columns)
+}
+
+
+
+func handleJob(ctx context.Context, client *agent.Agent, eng types.Engine, job *agent.JobResponse) agent.JobResult {
+	logger := slog.Default()
+	logger.Info("Handling job", "job_id", job.Id, "kind", job.Kind, "payload", job.PayloadJSON)
+
 	switch pb.JobKind(job.Kind) {
 	case pb.JobKind_JOB_KIND_PING:
 		return handlePing(ctx, eng)
+	case pb.JobKind_JOB_KIND_QUERY:
+		if job.StreamResult {
+			return handleQueryStream(ctx, client, eng, job)
+		}
+		return handleQuery(ctx, client, eng, job)
 	case pb.JobKind_JOB_KIND_DSL_QUERY:
-		return handleDslQuery(ctx, eng, job.Payload)
+		if job.StreamResult {
+			return handleDslQueryStream(ctx, client, eng, job)
+		}
+		return handleDslQuery(ctx, client, eng, job)
+	case pb.JobKind_JOB_KIND_SCHEMA_REFRESH:
+		return handleSchemaRefresh(ctx, client, eng)
+	case pb.JobKind_JOB_KIND_FETCH_COLUMNS:
+		return handleFetchColumns(ctx, client, eng, job)
 	default:
 		return agent.JobResult{
 			Success:      false,
 			ResultJSON:   "{}",
 			ErrorMessage: fmt.Sprintf("Unhandled Job Kind: %d", job.Kind),
+			ErrorCode:    types.ErrorValidation,
 		}
 	}
 }
 
+// register announces the agent to the server, retrying while the server is
+// unreachable. It fails when the server rejects the agent.
+func register(ctx context.Context, client *agent.Agent, eng types.Engine) error {
+	logger := slog.Default()
+
+	info, err := eng.Info(ctx)
+	if err != nil {
+		logger.Warn("Failed to get database version", "error", err)
+		info = &types.EngineInfo{Type: engine.Type}
+	}
+
+	retry := utils.Backoff{Base: time.Second, Max: time.Minute}
+	for {
+		err := client.Register(ctx, info)
+		var rejected *agent.Rejected
+		if err == nil || errors.As(err, &rejected) {
+			return err
+		}
+
+		wait := retry.Next()
+		logger.Warn("Registration failed, retrying", "error", err, "retry_in", wait)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// deregister tells the server the agent is going away
+func deregister(client *agent.Agent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := client.Deregister(ctx); err != nil {
+		slog.Default().Warn("Failed to deregister agent", "error", err)
+	}
+}
+
 func main() {
 	cfg, err := configs.LoadConfig()
 	if err != nil {
@@ -139,21 +358,65 @@ func main() {
 
 	supportedKinds := []pb.JobKind{
 		pb.JobKind_JOB_KIND_PING,
+		pb.JobKind_JOB_KIND_QUERY,
 		pb.JobKind_JOB_KIND_FETCH_COLUMNS,
 		pb.JobKind_JOB_KIND_DSL_QUERY,
 		pb.JobKind_JOB_KIND_SCHEMA_REFRESH,
 	}
 
-	a, err := agent.NewAgent(ctx, "localhost:9001", cfg.ConnectorId, cfg.AuthToken, supportedKinds, logger)
+	a, err := agent.NewAgent(ctx, cfg, supportedKinds, logger)
 	if err != nil {
 		logger.Error("Failed to create agent", "error", err)
 		os.Exit(1)
 	}
+	defer a.Close()
+
+	eng, err := engine.NewEngine(cfg)
+	if err != nil {
+		logger.Error("Failed to initialize engine", "error", err)
+		os.Exit(1)
+	}
+	defer eng.Close()
+
+	kindLimits, err := loops.ParseKindLimits(cfg.JobKindLimits)
+	if err != nil {
+		logger.Error("Invalid job kind limits", "error", err)
+		os.Exit(1)
+	}
+
+	box, err := outbox.Open(cfg.OutboxDir, cfg.OutboxMaxBytes, cfg.OutboxMaxAge)
+	if err != nil {
+		logger.Error("Failed to open outbox", "error", err)
+		os.Exit(1)
+	}
+
+	if err := register(ctx, a, eng); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error("Failed to register agent", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Agent started", "servers", cfg.ServerAddrs, "version", agent.Version)
+
+	// Heartbeats go on while running jobs finish after a shutdown signal
+	heartbeatCtx, stopHeartbeats := context.WithCancel(context.WithoutCancel(ctx))
+	heartbeatsDone := make(chan struct{})
+	go func() {
+		loops.NewHeartBeatLoop(heartbeatCtx, a)
+		close(heartbeatsDone)
+	}()
 
-	logger.Info("Agent connected, starting loops")
+	go loops.NewOutboxLoop(ctx, a, box)
+	pool := loops.NewWorkerPool(supportedKinds, cfg.JobConcurrency, kindLimits)
+	loops.NewJobProcessLoop(ctx, a, box, pool, cfg.ShutdownGracePeriod, func(ctx context.Context, client *agent.Agent, job *agent.JobResponse) agent.JobResult {
+		return handleJob(ctx, client, eng, job)
+	})
 
-	go loops.NewHeartBeatLoop(ctx, a)
-	loops.NewJobProcessLoop(ctx, a, handleJob)
+	stopHeartbeats()
+	<-heartbeatsDone
+	deregister(a)
 
 	logger.Info("Agent stopped")
 }
@@ -1,16 +1,68 @@
 package configs
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
-	ConnectorId	string	`envconfig:"CONNECTOR_ID"`
-	AuthToken		string	`envconfig:"AUTH_TOKEN"`
-	DSN					string	`envconfig:"DB_URL"`
-	SSLMode			string	`envconfig:"DB_SSLMODE" default:"disable"`
-}
+	ConnectorId string `envconfig:"CONNECTOR_ID"`
+	AuthToken   string `envconfig:"AUTH_TOKEN"`
+	// DSN is the database connection string. MySQL sessions run in UTC
+	// unless it sets time_zone, in which case TIMESTAMP values are labelled
+	// with that zone and NOW() and timestamp literals follow it too.
+	DSN     string `envconfig:"DB_URL"`
+	SSLMode string `envconfig:"DB_SSLMODE" default:"disable"`
+
+	// AllowRawSelect pastes the legacy DSL select string into queries
+	// verbatim instead of treating it as a list of column names
+	AllowRawSelect bool `envconfig:"DSL_ALLOW_RAW_SELECT" default:"false"`
+
+	// JobConcurrency is the number of jobs run at once. JobKindLimits caps
+	// it for some kinds, as KIND:N pairs such as SCHEMA_REFRESH:1.
+	JobConcurrency int            `envconfig:"JOB_CONCURRENCY" default:"4"`
+	JobKindLimits  map[string]int `envconfig:"JOB_KIND_LIMITS" default:"SCHEMA_REFRESH:1"`
+
+	// ShutdownGracePeriod is how long running jobs may take to finish once
+	// the agent is asked to stop. Jobs still running after it are stopped
+	// and reported as failed.
+	ShutdownGracePeriod time.Duration `envconfig:"SHUTDOWN_GRACE_PERIOD" default:"30s"`
+
+	// ServerAddrs are the host:port addresses of the server, tried in order
+	// when connecting and failed over to when the current one goes away
+	ServerAddrs []string `envconfig:"SERVER_ADDRS" default:"localhost:9001"`
 
+	// Keepalive pings detect a dead connection while waiting for jobs. The
+	// server's keepalive enforcement policy must allow KeepaliveTime.
+	KeepaliveTime    time.Duration `envconfig:"SERVER_KEEPALIVE_TIME" default:"1m"`
+	KeepaliveTimeout time.Duration `envconfig:"SERVER_KEEPALIVE_TIMEOUT" default:"20s"`
+
+	// Delays between reconnection attempts, growing exponentially with
+	// jitter from the base delay up to the max delay
+	ReconnectBaseDelay time.Duration `envconfig:"SERVER_RECONNECT_BASE_DELAY" default:"1s"`
+	ReconnectMaxDelay  time.Duration `envconfig:"SERVER_RECONNECT_MAX_DELAY" default:"1m"`
+
+	// TLS of the connection to the server. The CA bundle defaults to the
+	// system roots; a client certificate and key enable mutual TLS.
+	ServerCAFile   string `envconfig:"SERVER_TLS_CA_FILE"`
+	ServerName     string `envconfig:"SERVER_TLS_SERVER_NAME"`
+	ClientCertFile string `envconfig:"SERVER_TLS_CERT_FILE"`
+	ClientKeyFile  string `envconfig:"SERVER_TLS_KEY_FILE"`
+	TLSMinVersion  string `envconfig:"SERVER_TLS_MIN_VERSION" default:"1.2"`
+
+	// ServerInsecure connects to the server without TLS, sending the auth
+	// token in cleartext. Only meant for local development.
+	ServerInsecure bool `envconfig:"SERVER_INSECURE" default:"false"`
+
+	// Job results the server could not be reached for are kept in the
+	// outbox directory until delivered. The oldest results are dropped once
+	// the outbox grows past its size limit or they get older than its max
+	// age; 0 disables a limit.
+	OutboxDir      string        `envconfig:"OUTBOX_DIR" default:"outbox"`
+	OutboxMaxBytes int64         `envconfig:"OUTBOX_MAX_BYTES" default:"67108864"`
+	OutboxMaxAge   time.Duration `envconfig:"OUTBOX_MAX_AGE" default:"24h"`
+}
 
 func readEnv() (*Config, error) {
 	var cfg Config
@@ -18,7 +70,6 @@ func readEnv() (*Config, error) {
 	return &cfg, err
 }
 
-
 func LoadConfig() (*Config, error) {
 	cfg, err := readEnv()
 	if err != nil {
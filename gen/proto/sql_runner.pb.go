@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.9
+// 	protoc-gen-go v1.36.11
 // 	protoc        v6.33.2
 // source: proto/sql_runner.proto
 
@@ -9,6 +9,7 @@ package sql_runner
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -79,28 +80,2378 @@ func (JobKind) EnumDescriptor() ([]byte, []int) {
 	return file_proto_sql_runner_proto_rawDescGZIP(), []int{0}
 }
 
+// Why a job failed, the same way for every database engine
+type ErrorCode int32
+
+const (
+	ErrorCode_ERROR_CODE_UNSPECIFIED ErrorCode = 0
+	// The job payload or query is invalid
+	ErrorCode_ERROR_CODE_VALIDATION        ErrorCode = 1
+	ErrorCode_ERROR_CODE_UNKNOWN_COLUMN    ErrorCode = 2
+	ErrorCode_ERROR_CODE_UNKNOWN_TABLE     ErrorCode = 3
+	ErrorCode_ERROR_CODE_PERMISSION_DENIED ErrorCode = 4
+	// The database cannot be reached or dropped the connection
+	ErrorCode_ERROR_CODE_CONNECTION ErrorCode = 5
+	ErrorCode_ERROR_CODE_TIMEOUT    ErrorCode = 6
+	ErrorCode_ERROR_CODE_CANCELLED  ErrorCode = 7
+	ErrorCode_ERROR_CODE_SYNTAX     ErrorCode = 8
+	ErrorCode_ERROR_CODE_INTERNAL   ErrorCode = 9
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "ERROR_CODE_UNSPECIFIED",
+		1: "ERROR_CODE_VALIDATION",
+		2: "ERROR_CODE_UNKNOWN_COLUMN",
+		3: "ERROR_CODE_UNKNOWN_TABLE",
+		4: "ERROR_CODE_PERMISSION_DENIED",
+		5: "ERROR_CODE_CONNECTION",
+		6: "ERROR_CODE_TIMEOUT",
+		7: "ERROR_CODE_CANCELLED",
+		8: "ERROR_CODE_SYNTAX",
+		9: "ERROR_CODE_INTERNAL",
+	}
+	ErrorCode_value = map[string]int32{
+		"ERROR_CODE_UNSPECIFIED":       0,
+		"ERROR_CODE_VALIDATION":        1,
+		"ERROR_CODE_UNKNOWN_COLUMN":    2,
+		"ERROR_CODE_UNKNOWN_TABLE":     3,
+		"ERROR_CODE_PERMISSION_DENIED": 4,
+		"ERROR_CODE_CONNECTION":        5,
+		"ERROR_CODE_TIMEOUT":           6,
+		"ERROR_CODE_CANCELLED":         7,
+		"ERROR_CODE_SYNTAX":            8,
+		"ERROR_CODE_INTERNAL":          9,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_sql_runner_proto_enumTypes[1].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_proto_sql_runner_proto_enumTypes[1]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{1}
+}
+
+type RegisterRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	AgentId      string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	AgentVersion string                 `protobuf:"bytes,2,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	// Highest version of this protocol implemented by the agent; agents also
+	// speak every version down to 1
+	ProtocolVersion int32 `protobuf:"varint,3,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// Database engine, postgres or mysql
+	EngineType      string    `protobuf:"bytes,4,opt,name=engine_type,json=engineType,proto3" json:"engine_type,omitempty"`
+	DatabaseVersion string    `protobuf:"bytes,5,opt,name=database_version,json=databaseVersion,proto3" json:"database_version,omitempty"`
+	SupportedKinds  []JobKind `protobuf:"varint,6,rep,packed,name=supported_kinds,json=supportedKinds,proto3,enum=sql.v1.JobKind" json:"supported_kinds,omitempty"`
+	// DSL condition types, such as equal or between
+	ConditionTypes []string `protobuf:"bytes,7,rep,name=condition_types,json=conditionTypes,proto3" json:"condition_types,omitempty"`
+	// Optional DSL and protocol features, such as order_by or job_stream
+	Features []string `protobuf:"bytes,8,rep,name=features,proto3" json:"features,omitempty"`
+	// Largest message the agent accepts from the server
+	MaxPayloadBytes int64 `protobuf:"varint,9,opt,name=max_payload_bytes,json=maxPayloadBytes,proto3" json:"max_payload_bytes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_proto_sql_runner_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RegisterRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetAgentVersion() string {
+	if x != nil {
+		return x.AgentVersion
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetEngineType() string {
+	if x != nil {
+		return x.EngineType
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetDatabaseVersion() string {
+	if x != nil {
+		return x.DatabaseVersion
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetSupportedKinds() []JobKind {
+	if x != nil {
+		return x.SupportedKinds
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetConditionTypes() []string {
+	if x != nil {
+		return x.ConditionTypes
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetMaxPayloadBytes() int64 {
+	if x != nil {
+		return x.MaxPayloadBytes
+	}
+	return 0
+}
+
+type RegisterResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Accepted bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	// Why the agent was refused, when not accepted
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Protocol version the server chose for the agent, at most the agent's
+	// protocol_version. 0 from servers predating negotiation means 1.
+	ProtocolVersion int32 `protobuf:"varint,3,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	mi := &file_proto_sql_runner_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RegisterResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *RegisterResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RegisterResponse) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
 type HeartbeatRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	AgentId string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	// Last heartbeat of an agent going away, which takes no more jobs
+	ShuttingDown  bool `protobuf:"varint,2,opt,name=shutting_down,json=shuttingDown,proto3" json:"shutting_down,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_proto_sql_runner_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HeartbeatRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetShuttingDown() bool {
+	if x != nil {
+		return x.ShuttingDown
+	}
+	return false
+}
+
+type HeartbeatResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Success bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// Jobs of this agent the server wants stopped. Used by agents polling
+	// with GetJob; streaming agents receive CancelJob messages instead.
+	CancelledJobIds []string `protobuf:"bytes,2,rep,name=cancelled_job_ids,json=cancelledJobIds,proto3" json:"cancelled_job_ids,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HeartbeatResponse) Reset() {
+	*x = HeartbeatResponse{}
+	mi := &file_proto_sql_runner_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResponse) ProtoMessage() {}
+
+func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HeartbeatResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *HeartbeatResponse) GetCancelledJobIds() []string {
+	if x != nil {
+		return x.CancelledJobIds
+	}
+	return nil
+}
+
+type GetJobRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SupportedKinds []JobKind              `protobuf:"varint,1,rep,packed,name=supported_kinds,json=supportedKinds,proto3,enum=sql.v1.JobKind" json:"supported_kinds,omitempty"`
+	AgentId        string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_proto_sql_runner_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetJobRequest) GetSupportedKinds() []JobKind {
+	if x != nil {
+		return x.SupportedKinds
+	}
+	return nil
+}
+
+func (x *GetJobRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+type GetJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HasJob        bool                   `protobuf:"varint,1,opt,name=has_job,json=hasJob,proto3" json:"has_job,omitempty"`
+	Job           *Job                   `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobResponse) Reset() {
+	*x = GetJobResponse{}
+	mi := &file_proto_sql_runner_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobResponse) ProtoMessage() {}
+
+func (x *GetJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobResponse.ProtoReflect.Descriptor instead.
+func (*GetJobResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetJobResponse) GetHasJob() bool {
+	if x != nil {
+		return x.HasJob
+	}
+	return false
+}
+
+func (x *GetJobResponse) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+type Job struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind        JobKind                `protobuf:"varint,2,opt,name=kind,proto3,enum=sql.v1.JobKind" json:"kind,omitempty"`
+	PayloadJson string                 `protobuf:"bytes,3,opt,name=payload_json,json=payloadJson,proto3" json:"payload_json,omitempty"`
+	// Deliver the result through UploadJobResult instead of UpdateJob
+	StreamResult bool `protobuf:"varint,4,opt,name=stream_result,json=streamResult,proto3" json:"stream_result,omitempty"`
+	// Time the job may run for, 0 for no limit. Queries are stopped on the
+	// database when it runs out and the job is reported with timed_out set.
+	TimeoutMs int64 `protobuf:"varint,5,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	// Typed payload, sent instead of payload_json from protocol version 2
+	//
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*Job_DslQuery
+	//	*Job_RawQuery
+	//	*Job_FetchColumns
+	//	*Job_SchemaRefresh
+	Payload       isJob_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_proto_sql_runner_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetKind() JobKind {
+	if x != nil {
+		return x.Kind
+	}
+	return JobKind_JOB_KIND_UNSPECIFIED
+}
+
+func (x *Job) GetPayloadJson() string {
+	if x != nil {
+		return x.PayloadJson
+	}
+	return ""
+}
+
+func (x *Job) GetStreamResult() bool {
+	if x != nil {
+		return x.StreamResult
+	}
+	return false
+}
+
+func (x *Job) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+func (x *Job) GetPayload() isJob_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Job) GetDslQuery() *DslQueryPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Job_DslQuery); ok {
+			return x.DslQuery
+		}
+	}
+	return nil
+}
+
+func (x *Job) GetRawQuery() *RawQueryPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Job_RawQuery); ok {
+			return x.RawQuery
+		}
+	}
+	return nil
+}
+
+func (x *Job) GetFetchColumns() *FetchColumnsPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Job_FetchColumns); ok {
+			return x.FetchColumns
+		}
+	}
+	return nil
+}
+
+func (x *Job) GetSchemaRefresh() *SchemaRefreshPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Job_SchemaRefresh); ok {
+			return x.SchemaRefresh
+		}
+	}
+	return nil
+}
+
+type isJob_Payload interface {
+	isJob_Payload()
+}
+
+type Job_DslQuery struct {
+	DslQuery *DslQueryPayload `protobuf:"bytes,6,opt,name=dsl_query,json=dslQuery,proto3,oneof"`
+}
+
+type Job_RawQuery struct {
+	RawQuery *RawQueryPayload `protobuf:"bytes,7,opt,name=raw_query,json=rawQuery,proto3,oneof"`
+}
+
+type Job_FetchColumns struct {
+	FetchColumns *FetchColumnsPayload `protobuf:"bytes,8,opt,name=fetch_columns,json=fetchColumns,proto3,oneof"`
+}
+
+type Job_SchemaRefresh struct {
+	SchemaRefresh *SchemaRefreshPayload `protobuf:"bytes,9,opt,name=schema_refresh,json=schemaRefresh,proto3,oneof"`
+}
+
+func (*Job_DslQuery) isJob_Payload() {}
+
+func (*Job_RawQuery) isJob_Payload() {}
+
+func (*Job_FetchColumns) isJob_Payload() {}
+
+func (*Job_SchemaRefresh) isJob_Payload() {}
+
+type DslQueryPayload struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	DatabaseType string                 `protobuf:"bytes,1,opt,name=database_type,json=databaseType,proto3" json:"database_type,omitempty"`
+	SchemaName   *string                `protobuf:"bytes,2,opt,name=schema_name,json=schemaName,proto3,oneof" json:"schema_name,omitempty"`
+	Table        string                 `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
+	Distinct     bool                   `protobuf:"varint,4,opt,name=distinct,proto3" json:"distinct,omitempty"`
+	Projections  []*Projection          `protobuf:"bytes,5,rep,name=projections,proto3" json:"projections,omitempty"`
+	// Legacy comma separated select list
+	Select     *string      `protobuf:"bytes,6,opt,name=select,proto3,oneof" json:"select,omitempty"`
+	Conditions []*Condition `protobuf:"bytes,7,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	Limit      *int32       `protobuf:"varint,8,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
+	GroupBy    []string     `protobuf:"bytes,9,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	Having     []*Condition `protobuf:"bytes,10,rep,name=having,proto3" json:"having,omitempty"`
+	OrderBy    []*OrderTerm `protobuf:"bytes,11,rep,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	Offset     *int32       `protobuf:"varint,12,opt,name=offset,proto3,oneof" json:"offset,omitempty"`
+	Cursor     string       `protobuf:"bytes,13,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// rows, columnar or arrow, rows when empty. Arrow results are always
+	// delivered through UploadJobResult.
+	ResultFormat  string `protobuf:"bytes,14,opt,name=result_format,json=resultFormat,proto3" json:"result_format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DslQueryPayload) Reset() {
+	*x = DslQueryPayload{}
+	mi := &file_proto_sql_runner_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DslQueryPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DslQueryPayload) ProtoMessage() {}
+
+func (x *DslQueryPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DslQueryPayload.ProtoReflect.Descriptor instead.
+func (*DslQueryPayload) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DslQueryPayload) GetDatabaseType() string {
+	if x != nil {
+		return x.DatabaseType
+	}
+	return ""
+}
+
+func (x *DslQueryPayload) GetSchemaName() string {
+	if x != nil && x.SchemaName != nil {
+		return *x.SchemaName
+	}
+	return ""
+}
+
+func (x *DslQueryPayload) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *DslQueryPayload) GetDistinct() bool {
+	if x != nil {
+		return x.Distinct
+	}
+	return false
+}
+
+func (x *DslQueryPayload) GetProjections() []*Projection {
+	if x != nil {
+		return x.Projections
+	}
+	return nil
+}
+
+func (x *DslQueryPayload) GetSelect() string {
+	if x != nil && x.Select != nil {
+		return *x.Select
+	}
+	return ""
+}
+
+func (x *DslQueryPayload) GetConditions() []*Condition {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *DslQueryPayload) GetLimit() int32 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
+	}
+	return 0
+}
+
+func (x *DslQueryPayload) GetGroupBy() []string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return nil
+}
+
+func (x *DslQueryPayload) GetHaving() []*Condition {
+	if x != nil {
+		return x.Having
+	}
+	return nil
+}
+
+func (x *DslQueryPayload) GetOrderBy() []*OrderTerm {
+	if x != nil {
+		return x.OrderBy
+	}
+	return nil
+}
+
+func (x *DslQueryPayload) GetOffset() int32 {
+	if x != nil && x.Offset != nil {
+		return *x.Offset
+	}
+	return 0
+}
+
+func (x *DslQueryPayload) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *DslQueryPayload) GetResultFormat() string {
+	if x != nil {
+		return x.ResultFormat
+	}
+	return ""
+}
+
+type Projection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Column        string                 `protobuf:"bytes,1,opt,name=column,proto3" json:"column,omitempty"`
+	Aggregate     string                 `protobuf:"bytes,2,opt,name=aggregate,proto3" json:"aggregate,omitempty"`
+	Alias         string                 `protobuf:"bytes,3,opt,name=alias,proto3" json:"alias,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Projection) Reset() {
+	*x = Projection{}
+	mi := &file_proto_sql_runner_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Projection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Projection) ProtoMessage() {}
+
+func (x *Projection) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Projection.ProtoReflect.Descriptor instead.
+func (*Projection) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Projection) GetColumn() string {
+	if x != nil {
+		return x.Column
+	}
+	return ""
+}
+
+func (x *Projection) GetAggregate() string {
+	if x != nil {
+		return x.Aggregate
+	}
+	return ""
+}
+
+func (x *Projection) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+// A comparison on column, or a group setting one of all, any and not
+type Condition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Column        string                 `protobuf:"bytes,1,opt,name=column,proto3" json:"column,omitempty"`
+	Aggregate     string                 `protobuf:"bytes,2,opt,name=aggregate,proto3" json:"aggregate,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Value         *structpb.Value        `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	All           []*Condition           `protobuf:"bytes,5,rep,name=all,proto3" json:"all,omitempty"`
+	Any           []*Condition           `protobuf:"bytes,6,rep,name=any,proto3" json:"any,omitempty"`
+	Not           *Condition             `protobuf:"bytes,7,opt,name=not,proto3" json:"not,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Condition) Reset() {
+	*x = Condition{}
+	mi := &file_proto_sql_runner_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Condition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Condition) ProtoMessage() {}
+
+func (x *Condition) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Condition.ProtoReflect.Descriptor instead.
+func (*Condition) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Condition) GetColumn() string {
+	if x != nil {
+		return x.Column
+	}
+	return ""
+}
+
+func (x *Condition) GetAggregate() string {
+	if x != nil {
+		return x.Aggregate
+	}
+	return ""
+}
+
+func (x *Condition) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Condition) GetValue() *structpb.Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Condition) GetAll() []*Condition {
+	if x != nil {
+		return x.All
+	}
+	return nil
+}
+
+func (x *Condition) GetAny() []*Condition {
+	if x != nil {
+		return x.Any
+	}
+	return nil
+}
+
+func (x *Condition) GetNot() *Condition {
+	if x != nil {
+		return x.Not
+	}
+	return nil
+}
+
+type OrderTerm struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Column        string                 `protobuf:"bytes,1,opt,name=column,proto3" json:"column,omitempty"`
+	Aggregate     string                 `protobuf:"bytes,2,opt,name=aggregate,proto3" json:"aggregate,omitempty"`
+	Direction     string                 `protobuf:"bytes,3,opt,name=direction,proto3" json:"direction,omitempty"`
+	Nulls         string                 `protobuf:"bytes,4,opt,name=nulls,proto3" json:"nulls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderTerm) Reset() {
+	*x = OrderTerm{}
+	mi := &file_proto_sql_runner_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderTerm) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderTerm) ProtoMessage() {}
+
+func (x *OrderTerm) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderTerm.ProtoReflect.Descriptor instead.
+func (*OrderTerm) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *OrderTerm) GetColumn() string {
+	if x != nil {
+		return x.Column
+	}
+	return ""
+}
+
+func (x *OrderTerm) GetAggregate() string {
+	if x != nil {
+		return x.Aggregate
+	}
+	return ""
+}
+
+func (x *OrderTerm) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *OrderTerm) GetNulls() string {
+	if x != nil {
+		return x.Nulls
+	}
+	return ""
+}
+
+type RawQueryPayload struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Sql    string                 `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
+	Params []*structpb.Value      `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
+	// Same as in DslQueryPayload
+	ResultFormat  string `protobuf:"bytes,3,opt,name=result_format,json=resultFormat,proto3" json:"result_format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RawQueryPayload) Reset() {
+	*x = RawQueryPayload{}
+	mi := &file_proto_sql_runner_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RawQueryPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RawQueryPayload) ProtoMessage() {}
+
+func (x *RawQueryPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RawQueryPayload.ProtoReflect.Descriptor instead.
+func (*RawQueryPayload) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RawQueryPayload) GetSql() string {
+	if x != nil {
+		return x.Sql
+	}
+	return ""
+}
+
+func (x *RawQueryPayload) GetParams() []*structpb.Value {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *RawQueryPayload) GetResultFormat() string {
+	if x != nil {
+		return x.ResultFormat
+	}
+	return ""
+}
+
+type FetchColumnsPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SchemaName    *string                `protobuf:"bytes,1,opt,name=schema_name,json=schemaName,proto3,oneof" json:"schema_name,omitempty"`
+	Table         string                 `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchColumnsPayload) Reset() {
+	*x = FetchColumnsPayload{}
+	mi := &file_proto_sql_runner_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchColumnsPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchColumnsPayload) ProtoMessage() {}
+
+func (x *FetchColumnsPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchColumnsPayload.ProtoReflect.Descriptor instead.
+func (*FetchColumnsPayload) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FetchColumnsPayload) GetSchemaName() string {
+	if x != nil && x.SchemaName != nil {
+		return *x.SchemaName
+	}
+	return ""
+}
+
+func (x *FetchColumnsPayload) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+type SchemaRefreshPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchemaRefreshPayload) Reset() {
+	*x = SchemaRefreshPayload{}
+	mi := &file_proto_sql_runner_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchemaRefreshPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchemaRefreshPayload) ProtoMessage() {}
+
+func (x *SchemaRefreshPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchemaRefreshPayload.ProtoReflect.Descriptor instead.
+func (*SchemaRefreshPayload) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{13}
+}
+
+type UpdateJobRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	JobId        string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	AgentId      string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Success      bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	ResultJson   string                 `protobuf:"bytes,4,opt,name=result_json,json=resultJson,proto3" json:"result_json,omitempty"`
+	ErrorMessage string                 `protobuf:"bytes,5,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	// The job was stopped because the server cancelled it
+	Cancelled bool `protobuf:"varint,6,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	// The job ran past its timeout_ms
+	TimedOut  bool      `protobuf:"varint,7,opt,name=timed_out,json=timedOut,proto3" json:"timed_out,omitempty"`
+	ErrorCode ErrorCode `protobuf:"varint,8,opt,name=error_code,json=errorCode,proto3,enum=sql.v1.ErrorCode" json:"error_code,omitempty"`
+	// Code and message of the database error, such as a SQLSTATE or a MySQL
+	// error number, when the job failed on one
+	NativeErrorCode    string `protobuf:"bytes,9,opt,name=native_error_code,json=nativeErrorCode,proto3" json:"native_error_code,omitempty"`
+	NativeErrorMessage string `protobuf:"bytes,10,opt,name=native_error_message,json=nativeErrorMessage,proto3" json:"native_error_message,omitempty"`
+	// Typed result, sent instead of result_json from protocol version 2
+	//
+	// Types that are valid to be assigned to Result:
+	//
+	//	*UpdateJobRequest_QueryResult
+	//	*UpdateJobRequest_SchemaResult
+	//	*UpdateJobRequest_ColumnsResult
+	//	*UpdateJobRequest_ColumnarResult
+	Result        isUpdateJobRequest_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateJobRequest) Reset() {
+	*x = UpdateJobRequest{}
+	mi := &file_proto_sql_runner_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateJobRequest) ProtoMessage() {}
+
+func (x *UpdateJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateJobRequest.ProtoReflect.Descriptor instead.
+func (*UpdateJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *UpdateJobRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *UpdateJobRequest) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *UpdateJobRequest) GetResultJson() string {
+	if x != nil {
+		return x.ResultJson
+	}
+	return ""
+}
+
+func (x *UpdateJobRequest) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *UpdateJobRequest) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+func (x *UpdateJobRequest) GetTimedOut() bool {
+	if x != nil {
+		return x.TimedOut
+	}
+	return false
+}
+
+func (x *UpdateJobRequest) GetErrorCode() ErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *UpdateJobRequest) GetNativeErrorCode() string {
+	if x != nil {
+		return x.NativeErrorCode
+	}
+	return ""
+}
+
+func (x *UpdateJobRequest) GetNativeErrorMessage() string {
+	if x != nil {
+		return x.NativeErrorMessage
+	}
+	return ""
+}
+
+func (x *UpdateJobRequest) GetResult() isUpdateJobRequest_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *UpdateJobRequest) GetQueryResult() *QueryResult {
+	if x != nil {
+		if x, ok := x.Result.(*UpdateJobRequest_QueryResult); ok {
+			return x.QueryResult
+		}
+	}
+	return nil
+}
+
+func (x *UpdateJobRequest) GetSchemaResult() *SchemaResult {
+	if x != nil {
+		if x, ok := x.Result.(*UpdateJobRequest_SchemaResult); ok {
+			return x.SchemaResult
+		}
+	}
+	return nil
+}
+
+func (x *UpdateJobRequest) GetColumnsResult() *ColumnsResult {
+	if x != nil {
+		if x, ok := x.Result.(*UpdateJobRequest_ColumnsResult); ok {
+			return x.ColumnsResult
+		}
+	}
+	return nil
+}
+
+func (x *UpdateJobRequest) GetColumnarResult() *ColumnarResult {
+	if x != nil {
+		if x, ok := x.Result.(*UpdateJobRequest_ColumnarResult); ok {
+			return x.ColumnarResult
+		}
+	}
+	return nil
+}
+
+type isUpdateJobRequest_Result interface {
+	isUpdateJobRequest_Result()
+}
+
+type UpdateJobRequest_QueryResult struct {
+	QueryResult *QueryResult `protobuf:"bytes,11,opt,name=query_result,json=queryResult,proto3,oneof"`
+}
+
+type UpdateJobRequest_SchemaResult struct {
+	SchemaResult *SchemaResult `protobuf:"bytes,12,opt,name=schema_result,json=schemaResult,proto3,oneof"`
+}
+
+type UpdateJobRequest_ColumnsResult struct {
+	ColumnsResult *ColumnsResult `protobuf:"bytes,13,opt,name=columns_result,json=columnsResult,proto3,oneof"`
+}
+
+type UpdateJobRequest_ColumnarResult struct {
+	ColumnarResult *ColumnarResult `protobuf:"bytes,14,opt,name=columnar_result,json=columnarResult,proto3,oneof"`
+}
+
+func (*UpdateJobRequest_QueryResult) isUpdateJobRequest_Result() {}
+
+func (*UpdateJobRequest_SchemaResult) isUpdateJobRequest_Result() {}
+
+func (*UpdateJobRequest_ColumnsResult) isUpdateJobRequest_Result() {}
+
+func (*UpdateJobRequest_ColumnarResult) isUpdateJobRequest_Result() {}
+
+// Result of a DSL or raw query
+type QueryResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rows          []*structpb.Struct     `protobuf:"bytes,1,rep,name=rows,proto3" json:"rows,omitempty"`
+	RowCount      int64                  `protobuf:"varint,2,opt,name=row_count,json=rowCount,proto3" json:"row_count,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,3,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryResult) Reset() {
+	*x = QueryResult{}
+	mi := &file_proto_sql_runner_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryResult) ProtoMessage() {}
+
+func (x *QueryResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryResult.ProtoReflect.Descriptor instead.
+func (*QueryResult) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *QueryResult) GetRows() []*structpb.Struct {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+func (x *QueryResult) GetRowCount() int64 {
+	if x != nil {
+		return x.RowCount
+	}
+	return 0
+}
+
+func (x *QueryResult) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// Result of a DSL or raw query in the columnar result format
+type ColumnarResult struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Columns []*ResultColumn        `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	// Values of each row in column order
+	Rows          []*structpb.ListValue `protobuf:"bytes,2,rep,name=rows,proto3" json:"rows,omitempty"`
+	RowCount      int64                 `protobuf:"varint,3,opt,name=row_count,json=rowCount,proto3" json:"row_count,omitempty"`
+	NextCursor    string                `protobuf:"bytes,4,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ColumnarResult) Reset() {
+	*x = ColumnarResult{}
+	mi := &file_proto_sql_runner_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ColumnarResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColumnarResult) ProtoMessage() {}
+
+func (x *ColumnarResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColumnarResult.ProtoReflect.Descriptor instead.
+func (*ColumnarResult) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ColumnarResult) GetColumns() []*ResultColumn {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *ColumnarResult) GetRows() []*structpb.ListValue {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+func (x *ColumnarResult) GetRowCount() int64 {
+	if x != nil {
+		return x.RowCount
+	}
+	return 0
+}
+
+func (x *ColumnarResult) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type ResultColumn struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Database type name, such as INT4 or VARCHAR
+	NativeType string `protobuf:"bytes,2,opt,name=native_type,json=nativeType,proto3" json:"native_type,omitempty"`
+	// Same as in ColumnDetail
+	LogicalType string `protobuf:"bytes,3,opt,name=logical_type,json=logicalType,proto3" json:"logical_type,omitempty"`
+	// Unset when the driver does not know
+	Nullable      *bool `protobuf:"varint,4,opt,name=nullable,proto3,oneof" json:"nullable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultColumn) Reset() {
+	*x = ResultColumn{}
+	mi := &file_proto_sql_runner_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultColumn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultColumn) ProtoMessage() {}
+
+func (x *ResultColumn) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultColumn.ProtoReflect.Descriptor instead.
+func (*ResultColumn) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ResultColumn) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ResultColumn) GetNativeType() string {
+	if x != nil {
+		return x.NativeType
+	}
+	return ""
+}
+
+func (x *ResultColumn) GetLogicalType() string {
+	if x != nil {
+		return x.LogicalType
+	}
+	return ""
+}
+
+func (x *ResultColumn) GetNullable() bool {
+	if x != nil && x.Nullable != nil {
+		return *x.Nullable
+	}
+	return false
+}
+
+// Result of a schema refresh
+type SchemaResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schemas       []*SchemaDescription   `protobuf:"bytes,1,rep,name=schemas,proto3" json:"schemas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchemaResult) Reset() {
+	*x = SchemaResult{}
+	mi := &file_proto_sql_runner_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchemaResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchemaResult) ProtoMessage() {}
+
+func (x *SchemaResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchemaResult.ProtoReflect.Descriptor instead.
+func (*SchemaResult) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SchemaResult) GetSchemas() []*SchemaDescription {
+	if x != nil {
+		return x.Schemas
+	}
+	return nil
+}
+
+type SchemaDescription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Comment       *string                `protobuf:"bytes,2,opt,name=comment,proto3,oneof" json:"comment,omitempty"`
+	Tables        []*TableDescription    `protobuf:"bytes,3,rep,name=tables,proto3" json:"tables,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchemaDescription) Reset() {
+	*x = SchemaDescription{}
+	mi := &file_proto_sql_runner_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchemaDescription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchemaDescription) ProtoMessage() {}
+
+func (x *SchemaDescription) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchemaDescription.ProtoReflect.Descriptor instead.
+func (*SchemaDescription) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SchemaDescription) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SchemaDescription) GetComment() string {
+	if x != nil && x.Comment != nil {
+		return *x.Comment
+	}
+	return ""
+}
+
+func (x *SchemaDescription) GetTables() []*TableDescription {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+type TableDescription struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// table, view, materialized_view or foreign_table
+	Type          string               `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Comment       *string              `protobuf:"bytes,3,opt,name=comment,proto3,oneof" json:"comment,omitempty"`
+	Columns       []*ColumnDescription `protobuf:"bytes,4,rep,name=columns,proto3" json:"columns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TableDescription) Reset() {
+	*x = TableDescription{}
+	mi := &file_proto_sql_runner_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TableDescription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TableDescription) ProtoMessage() {}
+
+func (x *TableDescription) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TableDescription.ProtoReflect.Descriptor instead.
+func (*TableDescription) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *TableDescription) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TableDescription) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TableDescription) GetComment() string {
+	if x != nil && x.Comment != nil {
+		return *x.Comment
+	}
+	return ""
+}
+
+func (x *TableDescription) GetColumns() []*ColumnDescription {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+type ColumnDescription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DataType      string                 `protobuf:"bytes,2,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`
+	Nullable      bool                   `protobuf:"varint,3,opt,name=nullable,proto3" json:"nullable,omitempty"`
+	Default       *string                `protobuf:"bytes,4,opt,name=default,proto3,oneof" json:"default,omitempty"`
+	Comment       *string                `protobuf:"bytes,5,opt,name=comment,proto3,oneof" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ColumnDescription) Reset() {
+	*x = ColumnDescription{}
+	mi := &file_proto_sql_runner_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ColumnDescription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColumnDescription) ProtoMessage() {}
+
+func (x *ColumnDescription) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColumnDescription.ProtoReflect.Descriptor instead.
+func (*ColumnDescription) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ColumnDescription) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ColumnDescription) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *ColumnDescription) GetNullable() bool {
+	if x != nil {
+		return x.Nullable
+	}
+	return false
+}
+
+func (x *ColumnDescription) GetDefault() string {
+	if x != nil && x.Default != nil {
+		return *x.Default
+	}
+	return ""
+}
+
+func (x *ColumnDescription) GetComment() string {
+	if x != nil && x.Comment != nil {
+		return *x.Comment
+	}
+	return ""
+}
+
+// Result of a fetch columns job
+type ColumnsResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SchemaName    string                 `protobuf:"bytes,1,opt,name=schema_name,json=schemaName,proto3" json:"schema_name,omitempty"`
+	Table         string                 `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	Columns       []*ColumnDetail        `protobuf:"bytes,3,rep,name=columns,proto3" json:"columns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ColumnsResult) Reset() {
+	*x = ColumnsResult{}
+	mi := &file_proto_sql_runner_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ColumnsResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColumnsResult) ProtoMessage() {}
+
+func (x *ColumnsResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColumnsResult.ProtoReflect.Descriptor instead.
+func (*ColumnsResult) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ColumnsResult) GetSchemaName() string {
+	if x != nil {
+		return x.SchemaName
+	}
+	return ""
+}
+
+func (x *ColumnsResult) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *ColumnsResult) GetColumns() []*ColumnDetail {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+type ColumnDetail struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Name       string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Position   int32                  `protobuf:"varint,2,opt,name=position,proto3" json:"position,omitempty"`
+	NativeType string                 `protobuf:"bytes,3,opt,name=native_type,json=nativeType,proto3" json:"native_type,omitempty"`
+	// string, int, decimal, time, bool, json or binary
+	LogicalType        string  `protobuf:"bytes,4,opt,name=logical_type,json=logicalType,proto3" json:"logical_type,omitempty"`
+	Nullable           bool    `protobuf:"varint,5,opt,name=nullable,proto3" json:"nullable,omitempty"`
+	Default            *string `protobuf:"bytes,6,opt,name=default,proto3,oneof" json:"default,omitempty"`
+	PrimaryKey         bool    `protobuf:"varint,7,opt,name=primary_key,json=primaryKey,proto3" json:"primary_key,omitempty"`
+	CharacterMaxLength *int64  `protobuf:"varint,8,opt,name=character_max_length,json=characterMaxLength,proto3,oneof" json:"character_max_length,omitempty"`
+	NumericPrecision   *int64  `protobuf:"varint,9,opt,name=numeric_precision,json=numericPrecision,proto3,oneof" json:"numeric_precision,omitempty"`
+	NumericScale       *int64  `protobuf:"varint,10,opt,name=numeric_scale,json=numericScale,proto3,oneof" json:"numeric_scale,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ColumnDetail) Reset() {
+	*x = ColumnDetail{}
+	mi := &file_proto_sql_runner_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ColumnDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColumnDetail) ProtoMessage() {}
+
+func (x *ColumnDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColumnDetail.ProtoReflect.Descriptor instead.
+func (*ColumnDetail) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ColumnDetail) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ColumnDetail) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *ColumnDetail) GetNativeType() string {
+	if x != nil {
+		return x.NativeType
+	}
+	return ""
+}
+
+func (x *ColumnDetail) GetLogicalType() string {
+	if x != nil {
+		return x.LogicalType
+	}
+	return ""
+}
+
+func (x *ColumnDetail) GetNullable() bool {
+	if x != nil {
+		return x.Nullable
+	}
+	return false
+}
+
+func (x *ColumnDetail) GetDefault() string {
+	if x != nil && x.Default != nil {
+		return *x.Default
+	}
+	return ""
+}
+
+func (x *ColumnDetail) GetPrimaryKey() bool {
+	if x != nil {
+		return x.PrimaryKey
+	}
+	return false
+}
+
+func (x *ColumnDetail) GetCharacterMaxLength() int64 {
+	if x != nil && x.CharacterMaxLength != nil {
+		return *x.CharacterMaxLength
+	}
+	return 0
+}
+
+func (x *ColumnDetail) GetNumericPrecision() int64 {
+	if x != nil && x.NumericPrecision != nil {
+		return *x.NumericPrecision
+	}
+	return 0
+}
+
+func (x *ColumnDetail) GetNumericScale() int64 {
+	if x != nil && x.NumericScale != nil {
+		return *x.NumericScale
+	}
+	return 0
+}
+
+type UpdateJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateJobResponse) Reset() {
+	*x = UpdateJobResponse{}
+	mi := &file_proto_sql_runner_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateJobResponse) ProtoMessage() {}
+
+func (x *UpdateJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateJobResponse.ProtoReflect.Descriptor instead.
+func (*UpdateJobResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UpdateJobResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type UploadJobResultRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Part:
+	//
+	//	*UploadJobResultRequest_Header
+	//	*UploadJobResultRequest_Chunk
+	//	*UploadJobResultRequest_Summary
+	Part          isUploadJobResultRequest_Part `protobuf_oneof:"part"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadJobResultRequest) Reset() {
+	*x = UploadJobResultRequest{}
+	mi := &file_proto_sql_runner_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadJobResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadJobResultRequest) ProtoMessage() {}
+
+func (x *UploadJobResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadJobResultRequest.ProtoReflect.Descriptor instead.
+func (*UploadJobResultRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *UploadJobResultRequest) GetPart() isUploadJobResultRequest_Part {
+	if x != nil {
+		return x.Part
+	}
+	return nil
+}
+
+func (x *UploadJobResultRequest) GetHeader() *ResultHeader {
+	if x != nil {
+		if x, ok := x.Part.(*UploadJobResultRequest_Header); ok {
+			return x.Header
+		}
+	}
+	return nil
+}
+
+func (x *UploadJobResultRequest) GetChunk() *ResultChunk {
+	if x != nil {
+		if x, ok := x.Part.(*UploadJobResultRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *UploadJobResultRequest) GetSummary() *ResultSummary {
+	if x != nil {
+		if x, ok := x.Part.(*UploadJobResultRequest_Summary); ok {
+			return x.Summary
+		}
+	}
+	return nil
+}
+
+type isUploadJobResultRequest_Part interface {
+	isUploadJobResultRequest_Part()
+}
+
+type UploadJobResultRequest_Header struct {
+	Header *ResultHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type UploadJobResultRequest_Chunk struct {
+	Chunk *ResultChunk `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+type UploadJobResultRequest_Summary struct {
+	Summary *ResultSummary `protobuf:"bytes,3,opt,name=summary,proto3,oneof"`
+}
+
+func (*UploadJobResultRequest_Header) isUploadJobResultRequest_Part() {}
+
+func (*UploadJobResultRequest_Chunk) isUploadJobResultRequest_Part() {}
+
+func (*UploadJobResultRequest_Summary) isUploadJobResultRequest_Part() {}
+
+// First message of an upload
+type ResultHeader struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	JobId   string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	AgentId string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Columns []string               `protobuf:"bytes,3,rep,name=columns,proto3" json:"columns,omitempty"`
+	// Metadata of columns, in the same order
+	ColumnTypes []*ResultColumn `protobuf:"bytes,4,rep,name=column_types,json=columnTypes,proto3" json:"column_types,omitempty"`
+	// Format of the chunks, rows, columnar or arrow
+	ResultFormat  string `protobuf:"bytes,5,opt,name=result_format,json=resultFormat,proto3" json:"result_format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultHeader) Reset() {
+	*x = ResultHeader{}
+	mi := &file_proto_sql_runner_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultHeader) ProtoMessage() {}
+
+func (x *ResultHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultHeader.ProtoReflect.Descriptor instead.
+func (*ResultHeader) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ResultHeader) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *ResultHeader) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *ResultHeader) GetColumns() []string {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *ResultHeader) GetColumnTypes() []*ResultColumn {
+	if x != nil {
+		return x.ColumnTypes
+	}
+	return nil
+}
+
+func (x *ResultHeader) GetResultFormat() string {
+	if x != nil {
+		return x.ResultFormat
+	}
+	return ""
+}
+
+// A batch of rows, encoded as a JSON array of row objects, or of arrays of
+// values in column order in the columnar result format. In the arrow
+// result format rows_json and row_count are unset, and the data of all
+// chunks concatenated is an Arrow IPC stream.
+type ResultChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RowsJson      string                 `protobuf:"bytes,1,opt,name=rows_json,json=rowsJson,proto3" json:"rows_json,omitempty"`
+	RowCount      int32                  `protobuf:"varint,2,opt,name=row_count,json=rowCount,proto3" json:"row_count,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultChunk) Reset() {
+	*x = ResultChunk{}
+	mi := &file_proto_sql_runner_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultChunk) ProtoMessage() {}
+
+func (x *ResultChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultChunk.ProtoReflect.Descriptor instead.
+func (*ResultChunk) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ResultChunk) GetRowsJson() string {
+	if x != nil {
+		return x.RowsJson
+	}
+	return ""
+}
+
+func (x *ResultChunk) GetRowCount() int32 {
+	if x != nil {
+		return x.RowCount
+	}
+	return 0
+}
+
+func (x *ResultChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Last message of an upload, completing the job
+type ResultSummary struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Success      bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	RowCount     int64                  `protobuf:"varint,2,opt,name=row_count,json=rowCount,proto3" json:"row_count,omitempty"`
+	ErrorMessage string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	NextCursor   string                 `protobuf:"bytes,4,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	// Same as in UpdateJobRequest
+	ErrorCode          ErrorCode `protobuf:"varint,5,opt,name=error_code,json=errorCode,proto3,enum=sql.v1.ErrorCode" json:"error_code,omitempty"`
+	NativeErrorCode    string    `protobuf:"bytes,6,opt,name=native_error_code,json=nativeErrorCode,proto3" json:"native_error_code,omitempty"`
+	NativeErrorMessage string    `protobuf:"bytes,7,opt,name=native_error_message,json=nativeErrorMessage,proto3" json:"native_error_message,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ResultSummary) Reset() {
+	*x = ResultSummary{}
+	mi := &file_proto_sql_runner_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultSummary) ProtoMessage() {}
+
+func (x *ResultSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultSummary.ProtoReflect.Descriptor instead.
+func (*ResultSummary) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ResultSummary) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ResultSummary) GetRowCount() int64 {
+	if x != nil {
+		return x.RowCount
+	}
+	return 0
+}
+
+func (x *ResultSummary) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *ResultSummary) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *ResultSummary) GetErrorCode() ErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *ResultSummary) GetNativeErrorCode() string {
+	if x != nil {
+		return x.NativeErrorCode
+	}
+	return ""
+}
+
+func (x *ResultSummary) GetNativeErrorMessage() string {
+	if x != nil {
+		return x.NativeErrorMessage
+	}
+	return ""
+}
+
+type UploadJobResultResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HeartbeatRequest) Reset() {
-	*x = HeartbeatRequest{}
-	mi := &file_proto_sql_runner_proto_msgTypes[0]
+func (x *UploadJobResultResponse) Reset() {
+	*x = UploadJobResultResponse{}
+	mi := &file_proto_sql_runner_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HeartbeatRequest) String() string {
+func (x *UploadJobResultResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HeartbeatRequest) ProtoMessage() {}
+func (*UploadJobResultResponse) ProtoMessage() {}
 
-func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_sql_runner_proto_msgTypes[0]
+func (x *UploadJobResultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -111,40 +2462,46 @@ func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
-func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
-	return file_proto_sql_runner_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use UploadJobResultResponse.ProtoReflect.Descriptor instead.
+func (*UploadJobResultResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *HeartbeatRequest) GetAgentId() string {
+func (x *UploadJobResultResponse) GetSuccess() bool {
 	if x != nil {
-		return x.AgentId
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-type HeartbeatResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+type AgentMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*AgentMessage_Hello
+	//	*AgentMessage_Ready
+	//	*AgentMessage_Ack
+	//	*AgentMessage_Result
+	Message       isAgentMessage_Message `protobuf_oneof:"message"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HeartbeatResponse) Reset() {
-	*x = HeartbeatResponse{}
-	mi := &file_proto_sql_runner_proto_msgTypes[1]
+func (x *AgentMessage) Reset() {
+	*x = AgentMessage{}
+	mi := &file_proto_sql_runner_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HeartbeatResponse) String() string {
+func (x *AgentMessage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HeartbeatResponse) ProtoMessage() {}
+func (*AgentMessage) ProtoMessage() {}
 
-func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_sql_runner_proto_msgTypes[1]
+func (x *AgentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -155,41 +2512,106 @@ func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
-func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
-	return file_proto_sql_runner_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use AgentMessage.ProtoReflect.Descriptor instead.
+func (*AgentMessage) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *HeartbeatResponse) GetSuccess() bool {
+func (x *AgentMessage) GetMessage() isAgentMessage_Message {
 	if x != nil {
-		return x.Success
+		return x.Message
 	}
-	return false
+	return nil
 }
 
-type GetJobRequest struct {
+func (x *AgentMessage) GetHello() *AgentHello {
+	if x != nil {
+		if x, ok := x.Message.(*AgentMessage_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetReady() *JobReady {
+	if x != nil {
+		if x, ok := x.Message.(*AgentMessage_Ready); ok {
+			return x.Ready
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetAck() *JobAck {
+	if x != nil {
+		if x, ok := x.Message.(*AgentMessage_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetResult() *UpdateJobRequest {
+	if x != nil {
+		if x, ok := x.Message.(*AgentMessage_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isAgentMessage_Message interface {
+	isAgentMessage_Message()
+}
+
+type AgentMessage_Hello struct {
+	Hello *AgentHello `protobuf:"bytes,1,opt,name=hello,proto3,oneof"`
+}
+
+type AgentMessage_Ready struct {
+	Ready *JobReady `protobuf:"bytes,2,opt,name=ready,proto3,oneof"`
+}
+
+type AgentMessage_Ack struct {
+	Ack *JobAck `protobuf:"bytes,3,opt,name=ack,proto3,oneof"`
+}
+
+type AgentMessage_Result struct {
+	Result *UpdateJobRequest `protobuf:"bytes,4,opt,name=result,proto3,oneof"`
+}
+
+func (*AgentMessage_Hello) isAgentMessage_Message() {}
+
+func (*AgentMessage_Ready) isAgentMessage_Message() {}
+
+func (*AgentMessage_Ack) isAgentMessage_Message() {}
+
+func (*AgentMessage_Result) isAgentMessage_Message() {}
+
+// First message of a job stream
+type AgentHello struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
-	SupportedKinds []JobKind              `protobuf:"varint,1,rep,packed,name=supported_kinds,json=supportedKinds,proto3,enum=sql.v1.JobKind" json:"supported_kinds,omitempty"`
-	AgentId        string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	AgentId        string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	SupportedKinds []JobKind              `protobuf:"varint,2,rep,packed,name=supported_kinds,json=supportedKinds,proto3,enum=sql.v1.JobKind" json:"supported_kinds,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
-func (x *GetJobRequest) Reset() {
-	*x = GetJobRequest{}
-	mi := &file_proto_sql_runner_proto_msgTypes[2]
+func (x *AgentHello) Reset() {
+	*x = AgentHello{}
+	mi := &file_proto_sql_runner_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetJobRequest) String() string {
+func (x *AgentHello) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetJobRequest) ProtoMessage() {}
+func (*AgentHello) ProtoMessage() {}
 
-func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_sql_runner_proto_msgTypes[2]
+func (x *AgentHello) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -200,48 +2622,49 @@ func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
-func (*GetJobRequest) Descriptor() ([]byte, []int) {
-	return file_proto_sql_runner_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use AgentHello.ProtoReflect.Descriptor instead.
+func (*AgentHello) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *GetJobRequest) GetSupportedKinds() []JobKind {
+func (x *AgentHello) GetAgentId() string {
 	if x != nil {
-		return x.SupportedKinds
+		return x.AgentId
 	}
-	return nil
+	return ""
 }
 
-func (x *GetJobRequest) GetAgentId() string {
+func (x *AgentHello) GetSupportedKinds() []JobKind {
 	if x != nil {
-		return x.AgentId
+		return x.SupportedKinds
 	}
-	return ""
+	return nil
 }
 
-type GetJobResponse struct {
+// The agent can take this many more jobs, only of the given kinds when set
+type JobReady struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	HasJob        bool                   `protobuf:"varint,1,opt,name=has_job,json=hasJob,proto3" json:"has_job,omitempty"`
-	Job           *Job                   `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	Credits       int32                  `protobuf:"varint,1,opt,name=credits,proto3" json:"credits,omitempty"`
+	Kinds         []JobKind              `protobuf:"varint,2,rep,packed,name=kinds,proto3,enum=sql.v1.JobKind" json:"kinds,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetJobResponse) Reset() {
-	*x = GetJobResponse{}
-	mi := &file_proto_sql_runner_proto_msgTypes[3]
+func (x *JobReady) Reset() {
+	*x = JobReady{}
+	mi := &file_proto_sql_runner_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetJobResponse) String() string {
+func (x *JobReady) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetJobResponse) ProtoMessage() {}
+func (*JobReady) ProtoMessage() {}
 
-func (x *GetJobResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_sql_runner_proto_msgTypes[3]
+func (x *JobReady) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -252,49 +2675,48 @@ func (x *GetJobResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetJobResponse.ProtoReflect.Descriptor instead.
-func (*GetJobResponse) Descriptor() ([]byte, []int) {
-	return file_proto_sql_runner_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use JobReady.ProtoReflect.Descriptor instead.
+func (*JobReady) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *GetJobResponse) GetHasJob() bool {
+func (x *JobReady) GetCredits() int32 {
 	if x != nil {
-		return x.HasJob
+		return x.Credits
 	}
-	return false
+	return 0
 }
 
-func (x *GetJobResponse) GetJob() *Job {
+func (x *JobReady) GetKinds() []JobKind {
 	if x != nil {
-		return x.Job
+		return x.Kinds
 	}
 	return nil
 }
 
-type Job struct {
+// The agent received a job and started it
+type JobAck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Kind          JobKind                `protobuf:"varint,2,opt,name=kind,proto3,enum=sql.v1.JobKind" json:"kind,omitempty"`
-	PayloadJson   string                 `protobuf:"bytes,3,opt,name=payload_json,json=payloadJson,proto3" json:"payload_json,omitempty"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Job) Reset() {
-	*x = Job{}
-	mi := &file_proto_sql_runner_proto_msgTypes[4]
+func (x *JobAck) Reset() {
+	*x = JobAck{}
+	mi := &file_proto_sql_runner_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Job) String() string {
+func (x *JobAck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Job) ProtoMessage() {}
+func (*JobAck) ProtoMessage() {}
 
-func (x *Job) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_sql_runner_proto_msgTypes[4]
+func (x *JobAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -305,58 +2727,140 @@ func (x *Job) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Job.ProtoReflect.Descriptor instead.
-func (*Job) Descriptor() ([]byte, []int) {
-	return file_proto_sql_runner_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use JobAck.ProtoReflect.Descriptor instead.
+func (*JobAck) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *Job) GetId() string {
+func (x *JobAck) GetJobId() string {
 	if x != nil {
-		return x.Id
+		return x.JobId
 	}
 	return ""
 }
 
-func (x *Job) GetKind() JobKind {
+type ServerMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*ServerMessage_Job
+	//	*ServerMessage_ResultAck
+	//	*ServerMessage_Cancel
+	Message       isServerMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerMessage) Reset() {
+	*x = ServerMessage{}
+	mi := &file_proto_sql_runner_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerMessage) ProtoMessage() {}
+
+func (x *ServerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[34]
 	if x != nil {
-		return x.Kind
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return JobKind_JOB_KIND_UNSPECIFIED
+	return mi.MessageOf(x)
 }
 
-func (x *Job) GetPayloadJson() string {
+// Deprecated: Use ServerMessage.ProtoReflect.Descriptor instead.
+func (*ServerMessage) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ServerMessage) GetMessage() isServerMessage_Message {
 	if x != nil {
-		return x.PayloadJson
+		return x.Message
 	}
-	return ""
+	return nil
 }
 
-type UpdateJobRequest struct {
+func (x *ServerMessage) GetJob() *Job {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_Job); ok {
+			return x.Job
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetResultAck() *JobResultAck {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_ResultAck); ok {
+			return x.ResultAck
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetCancel() *CancelJob {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_Cancel); ok {
+			return x.Cancel
+		}
+	}
+	return nil
+}
+
+type isServerMessage_Message interface {
+	isServerMessage_Message()
+}
+
+type ServerMessage_Job struct {
+	Job *Job `protobuf:"bytes,1,opt,name=job,proto3,oneof"`
+}
+
+type ServerMessage_ResultAck struct {
+	ResultAck *JobResultAck `protobuf:"bytes,2,opt,name=result_ack,json=resultAck,proto3,oneof"`
+}
+
+type ServerMessage_Cancel struct {
+	Cancel *CancelJob `protobuf:"bytes,3,opt,name=cancel,proto3,oneof"`
+}
+
+func (*ServerMessage_Job) isServerMessage_Message() {}
+
+func (*ServerMessage_ResultAck) isServerMessage_Message() {}
+
+func (*ServerMessage_Cancel) isServerMessage_Message() {}
+
+// The server stored the result of a job
+type JobResultAck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
-	AgentId       string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	Success       bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
-	ResultJson    string                 `protobuf:"bytes,4,opt,name=result_json,json=resultJson,proto3" json:"result_json,omitempty"`
-	ErrorMessage  string                 `protobuf:"bytes,5,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateJobRequest) Reset() {
-	*x = UpdateJobRequest{}
-	mi := &file_proto_sql_runner_proto_msgTypes[5]
+func (x *JobResultAck) Reset() {
+	*x = JobResultAck{}
+	mi := &file_proto_sql_runner_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateJobRequest) String() string {
+func (x *JobResultAck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateJobRequest) ProtoMessage() {}
+func (*JobResultAck) ProtoMessage() {}
 
-func (x *UpdateJobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_sql_runner_proto_msgTypes[5]
+func (x *JobResultAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -367,68 +2871,48 @@ func (x *UpdateJobRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateJobRequest.ProtoReflect.Descriptor instead.
-func (*UpdateJobRequest) Descriptor() ([]byte, []int) {
-	return file_proto_sql_runner_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use JobResultAck.ProtoReflect.Descriptor instead.
+func (*JobResultAck) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *UpdateJobRequest) GetJobId() string {
+func (x *JobResultAck) GetJobId() string {
 	if x != nil {
 		return x.JobId
 	}
 	return ""
 }
 
-func (x *UpdateJobRequest) GetAgentId() string {
-	if x != nil {
-		return x.AgentId
-	}
-	return ""
-}
-
-func (x *UpdateJobRequest) GetSuccess() bool {
+func (x *JobResultAck) GetSuccess() bool {
 	if x != nil {
 		return x.Success
 	}
 	return false
 }
 
-func (x *UpdateJobRequest) GetResultJson() string {
-	if x != nil {
-		return x.ResultJson
-	}
-	return ""
-}
-
-func (x *UpdateJobRequest) GetErrorMessage() string {
-	if x != nil {
-		return x.ErrorMessage
-	}
-	return ""
-}
-
-type UpdateJobResponse struct {
+// Stop a running job; the agent reports it with cancelled set
+type CancelJob struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateJobResponse) Reset() {
-	*x = UpdateJobResponse{}
-	mi := &file_proto_sql_runner_proto_msgTypes[6]
+func (x *CancelJob) Reset() {
+	*x = CancelJob{}
+	mi := &file_proto_sql_runner_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateJobResponse) String() string {
+func (x *CancelJob) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateJobResponse) ProtoMessage() {}
+func (*CancelJob) ProtoMessage() {}
 
-func (x *UpdateJobResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_sql_runner_proto_msgTypes[6]
+func (x *CancelJob) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sql_runner_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -439,57 +2923,279 @@ func (x *UpdateJobResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateJobResponse.ProtoReflect.Descriptor instead.
-func (*UpdateJobResponse) Descriptor() ([]byte, []int) {
-	return file_proto_sql_runner_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use CancelJob.ProtoReflect.Descriptor instead.
+func (*CancelJob) Descriptor() ([]byte, []int) {
+	return file_proto_sql_runner_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *UpdateJobResponse) GetSuccess() bool {
+func (x *CancelJob) GetJobId() string {
 	if x != nil {
-		return x.Success
+		return x.JobId
 	}
-	return false
+	return ""
 }
 
 var File_proto_sql_runner_proto protoreflect.FileDescriptor
 
 const file_proto_sql_runner_proto_rawDesc = "" +
 	"\n" +
-	"\x16proto/sql_runner.proto\x12\x06sql.v1\"-\n" +
+	"\x16proto/sql_runner.proto\x12\x06sql.v1\x1a\x1cgoogle/protobuf/struct.proto\"\xf3\x02\n" +
+	"\x0fRegisterRequest\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12#\n" +
+	"\ragent_version\x18\x02 \x01(\tR\fagentVersion\x12)\n" +
+	"\x10protocol_version\x18\x03 \x01(\x05R\x0fprotocolVersion\x12\x1f\n" +
+	"\vengine_type\x18\x04 \x01(\tR\n" +
+	"engineType\x12)\n" +
+	"\x10database_version\x18\x05 \x01(\tR\x0fdatabaseVersion\x128\n" +
+	"\x0fsupported_kinds\x18\x06 \x03(\x0e2\x0f.sql.v1.JobKindR\x0esupportedKinds\x12'\n" +
+	"\x0fcondition_types\x18\a \x03(\tR\x0econditionTypes\x12\x1a\n" +
+	"\bfeatures\x18\b \x03(\tR\bfeatures\x12*\n" +
+	"\x11max_payload_bytes\x18\t \x01(\x03R\x0fmaxPayloadBytes\"q\n" +
+	"\x10RegisterResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12)\n" +
+	"\x10protocol_version\x18\x03 \x01(\x05R\x0fprotocolVersion\"R\n" +
 	"\x10HeartbeatRequest\x12\x19\n" +
-	"\bagent_id\x18\x01 \x01(\tR\aagentId\"-\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12#\n" +
+	"\rshutting_down\x18\x02 \x01(\bR\fshuttingDown\"Y\n" +
 	"\x11HeartbeatResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"d\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12*\n" +
+	"\x11cancelled_job_ids\x18\x02 \x03(\tR\x0fcancelledJobIds\"d\n" +
 	"\rGetJobRequest\x128\n" +
 	"\x0fsupported_kinds\x18\x01 \x03(\x0e2\x0f.sql.v1.JobKindR\x0esupportedKinds\x12\x19\n" +
 	"\bagent_id\x18\x02 \x01(\tR\aagentId\"H\n" +
 	"\x0eGetJobResponse\x12\x17\n" +
 	"\ahas_job\x18\x01 \x01(\bR\x06hasJob\x12\x1d\n" +
-	"\x03job\x18\x02 \x01(\v2\v.sql.v1.JobR\x03job\"]\n" +
+	"\x03job\x18\x02 \x01(\v2\v.sql.v1.JobR\x03job\"\xa7\x03\n" +
 	"\x03Job\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12#\n" +
 	"\x04kind\x18\x02 \x01(\x0e2\x0f.sql.v1.JobKindR\x04kind\x12!\n" +
-	"\fpayload_json\x18\x03 \x01(\tR\vpayloadJson\"\xa4\x01\n" +
+	"\fpayload_json\x18\x03 \x01(\tR\vpayloadJson\x12#\n" +
+	"\rstream_result\x18\x04 \x01(\bR\fstreamResult\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\x05 \x01(\x03R\ttimeoutMs\x126\n" +
+	"\tdsl_query\x18\x06 \x01(\v2\x17.sql.v1.DslQueryPayloadH\x00R\bdslQuery\x126\n" +
+	"\traw_query\x18\a \x01(\v2\x17.sql.v1.RawQueryPayloadH\x00R\brawQuery\x12B\n" +
+	"\rfetch_columns\x18\b \x01(\v2\x1b.sql.v1.FetchColumnsPayloadH\x00R\ffetchColumns\x12E\n" +
+	"\x0eschema_refresh\x18\t \x01(\v2\x1c.sql.v1.SchemaRefreshPayloadH\x00R\rschemaRefreshB\t\n" +
+	"\apayload\"\xad\x04\n" +
+	"\x0fDslQueryPayload\x12#\n" +
+	"\rdatabase_type\x18\x01 \x01(\tR\fdatabaseType\x12$\n" +
+	"\vschema_name\x18\x02 \x01(\tH\x00R\n" +
+	"schemaName\x88\x01\x01\x12\x14\n" +
+	"\x05table\x18\x03 \x01(\tR\x05table\x12\x1a\n" +
+	"\bdistinct\x18\x04 \x01(\bR\bdistinct\x124\n" +
+	"\vprojections\x18\x05 \x03(\v2\x12.sql.v1.ProjectionR\vprojections\x12\x1b\n" +
+	"\x06select\x18\x06 \x01(\tH\x01R\x06select\x88\x01\x01\x121\n" +
+	"\n" +
+	"conditions\x18\a \x03(\v2\x11.sql.v1.ConditionR\n" +
+	"conditions\x12\x19\n" +
+	"\x05limit\x18\b \x01(\x05H\x02R\x05limit\x88\x01\x01\x12\x19\n" +
+	"\bgroup_by\x18\t \x03(\tR\agroupBy\x12)\n" +
+	"\x06having\x18\n" +
+	" \x03(\v2\x11.sql.v1.ConditionR\x06having\x12,\n" +
+	"\border_by\x18\v \x03(\v2\x11.sql.v1.OrderTermR\aorderBy\x12\x1b\n" +
+	"\x06offset\x18\f \x01(\x05H\x03R\x06offset\x88\x01\x01\x12\x16\n" +
+	"\x06cursor\x18\r \x01(\tR\x06cursor\x12#\n" +
+	"\rresult_format\x18\x0e \x01(\tR\fresultFormatB\x0e\n" +
+	"\f_schema_nameB\t\n" +
+	"\a_selectB\b\n" +
+	"\x06_limitB\t\n" +
+	"\a_offset\"X\n" +
+	"\n" +
+	"Projection\x12\x16\n" +
+	"\x06column\x18\x01 \x01(\tR\x06column\x12\x1c\n" +
+	"\taggregate\x18\x02 \x01(\tR\taggregate\x12\x14\n" +
+	"\x05alias\x18\x03 \x01(\tR\x05alias\"\xf2\x01\n" +
+	"\tCondition\x12\x16\n" +
+	"\x06column\x18\x01 \x01(\tR\x06column\x12\x1c\n" +
+	"\taggregate\x18\x02 \x01(\tR\taggregate\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12,\n" +
+	"\x05value\x18\x04 \x01(\v2\x16.google.protobuf.ValueR\x05value\x12#\n" +
+	"\x03all\x18\x05 \x03(\v2\x11.sql.v1.ConditionR\x03all\x12#\n" +
+	"\x03any\x18\x06 \x03(\v2\x11.sql.v1.ConditionR\x03any\x12#\n" +
+	"\x03not\x18\a \x01(\v2\x11.sql.v1.ConditionR\x03not\"u\n" +
+	"\tOrderTerm\x12\x16\n" +
+	"\x06column\x18\x01 \x01(\tR\x06column\x12\x1c\n" +
+	"\taggregate\x18\x02 \x01(\tR\taggregate\x12\x1c\n" +
+	"\tdirection\x18\x03 \x01(\tR\tdirection\x12\x14\n" +
+	"\x05nulls\x18\x04 \x01(\tR\x05nulls\"x\n" +
+	"\x0fRawQueryPayload\x12\x10\n" +
+	"\x03sql\x18\x01 \x01(\tR\x03sql\x12.\n" +
+	"\x06params\x18\x02 \x03(\v2\x16.google.protobuf.ValueR\x06params\x12#\n" +
+	"\rresult_format\x18\x03 \x01(\tR\fresultFormat\"a\n" +
+	"\x13FetchColumnsPayload\x12$\n" +
+	"\vschema_name\x18\x01 \x01(\tH\x00R\n" +
+	"schemaName\x88\x01\x01\x12\x14\n" +
+	"\x05table\x18\x02 \x01(\tR\x05tableB\x0e\n" +
+	"\f_schema_name\"\x16\n" +
+	"\x14SchemaRefreshPayload\"\xf3\x04\n" +
 	"\x10UpdateJobRequest\x12\x15\n" +
 	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x19\n" +
 	"\bagent_id\x18\x02 \x01(\tR\aagentId\x12\x18\n" +
 	"\asuccess\x18\x03 \x01(\bR\asuccess\x12\x1f\n" +
 	"\vresult_json\x18\x04 \x01(\tR\n" +
 	"resultJson\x12#\n" +
-	"\rerror_message\x18\x05 \x01(\tR\ferrorMessage\"-\n" +
+	"\rerror_message\x18\x05 \x01(\tR\ferrorMessage\x12\x1c\n" +
+	"\tcancelled\x18\x06 \x01(\bR\tcancelled\x12\x1b\n" +
+	"\ttimed_out\x18\a \x01(\bR\btimedOut\x120\n" +
+	"\n" +
+	"error_code\x18\b \x01(\x0e2\x11.sql.v1.ErrorCodeR\terrorCode\x12*\n" +
+	"\x11native_error_code\x18\t \x01(\tR\x0fnativeErrorCode\x120\n" +
+	"\x14native_error_message\x18\n" +
+	" \x01(\tR\x12nativeErrorMessage\x128\n" +
+	"\fquery_result\x18\v \x01(\v2\x13.sql.v1.QueryResultH\x00R\vqueryResult\x12;\n" +
+	"\rschema_result\x18\f \x01(\v2\x14.sql.v1.SchemaResultH\x00R\fschemaResult\x12>\n" +
+	"\x0ecolumns_result\x18\r \x01(\v2\x15.sql.v1.ColumnsResultH\x00R\rcolumnsResult\x12A\n" +
+	"\x0fcolumnar_result\x18\x0e \x01(\v2\x16.sql.v1.ColumnarResultH\x00R\x0ecolumnarResultB\b\n" +
+	"\x06result\"x\n" +
+	"\vQueryResult\x12+\n" +
+	"\x04rows\x18\x01 \x03(\v2\x17.google.protobuf.StructR\x04rows\x12\x1b\n" +
+	"\trow_count\x18\x02 \x01(\x03R\browCount\x12\x1f\n" +
+	"\vnext_cursor\x18\x03 \x01(\tR\n" +
+	"nextCursor\"\xae\x01\n" +
+	"\x0eColumnarResult\x12.\n" +
+	"\acolumns\x18\x01 \x03(\v2\x14.sql.v1.ResultColumnR\acolumns\x12.\n" +
+	"\x04rows\x18\x02 \x03(\v2\x1a.google.protobuf.ListValueR\x04rows\x12\x1b\n" +
+	"\trow_count\x18\x03 \x01(\x03R\browCount\x12\x1f\n" +
+	"\vnext_cursor\x18\x04 \x01(\tR\n" +
+	"nextCursor\"\x94\x01\n" +
+	"\fResultColumn\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vnative_type\x18\x02 \x01(\tR\n" +
+	"nativeType\x12!\n" +
+	"\flogical_type\x18\x03 \x01(\tR\vlogicalType\x12\x1f\n" +
+	"\bnullable\x18\x04 \x01(\bH\x00R\bnullable\x88\x01\x01B\v\n" +
+	"\t_nullable\"C\n" +
+	"\fSchemaResult\x123\n" +
+	"\aschemas\x18\x01 \x03(\v2\x19.sql.v1.SchemaDescriptionR\aschemas\"\x84\x01\n" +
+	"\x11SchemaDescription\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\acomment\x18\x02 \x01(\tH\x00R\acomment\x88\x01\x01\x120\n" +
+	"\x06tables\x18\x03 \x03(\v2\x18.sql.v1.TableDescriptionR\x06tablesB\n" +
+	"\n" +
+	"\b_comment\"\x9a\x01\n" +
+	"\x10TableDescription\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1d\n" +
+	"\acomment\x18\x03 \x01(\tH\x00R\acomment\x88\x01\x01\x123\n" +
+	"\acolumns\x18\x04 \x03(\v2\x19.sql.v1.ColumnDescriptionR\acolumnsB\n" +
+	"\n" +
+	"\b_comment\"\xb6\x01\n" +
+	"\x11ColumnDescription\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tdata_type\x18\x02 \x01(\tR\bdataType\x12\x1a\n" +
+	"\bnullable\x18\x03 \x01(\bR\bnullable\x12\x1d\n" +
+	"\adefault\x18\x04 \x01(\tH\x00R\adefault\x88\x01\x01\x12\x1d\n" +
+	"\acomment\x18\x05 \x01(\tH\x01R\acomment\x88\x01\x01B\n" +
+	"\n" +
+	"\b_defaultB\n" +
+	"\n" +
+	"\b_comment\"v\n" +
+	"\rColumnsResult\x12\x1f\n" +
+	"\vschema_name\x18\x01 \x01(\tR\n" +
+	"schemaName\x12\x14\n" +
+	"\x05table\x18\x02 \x01(\tR\x05table\x12.\n" +
+	"\acolumns\x18\x03 \x03(\v2\x14.sql.v1.ColumnDetailR\acolumns\"\xbe\x03\n" +
+	"\fColumnDetail\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bposition\x18\x02 \x01(\x05R\bposition\x12\x1f\n" +
+	"\vnative_type\x18\x03 \x01(\tR\n" +
+	"nativeType\x12!\n" +
+	"\flogical_type\x18\x04 \x01(\tR\vlogicalType\x12\x1a\n" +
+	"\bnullable\x18\x05 \x01(\bR\bnullable\x12\x1d\n" +
+	"\adefault\x18\x06 \x01(\tH\x00R\adefault\x88\x01\x01\x12\x1f\n" +
+	"\vprimary_key\x18\a \x01(\bR\n" +
+	"primaryKey\x125\n" +
+	"\x14character_max_length\x18\b \x01(\x03H\x01R\x12characterMaxLength\x88\x01\x01\x120\n" +
+	"\x11numeric_precision\x18\t \x01(\x03H\x02R\x10numericPrecision\x88\x01\x01\x12(\n" +
+	"\rnumeric_scale\x18\n" +
+	" \x01(\x03H\x03R\fnumericScale\x88\x01\x01B\n" +
+	"\n" +
+	"\b_defaultB\x17\n" +
+	"\x15_character_max_lengthB\x14\n" +
+	"\x12_numeric_precisionB\x10\n" +
+	"\x0e_numeric_scale\"-\n" +
 	"\x11UpdateJobResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess*\x9b\x01\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xb0\x01\n" +
+	"\x16UploadJobResultRequest\x12.\n" +
+	"\x06header\x18\x01 \x01(\v2\x14.sql.v1.ResultHeaderH\x00R\x06header\x12+\n" +
+	"\x05chunk\x18\x02 \x01(\v2\x13.sql.v1.ResultChunkH\x00R\x05chunk\x121\n" +
+	"\asummary\x18\x03 \x01(\v2\x15.sql.v1.ResultSummaryH\x00R\asummaryB\x06\n" +
+	"\x04part\"\xb8\x01\n" +
+	"\fResultHeader\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x19\n" +
+	"\bagent_id\x18\x02 \x01(\tR\aagentId\x12\x18\n" +
+	"\acolumns\x18\x03 \x03(\tR\acolumns\x127\n" +
+	"\fcolumn_types\x18\x04 \x03(\v2\x14.sql.v1.ResultColumnR\vcolumnTypes\x12#\n" +
+	"\rresult_format\x18\x05 \x01(\tR\fresultFormat\"[\n" +
+	"\vResultChunk\x12\x1b\n" +
+	"\trows_json\x18\x01 \x01(\tR\browsJson\x12\x1b\n" +
+	"\trow_count\x18\x02 \x01(\x05R\browCount\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\x9c\x02\n" +
+	"\rResultSummary\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x1b\n" +
+	"\trow_count\x18\x02 \x01(\x03R\browCount\x12#\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\x12\x1f\n" +
+	"\vnext_cursor\x18\x04 \x01(\tR\n" +
+	"nextCursor\x120\n" +
+	"\n" +
+	"error_code\x18\x05 \x01(\x0e2\x11.sql.v1.ErrorCodeR\terrorCode\x12*\n" +
+	"\x11native_error_code\x18\x06 \x01(\tR\x0fnativeErrorCode\x120\n" +
+	"\x14native_error_message\x18\a \x01(\tR\x12nativeErrorMessage\"3\n" +
+	"\x17UploadJobResultResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xc7\x01\n" +
+	"\fAgentMessage\x12*\n" +
+	"\x05hello\x18\x01 \x01(\v2\x12.sql.v1.AgentHelloH\x00R\x05hello\x12(\n" +
+	"\x05ready\x18\x02 \x01(\v2\x10.sql.v1.JobReadyH\x00R\x05ready\x12\"\n" +
+	"\x03ack\x18\x03 \x01(\v2\x0e.sql.v1.JobAckH\x00R\x03ack\x122\n" +
+	"\x06result\x18\x04 \x01(\v2\x18.sql.v1.UpdateJobRequestH\x00R\x06resultB\t\n" +
+	"\amessage\"a\n" +
+	"\n" +
+	"AgentHello\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x128\n" +
+	"\x0fsupported_kinds\x18\x02 \x03(\x0e2\x0f.sql.v1.JobKindR\x0esupportedKinds\"K\n" +
+	"\bJobReady\x12\x18\n" +
+	"\acredits\x18\x01 \x01(\x05R\acredits\x12%\n" +
+	"\x05kinds\x18\x02 \x03(\x0e2\x0f.sql.v1.JobKindR\x05kinds\"\x1f\n" +
+	"\x06JobAck\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\x9f\x01\n" +
+	"\rServerMessage\x12\x1f\n" +
+	"\x03job\x18\x01 \x01(\v2\v.sql.v1.JobH\x00R\x03job\x125\n" +
+	"\n" +
+	"result_ack\x18\x02 \x01(\v2\x14.sql.v1.JobResultAckH\x00R\tresultAck\x12+\n" +
+	"\x06cancel\x18\x03 \x01(\v2\x11.sql.v1.CancelJobH\x00R\x06cancelB\t\n" +
+	"\amessage\"?\n" +
+	"\fJobResultAck\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\"\"\n" +
+	"\tCancelJob\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId*\x9b\x01\n" +
 	"\aJobKind\x12\x18\n" +
 	"\x14JOB_KIND_UNSPECIFIED\x10\x00\x12\x11\n" +
 	"\rJOB_KIND_PING\x10\x01\x12\x12\n" +
 	"\x0eJOB_KIND_QUERY\x10\x02\x12\x16\n" +
 	"\x12JOB_KIND_DSL_QUERY\x10\x03\x12\x1b\n" +
 	"\x17JOB_KIND_SCHEMA_REFRESH\x10\x04\x12\x1a\n" +
-	"\x16JOB_KIND_FETCH_COLUMNS\x10\x052\xc8\x01\n" +
-	"\tSqlRunner\x127\n" +
+	"\x16JOB_KIND_FETCH_COLUMNS\x10\x05*\x9e\x02\n" +
+	"\tErrorCode\x12\x1a\n" +
+	"\x16ERROR_CODE_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15ERROR_CODE_VALIDATION\x10\x01\x12\x1d\n" +
+	"\x19ERROR_CODE_UNKNOWN_COLUMN\x10\x02\x12\x1c\n" +
+	"\x18ERROR_CODE_UNKNOWN_TABLE\x10\x03\x12 \n" +
+	"\x1cERROR_CODE_PERMISSION_DENIED\x10\x04\x12\x19\n" +
+	"\x15ERROR_CODE_CONNECTION\x10\x05\x12\x16\n" +
+	"\x12ERROR_CODE_TIMEOUT\x10\x06\x12\x18\n" +
+	"\x14ERROR_CODE_CANCELLED\x10\a\x12\x15\n" +
+	"\x11ERROR_CODE_SYNTAX\x10\b\x12\x17\n" +
+	"\x13ERROR_CODE_INTERNAL\x10\t2\x9b\x03\n" +
+	"\tSqlRunner\x12=\n" +
+	"\bRegister\x12\x17.sql.v1.RegisterRequest\x1a\x18.sql.v1.RegisterResponse\x127\n" +
 	"\x06GetJob\x12\x15.sql.v1.GetJobRequest\x1a\x16.sql.v1.GetJobResponse\x12@\n" +
 	"\tUpdateJob\x12\x18.sql.v1.UpdateJobRequest\x1a\x19.sql.v1.UpdateJobResponse\x12@\n" +
-	"\tHeartbeat\x12\x18.sql.v1.HeartbeatRequest\x1a\x19.sql.v1.HeartbeatResponseB\x0eZ\f./sql_runnerb\x06proto3"
+	"\tHeartbeat\x12\x18.sql.v1.HeartbeatRequest\x1a\x19.sql.v1.HeartbeatResponse\x12T\n" +
+	"\x0fUploadJobResult\x12\x1e.sql.v1.UploadJobResultRequest\x1a\x1f.sql.v1.UploadJobResultResponse(\x01\x12<\n" +
+	"\tJobStream\x12\x14.sql.v1.AgentMessage\x1a\x15.sql.v1.ServerMessage(\x010\x01B\x0eZ\f./sql_runnerb\x06proto3"
 
 var (
 	file_proto_sql_runner_proto_rawDescOnce sync.Once
@@ -503,33 +3209,113 @@ func file_proto_sql_runner_proto_rawDescGZIP() []byte {
 	return file_proto_sql_runner_proto_rawDescData
 }
 
-var file_proto_sql_runner_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_sql_runner_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_sql_runner_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_sql_runner_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_proto_sql_runner_proto_goTypes = []any{
-	(JobKind)(0),              // 0: sql.v1.JobKind
-	(*HeartbeatRequest)(nil),  // 1: sql.v1.HeartbeatRequest
-	(*HeartbeatResponse)(nil), // 2: sql.v1.HeartbeatResponse
-	(*GetJobRequest)(nil),     // 3: sql.v1.GetJobRequest
-	(*GetJobResponse)(nil),    // 4: sql.v1.GetJobResponse
-	(*Job)(nil),               // 5: sql.v1.Job
-	(*UpdateJobRequest)(nil),  // 6: sql.v1.UpdateJobRequest
-	(*UpdateJobResponse)(nil), // 7: sql.v1.UpdateJobResponse
+	(JobKind)(0),                    // 0: sql.v1.JobKind
+	(ErrorCode)(0),                  // 1: sql.v1.ErrorCode
+	(*RegisterRequest)(nil),         // 2: sql.v1.RegisterRequest
+	(*RegisterResponse)(nil),        // 3: sql.v1.RegisterResponse
+	(*HeartbeatRequest)(nil),        // 4: sql.v1.HeartbeatRequest
+	(*HeartbeatResponse)(nil),       // 5: sql.v1.HeartbeatResponse
+	(*GetJobRequest)(nil),           // 6: sql.v1.GetJobRequest
+	(*GetJobResponse)(nil),          // 7: sql.v1.GetJobResponse
+	(*Job)(nil),                     // 8: sql.v1.Job
+	(*DslQueryPayload)(nil),         // 9: sql.v1.DslQueryPayload
+	(*Projection)(nil),              // 10: sql.v1.Projection
+	(*Condition)(nil),               // 11: sql.v1.Condition
+	(*OrderTerm)(nil),               // 12: sql.v1.OrderTerm
+	(*RawQueryPayload)(nil),         // 13: sql.v1.RawQueryPayload
+	(*FetchColumnsPayload)(nil),     // 14: sql.v1.FetchColumnsPayload
+	(*SchemaRefreshPayload)(nil),    // 15: sql.v1.SchemaRefreshPayload
+	(*UpdateJobRequest)(nil),        // 16: sql.v1.UpdateJobRequest
+	(*QueryResult)(nil),             // 17: sql.v1.QueryResult
+	(*ColumnarResult)(nil),          // 18: sql.v1.ColumnarResult
+	(*ResultColumn)(nil),            // 19: sql.v1.ResultColumn
+	(*SchemaResult)(nil),            // 20: sql.v1.SchemaResult
+	(*SchemaDescription)(nil),       // 21: sql.v1.SchemaDescription
+	(*TableDescription)(nil),        // 22: sql.v1.TableDescription
+	(*ColumnDescription)(nil),       // 23: sql.v1.ColumnDescription
+	(*ColumnsResult)(nil),           // 24: sql.v1.ColumnsResult
+	(*ColumnDetail)(nil),            // 25: sql.v1.ColumnDetail
+	(*UpdateJobResponse)(nil),       // 26: sql.v1.UpdateJobResponse
+	(*UploadJobResultRequest)(nil),  // 27: sql.v1.UploadJobResultRequest
+	(*ResultHeader)(nil),            // 28: sql.v1.ResultHeader
+	(*ResultChunk)(nil),             // 29: sql.v1.ResultChunk
+	(*ResultSummary)(nil),           // 30: sql.v1.ResultSummary
+	(*UploadJobResultResponse)(nil), // 31: sql.v1.UploadJobResultResponse
+	(*AgentMessage)(nil),            // 32: sql.v1.AgentMessage
+	(*AgentHello)(nil),              // 33: sql.v1.AgentHello
+	(*JobReady)(nil),                // 34: sql.v1.JobReady
+	(*JobAck)(nil),                  // 35: sql.v1.JobAck
+	(*ServerMessage)(nil),           // 36: sql.v1.ServerMessage
+	(*JobResultAck)(nil),            // 37: sql.v1.JobResultAck
+	(*CancelJob)(nil),               // 38: sql.v1.CancelJob
+	(*structpb.Value)(nil),          // 39: google.protobuf.Value
+	(*structpb.Struct)(nil),         // 40: google.protobuf.Struct
+	(*structpb.ListValue)(nil),      // 41: google.protobuf.ListValue
 }
 var file_proto_sql_runner_proto_depIdxs = []int32{
-	0, // 0: sql.v1.GetJobRequest.supported_kinds:type_name -> sql.v1.JobKind
-	5, // 1: sql.v1.GetJobResponse.job:type_name -> sql.v1.Job
-	0, // 2: sql.v1.Job.kind:type_name -> sql.v1.JobKind
-	3, // 3: sql.v1.SqlRunner.GetJob:input_type -> sql.v1.GetJobRequest
-	6, // 4: sql.v1.SqlRunner.UpdateJob:input_type -> sql.v1.UpdateJobRequest
-	1, // 5: sql.v1.SqlRunner.Heartbeat:input_type -> sql.v1.HeartbeatRequest
-	4, // 6: sql.v1.SqlRunner.GetJob:output_type -> sql.v1.GetJobResponse
-	7, // 7: sql.v1.SqlRunner.UpdateJob:output_type -> sql.v1.UpdateJobResponse
-	2, // 8: sql.v1.SqlRunner.Heartbeat:output_type -> sql.v1.HeartbeatResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	0,  // 0: sql.v1.RegisterRequest.supported_kinds:type_name -> sql.v1.JobKind
+	0,  // 1: sql.v1.GetJobRequest.supported_kinds:type_name -> sql.v1.JobKind
+	8,  // 2: sql.v1.GetJobResponse.job:type_name -> sql.v1.Job
+	0,  // 3: sql.v1.Job.kind:type_name -> sql.v1.JobKind
+	9,  // 4: sql.v1.Job.dsl_query:type_name -> sql.v1.DslQueryPayload
+	13, // 5: sql.v1.Job.raw_query:type_name -> sql.v1.RawQueryPayload
+	14, // 6: sql.v1.Job.fetch_columns:type_name -> sql.v1.FetchColumnsPayload
+	15, // 7: sql.v1.Job.schema_refresh:type_name -> sql.v1.SchemaRefreshPayload
+	10, // 8: sql.v1.DslQueryPayload.projections:type_name -> sql.v1.Projection
+	11, // 9: sql.v1.DslQueryPayload.conditions:type_name -> sql.v1.Condition
+	11, // 10: sql.v1.DslQueryPayload.having:type_name -> sql.v1.Condition
+	12, // 11: sql.v1.DslQueryPayload.order_by:type_name -> sql.v1.OrderTerm
+	39, // 12: sql.v1.Condition.value:type_name -> google.protobuf.Value
+	11, // 13: sql.v1.Condition.all:type_name -> sql.v1.Condition
+	11, // 14: sql.v1.Condition.any:type_name -> sql.v1.Condition
+	11, // 15: sql.v1.Condition.not:type_name -> sql.v1.Condition
+	39, // 16: sql.v1.RawQueryPayload.params:type_name -> google.protobuf.Value
+	1,  // 17: sql.v1.UpdateJobRequest.error_code:type_name -> sql.v1.ErrorCode
+	17, // 18: sql.v1.UpdateJobRequest.query_result:type_name -> sql.v1.QueryResult
+	20, // 19: sql.v1.UpdateJobRequest.schema_result:type_name -> sql.v1.SchemaResult
+	24, // 20: sql.v1.UpdateJobRequest.columns_result:type_name -> sql.v1.ColumnsResult
+	18, // 21: sql.v1.UpdateJobRequest.columnar_result:type_name -> sql.v1.ColumnarResult
+	40, // 22: sql.v1.QueryResult.rows:type_name -> google.protobuf.Struct
+	19, // 23: sql.v1.ColumnarResult.columns:type_name -> sql.v1.ResultColumn
+	41, // 24: sql.v1.ColumnarResult.rows:type_name -> google.protobuf.ListValue
+	21, // 25: sql.v1.SchemaResult.schemas:type_name -> sql.v1.SchemaDescription
+	22, // 26: sql.v1.SchemaDescription.tables:type_name -> sql.v1.TableDescription
+	23, // 27: sql.v1.TableDescription.columns:type_name -> sql.v1.ColumnDescription
+	25, // 28: sql.v1.ColumnsResult.columns:type_name -> sql.v1.ColumnDetail
+	28, // 29: sql.v1.UploadJobResultRequest.header:type_name -> sql.v1.ResultHeader
+	29, // 30: sql.v1.UploadJobResultRequest.chunk:type_name -> sql.v1.ResultChunk
+	30, // 31: sql.v1.UploadJobResultRequest.summary:type_name -> sql.v1.ResultSummary
+	19, // 32: sql.v1.ResultHeader.column_types:type_name -> sql.v1.ResultColumn
+	1,  // 33: sql.v1.ResultSummary.error_code:type_name -> sql.v1.ErrorCode
+	33, // 34: sql.v1.AgentMessage.hello:type_name -> sql.v1.AgentHello
+	34, // 35: sql.v1.AgentMessage.ready:type_name -> sql.v1.JobReady
+	35, // 36: sql.v1.AgentMessage.ack:type_name -> sql.v1.JobAck
+	16, // 37: sql.v1.AgentMessage.result:type_name -> sql.v1.UpdateJobRequest
+	0,  // 38: sql.v1.AgentHello.supported_kinds:type_name -> sql.v1.JobKind
+	0,  // 39: sql.v1.JobReady.kinds:type_name -> sql.v1.JobKind
+	8,  // 40: sql.v1.ServerMessage.job:type_name -> sql.v1.Job
+	37, // 41: sql.v1.ServerMessage.result_ack:type_name -> sql.v1.JobResultAck
+	38, // 42: sql.v1.ServerMessage.cancel:type_name -> sql.v1.CancelJob
+	2,  // 43: sql.v1.SqlRunner.Register:input_type -> sql.v1.RegisterRequest
+	6,  // 44: sql.v1.SqlRunner.GetJob:input_type -> sql.v1.GetJobRequest
+	16, // 45: sql.v1.SqlRunner.UpdateJob:input_type -> sql.v1.UpdateJobRequest
+	4,  // 46: sql.v1.SqlRunner.Heartbeat:input_type -> sql.v1.HeartbeatRequest
+	27, // 47: sql.v1.SqlRunner.UploadJobResult:input_type -> sql.v1.UploadJobResultRequest
+	32, // 48: sql.v1.SqlRunner.JobStream:input_type -> sql.v1.AgentMessage
+	3,  // 49: sql.v1.SqlRunner.Register:output_type -> sql.v1.RegisterResponse
+	7,  // 50: sql.v1.SqlRunner.GetJob:output_type -> sql.v1.GetJobResponse
+	26, // 51: sql.v1.SqlRunner.UpdateJob:output_type -> sql.v1.UpdateJobResponse
+	5,  // 52: sql.v1.SqlRunner.Heartbeat:output_type -> sql.v1.HeartbeatResponse
+	31, // 53: sql.v1.SqlRunner.UploadJobResult:output_type -> sql.v1.UploadJobResultResponse
+	36, // 54: sql.v1.SqlRunner.JobStream:output_type -> sql.v1.ServerMessage
+	49, // [49:55] is the sub-list for method output_type
+	43, // [43:49] is the sub-list for method input_type
+	43, // [43:43] is the sub-list for extension type_name
+	43, // [43:43] is the sub-list for extension extendee
+	0,  // [0:43] is the sub-list for field type_name
 }
 
 func init() { file_proto_sql_runner_proto_init() }
@@ -537,13 +3323,48 @@ func file_proto_sql_runner_proto_init() {
 	if File_proto_sql_runner_proto != nil {
 		return
 	}
+	file_proto_sql_runner_proto_msgTypes[6].OneofWrappers = []any{
+		(*Job_DslQuery)(nil),
+		(*Job_RawQuery)(nil),
+		(*Job_FetchColumns)(nil),
+		(*Job_SchemaRefresh)(nil),
+	}
+	file_proto_sql_runner_proto_msgTypes[7].OneofWrappers = []any{}
+	file_proto_sql_runner_proto_msgTypes[12].OneofWrappers = []any{}
+	file_proto_sql_runner_proto_msgTypes[14].OneofWrappers = []any{
+		(*UpdateJobRequest_QueryResult)(nil),
+		(*UpdateJobRequest_SchemaResult)(nil),
+		(*UpdateJobRequest_ColumnsResult)(nil),
+		(*UpdateJobRequest_ColumnarResult)(nil),
+	}
+	file_proto_sql_runner_proto_msgTypes[17].OneofWrappers = []any{}
+	file_proto_sql_runner_proto_msgTypes[19].OneofWrappers = []any{}
+	file_proto_sql_runner_proto_msgTypes[20].OneofWrappers = []any{}
+	file_proto_sql_runner_proto_msgTypes[21].OneofWrappers = []any{}
+	file_proto_sql_runner_proto_msgTypes[23].OneofWrappers = []any{}
+	file_proto_sql_runner_proto_msgTypes[25].OneofWrappers = []any{
+		(*UploadJobResultRequest_Header)(nil),
+		(*UploadJobResultRequest_Chunk)(nil),
+		(*UploadJobResultRequest_Summary)(nil),
+	}
+	file_proto_sql_runner_proto_msgTypes[30].OneofWrappers = []any{
+		(*AgentMessage_Hello)(nil),
+		(*AgentMessage_Ready)(nil),
+		(*AgentMessage_Ack)(nil),
+		(*AgentMessage_Result)(nil),
+	}
+	file_proto_sql_runner_proto_msgTypes[34].OneofWrappers = []any{
+		(*ServerMessage_Job)(nil),
+		(*ServerMessage_ResultAck)(nil),
+		(*ServerMessage_Cancel)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_sql_runner_proto_rawDesc), len(file_proto_sql_runner_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   7,
+			NumEnums:      2,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
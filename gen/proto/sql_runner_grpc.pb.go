@@ -19,21 +19,35 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	SqlRunner_GetJob_FullMethodName    = "/sql.v1.SqlRunner/GetJob"
-	SqlRunner_UpdateJob_FullMethodName = "/sql.v1.SqlRunner/UpdateJob"
-	SqlRunner_Heartbeat_FullMethodName = "/sql.v1.SqlRunner/Heartbeat"
+	SqlRunner_Register_FullMethodName        = "/sql.v1.SqlRunner/Register"
+	SqlRunner_GetJob_FullMethodName          = "/sql.v1.SqlRunner/GetJob"
+	SqlRunner_UpdateJob_FullMethodName       = "/sql.v1.SqlRunner/UpdateJob"
+	SqlRunner_Heartbeat_FullMethodName       = "/sql.v1.SqlRunner/Heartbeat"
+	SqlRunner_UploadJobResult_FullMethodName = "/sql.v1.SqlRunner/UploadJobResult"
+	SqlRunner_JobStream_FullMethodName       = "/sql.v1.SqlRunner/JobStream"
 )
 
 // SqlRunnerClient is the client API for SqlRunner service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type SqlRunnerClient interface {
+	// Agent announces its version and capabilities at startup. The server
+	// only routes jobs the agent can handle to it, and refuses agents it is
+	// not compatible with.
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
 	// Agent requests a job to process
 	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*GetJobResponse, error)
 	// Agent reports result
 	UpdateJob(ctx context.Context, in *UpdateJobRequest, opts ...grpc.CallOption) (*UpdateJobResponse, error)
 	// Agent sends heartbeat
 	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	// Agent streams a large result as a header, row chunks and a final
+	// summary. Used instead of UpdateJob for jobs with stream_result set.
+	UploadJobResult(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadJobResultRequest, UploadJobResultResponse], error)
+	// Server pushes jobs as the agent has capacity for them; the agent acks
+	// each job and reports its result on the same stream. Agents fall back
+	// to GetJob polling when the server does not implement it.
+	JobStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AgentMessage, ServerMessage], error)
 }
 
 type sqlRunnerClient struct {
@@ -44,6 +58,16 @@ func NewSqlRunnerClient(cc grpc.ClientConnInterface) SqlRunnerClient {
 	return &sqlRunnerClient{cc}
 }
 
+func (c *sqlRunnerClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, SqlRunner_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *sqlRunnerClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*GetJobResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetJobResponse)
@@ -74,16 +98,53 @@ func (c *sqlRunnerClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, o
 	return out, nil
 }
 
+func (c *sqlRunnerClient) UploadJobResult(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadJobResultRequest, UploadJobResultResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SqlRunner_ServiceDesc.Streams[0], SqlRunner_UploadJobResult_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadJobResultRequest, UploadJobResultResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SqlRunner_UploadJobResultClient = grpc.ClientStreamingClient[UploadJobResultRequest, UploadJobResultResponse]
+
+func (c *sqlRunnerClient) JobStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AgentMessage, ServerMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SqlRunner_ServiceDesc.Streams[1], SqlRunner_JobStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AgentMessage, ServerMessage]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SqlRunner_JobStreamClient = grpc.BidiStreamingClient[AgentMessage, ServerMessage]
+
 // SqlRunnerServer is the server API for SqlRunner service.
 // All implementations must embed UnimplementedSqlRunnerServer
 // for forward compatibility.
 type SqlRunnerServer interface {
+	// Agent announces its version and capabilities at startup. The server
+	// only routes jobs the agent can handle to it, and refuses agents it is
+	// not compatible with.
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
 	// Agent requests a job to process
 	GetJob(context.Context, *GetJobRequest) (*GetJobResponse, error)
 	// Agent reports result
 	UpdateJob(context.Context, *UpdateJobRequest) (*UpdateJobResponse, error)
 	// Agent sends heartbeat
 	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	// Agent streams a large result as a header, row chunks and a final
+	// summary. Used instead of UpdateJob for jobs with stream_result set.
+	UploadJobResult(grpc.ClientStreamingServer[UploadJobResultRequest, UploadJobResultResponse]) error
+	// Server pushes jobs as the agent has capacity for them; the agent acks
+	// each job and reports its result on the same stream. Agents fall back
+	// to GetJob polling when the server does not implement it.
+	JobStream(grpc.BidiStreamingServer[AgentMessage, ServerMessage]) error
 	mustEmbedUnimplementedSqlRunnerServer()
 }
 
@@ -94,6 +155,9 @@ type SqlRunnerServer interface {
 // pointer dereference when methods are called.
 type UnimplementedSqlRunnerServer struct{}
 
+func (UnimplementedSqlRunnerServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
 func (UnimplementedSqlRunnerServer) GetJob(context.Context, *GetJobRequest) (*GetJobResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
 }
@@ -103,6 +167,12 @@ func (UnimplementedSqlRunnerServer) UpdateJob(context.Context, *UpdateJobRequest
 func (UnimplementedSqlRunnerServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
 }
+func (UnimplementedSqlRunnerServer) UploadJobResult(grpc.ClientStreamingServer[UploadJobResultRequest, UploadJobResultResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadJobResult not implemented")
+}
+func (UnimplementedSqlRunnerServer) JobStream(grpc.BidiStreamingServer[AgentMessage, ServerMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method JobStream not implemented")
+}
 func (UnimplementedSqlRunnerServer) mustEmbedUnimplementedSqlRunnerServer() {}
 func (UnimplementedSqlRunnerServer) testEmbeddedByValue()                   {}
 
@@ -124,6 +194,24 @@ func RegisterSqlRunnerServer(s grpc.ServiceRegistrar, srv SqlRunnerServer) {
 	s.RegisterService(&SqlRunner_ServiceDesc, srv)
 }
 
+func _SqlRunner_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SqlRunnerServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SqlRunner_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SqlRunnerServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SqlRunner_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetJobRequest)
 	if err := dec(in); err != nil {
@@ -178,6 +266,20 @@ func _SqlRunner_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SqlRunner_UploadJobResult_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SqlRunnerServer).UploadJobResult(&grpc.GenericServerStream[UploadJobResultRequest, UploadJobResultResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SqlRunner_UploadJobResultServer = grpc.ClientStreamingServer[UploadJobResultRequest, UploadJobResultResponse]
+
+func _SqlRunner_JobStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SqlRunnerServer).JobStream(&grpc.GenericServerStream[AgentMessage, ServerMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SqlRunner_JobStreamServer = grpc.BidiStreamingServer[AgentMessage, ServerMessage]
+
 // SqlRunner_ServiceDesc is the grpc.ServiceDesc for SqlRunner service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -185,6 +287,10 @@ var SqlRunner_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "sql.v1.SqlRunner",
 	HandlerType: (*SqlRunnerServer)(nil),
 	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _SqlRunner_Register_Handler,
+		},
 		{
 			MethodName: "GetJob",
 			Handler:    _SqlRunner_GetJob_Handler,
@@ -198,6 +304,18 @@ var SqlRunner_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _SqlRunner_Heartbeat_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadJobResult",
+			Handler:       _SqlRunner_UploadJobResult_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "JobStream",
+			Handler:       _SqlRunner_JobStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "proto/sql_runner.proto",
 }
@@ -4,80 +4,163 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"encoding/json"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 
+	"starless/kadath/configs"
 	pb "starless/kadath/gen/proto"
+	"starless/kadath/internal/types"
 	"starless/kadath/internal/utils"
 )
 
-type NoJobs struct {}
+type NoJobs struct{}
 
 func (e NoJobs) Error() string {
 	return "No jobs available"
 }
 
+// JobResult is the outcome of a job. Streamed is set when the result was
+// already delivered through UploadJobResult, so UpdateJob must not be called.
+// Cancelled is set when the job was stopped by the server, and TimedOut when
+// it ran past its timeout. A failed job has an ErrorCode, and the native
+// code and message of the database error it failed on, if any.
+//
+// A successful job sets one of Query, Columnar, Schema and Columns. Servers
+// speaking protocol version 1 get it encoded in JSON unless ResultJSON is
+// set.
 type JobResult struct {
-	Success      bool
-	ResultJSON   string
-	ErrorMessage string
+	Success       bool
+	ResultJSON    string
+	Query         *types.QueryResponse
+	Columnar      *types.ColumnarResponse
+	Schema        *types.SchemaResponse
+	Columns       *types.ColumnsResponse
+	ErrorMessage  string
+	ErrorCode     types.ErrorCode
+	NativeCode    string
+	NativeMessage string
+	Streamed      bool
+	Cancelled     bool
+	TimedOut      bool
 }
 
+// errorCodes maps error codes to their protocol values
+var errorCodes = map[types.ErrorCode]pb.ErrorCode{
+	types.ErrorValidation:       pb.ErrorCode_ERROR_CODE_VALIDATION,
+	types.ErrorUnknownColumn:    pb.ErrorCode_ERROR_CODE_UNKNOWN_COLUMN,
+	types.ErrorUnknownTable:     pb.ErrorCode_ERROR_CODE_UNKNOWN_TABLE,
+	types.ErrorPermissionDenied: pb.ErrorCode_ERROR_CODE_PERMISSION_DENIED,
+	types.ErrorConnection:       pb.ErrorCode_ERROR_CODE_CONNECTION,
+	types.ErrorTimeout:          pb.ErrorCode_ERROR_CODE_TIMEOUT,
+	types.ErrorCancelled:        pb.ErrorCode_ERROR_CODE_CANCELLED,
+	types.ErrorSyntax:           pb.ErrorCode_ERROR_CODE_SYNTAX,
+	types.ErrorInternal:         pb.ErrorCode_ERROR_CODE_INTERNAL,
+}
+
+// JobResponse is a job received from the server. StreamResult asks for the
+// result to be uploaded with OpenResultStream. Timeout is the time the job
+// may run for, 0 for no limit.
+//
+// Servers speaking protocol version 2 send typed payloads, converted into
+// DslQuery, RawQuery or FetchColumns; older ones send PayloadJSON.
 type JobResponse struct {
-	Id			string
-	Kind		int32
-	Payload map[string]interface{}
+	Id           string
+	Kind         int32
+	PayloadJSON  string
+	DslQuery     *types.QueryParams
+	RawQuery     *types.RawQueryParams
+	FetchColumns *types.FetchColumnsParams
+	StreamResult bool
+	Timeout      time.Duration
 }
 
 type Agent struct {
+	conn           *grpc.ClientConn
 	client         pb.SqlRunnerClient
 	connectorID    string
 	agentID        string
 	authToken      string
 	logger         *slog.Logger
 	supportedKinds []pb.JobKind
+
+	// Protocol version negotiated by Register, 0 until then
+	protocolVersion int32
+
+	// Job stream state, see NextJob
+	streamMu  sync.Mutex
+	jobStream *jobStream
+	pollOnly  bool
+
+	// Running jobs, see StartJob
+	cancelMu sync.Mutex
+	running  map[string]context.CancelCauseFunc
 }
 
-func NewAgent(ctx context.Context, serverAddr, connectorID, authToken string, supportedKinds []pb.JobKind, logger *slog.Logger) (*Agent, error) {
-	conn, err := grpc.DialContext(ctx, serverAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-	)
+// NewAgent creates an agent connected to the servers of cfg. The connection
+// state is logged until ctx is done.
+func NewAgent(ctx context.Context, cfg *configs.Config, supportedKinds []pb.JobKind, logger *slog.Logger) (*Agent, error) {
+	conn, err := dialServer(cfg, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to %s: %w", serverAddr, err)
+		return nil, err
 	}
 
-	return &Agent{
+	a := &Agent{
+		conn:           conn,
 		client:         pb.NewSqlRunnerClient(conn),
-		connectorID:    connectorID,
+		connectorID:    cfg.ConnectorId,
 		agentID:        fmt.Sprintf("%s.%s", utils.GetHostname(), utils.RandomUUID()),
-		authToken:      authToken,
+		authToken:      cfg.AuthToken,
 		logger:         logger,
 		supportedKinds: supportedKinds,
-	}, nil
+	}
+	go a.watchConnState(ctx)
+
+	return a, nil
 }
 
 func (a *Agent) authCtx(ctx context.Context) context.Context {
 	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+a.authToken)
 }
 
+// SendHeartbeat reports the agent alive and cancels the jobs the server
+// asks to stop
 func (a *Agent) SendHeartbeat(ctx context.Context) error {
-	_, err := a.client.Heartbeat(a.authCtx(ctx), &pb.HeartbeatRequest{
+	resp, err := a.client.Heartbeat(a.authCtx(ctx), &pb.HeartbeatRequest{
 		AgentId: a.agentID,
 	})
-	if err == nil {
-		a.logger.Debug("Heartbeat sent")
+	if err != nil {
+		return err
 	}
+
+	a.logger.Debug("Heartbeat sent")
+	for _, jobID := range resp.CancelledJobIds {
+		a.CancelJob(jobID)
+	}
+	return nil
+}
+
+// Deregister sends the final heartbeat of an agent shutting down, so the
+// server stops counting on it without waiting for heartbeats to time out
+func (a *Agent) Deregister(ctx context.Context) error {
+	_, err := a.client.Heartbeat(a.authCtx(ctx), &pb.HeartbeatRequest{
+		AgentId:      a.agentID,
+		ShuttingDown: true,
+	})
 	return err
 }
 
-func (a *Agent) GetJob(ctx context.Context) (*JobResponse, error) {
+// GetJob polls for a job of one of kinds, or of any supported kind when
+// kinds is empty
+func (a *Agent) GetJob(ctx context.Context, kinds []pb.JobKind) (*JobResponse, error) {
+	if len(kinds) == 0 {
+		kinds = a.supportedKinds
+	}
 	resp, err := a.client.GetJob(a.authCtx(ctx), &pb.GetJobRequest{
 		AgentId:        a.agentID,
-		SupportedKinds: a.supportedKinds,
+		SupportedKinds: kinds,
 	})
 
 	if err != nil {
@@ -88,24 +171,90 @@ func (a *Agent) GetJob(ctx context.Context) (*JobResponse, error) {
 		return nil, &NoJobs{}
 	}
 
-	var payload map[string]interface{}
-
-	job := resp.Job
-
+	return jobFromProto(resp.Job), nil
+}
 
-	json.Unmarshal([]byte(job.PayloadJson), &payload)
+// jobFromProto converts a job received from the server
+func jobFromProto(job *pb.Job) *JobResponse {
+	resp := &JobResponse{
+		Id:           job.Id,
+		Kind:         int32(job.Kind),
+		PayloadJSON:  job.PayloadJson,
+		StreamResult: job.StreamResult,
+		Timeout:      time.Duration(job.TimeoutMs) * time.Millisecond,
+	}
+	resp.setPayload(job)
+	return resp
+}
 
-	return &JobResponse{Id: job.Id, Kind: int32(job.Kind), Payload: payload}, nil
+// TypedResults reports whether the server takes typed job results, from
+// protocol version 2
+func (a *Agent) TypedResults() bool {
+	return a.protocolVersion >= 2
 }
 
+// UpdateJob reports a job result, over the job stream when one is open and
+// with the unary UpdateJob RPC otherwise or when the stream fails
 func (a *Agent) UpdateJob(ctx context.Context, jobId string, result JobResult) error {
-	_, err := a.client.UpdateJob(a.authCtx(ctx), &pb.UpdateJobRequest{
+	req, err := a.ResultRequest(jobId, result)
+	if err != nil {
+		return err
+	}
+	return a.SendResult(ctx, req)
+}
+
+// ResultRequest encodes a job result for the protocol version of the
+// server, typed from version 2 and in JSON before
+func (a *Agent) ResultRequest(jobId string, result JobResult) (*pb.UpdateJobRequest, error) {
+	req := &pb.UpdateJobRequest{
 		JobId:        jobId,
-		AgentId:      a.agentID,
 		Success:      result.Success,
-		ResultJson:   result.ResultJSON,
 		ErrorMessage: result.ErrorMessage,
-	})
+		Cancelled:    result.Cancelled,
+		TimedOut:     result.TimedOut,
+
+		ErrorCode:          errorCodes[result.ErrorCode],
+		NativeErrorCode:    result.NativeCode,
+		NativeErrorMessage: result.NativeMessage,
+	}
+
+	typed := false
+	if a.TypedResults() {
+		var err error
+		if typed, err = setTypedResult(req, result); err != nil {
+			a.logger.Warn("Failed to convert typed result, sending JSON", "job_id", jobId, "error", err)
+		}
+	}
+	if !typed {
+		resultJSON, err := resultJSON(result)
+		if err != nil {
+			return nil, err
+		}
+		req.ResultJson = resultJSON
+	}
+
+	return req, nil
+}
+
+// SendResult reports a job result encoded by ResultRequest, as UpdateJob
+// does. The request may come from a previous run, so it is sent with the
+// current agent ID.
+func (a *Agent) SendResult(ctx context.Context, req *pb.UpdateJobRequest) error {
+	req.AgentId = a.agentID
+
+	a.streamMu.Lock()
+	js := a.jobStream
+	a.streamMu.Unlock()
+
+	if js != nil {
+		err := js.sendResult(ctx, req)
+		if err == nil {
+			return nil
+		}
+		a.logger.Warn("Failed to report result over job stream, using UpdateJob", "job_id", req.JobId, "error", err)
+	}
+
+	_, err := a.client.UpdateJob(a.authCtx(ctx), req)
 
 	return err
 }
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrJobCancelled is the cause of the context of a job cancelled by the
+// server
+var ErrJobCancelled = errors.New("job cancelled by server")
+
+// StartJob returns the context to run a job with, which is cancelled when
+// the server cancels the job or once timeout has passed, unless timeout is
+// 0. done must be called once the job finished.
+func (a *Agent) StartJob(ctx context.Context, jobID string, timeout time.Duration) (context.Context, func()) {
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	stop := func() {}
+	if timeout > 0 {
+		jobCtx, stop = context.WithTimeout(jobCtx, timeout)
+	}
+
+	a.cancelMu.Lock()
+	if a.running == nil {
+		a.running = make(map[string]context.CancelCauseFunc)
+	}
+	a.running[jobID] = cancel
+	a.cancelMu.Unlock()
+
+	return jobCtx, func() {
+		a.cancelMu.Lock()
+		delete(a.running, jobID)
+		a.cancelMu.Unlock()
+		stop()
+		cancel(nil)
+	}
+}
+
+// CancelJob cancels the context of a running job. It reports false when the
+// job is not running on this agent.
+func (a *Agent) CancelJob(jobID string) bool {
+	a.cancelMu.Lock()
+	cancel, ok := a.running[jobID]
+	a.cancelMu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	a.logger.Info("Cancelling job", "job_id", jobID)
+	cancel(ErrJobCancelled)
+	return true
+}
+
+// JobCancelled reports whether ctx, as returned by StartJob, was cancelled
+// by the server
+func JobCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrJobCancelled)
+}
+
+// JobTimedOut reports whether ctx, as returned by StartJob, ran out of time
+func JobTimedOut(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), context.DeadlineExceeded)
+}
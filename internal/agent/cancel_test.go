@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "starless/kadath/gen/proto"
+)
+
+// cancelServer cancels job-1 both on the job stream and in heartbeats
+type cancelServer struct {
+	pb.UnimplementedSqlRunnerServer
+}
+
+func (s *cancelServer) Heartbeat(context.Context, *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	return &pb.HeartbeatResponse{Success: true, CancelledJobIds: []string{"job-1"}}, nil
+}
+
+func (s *cancelServer) JobStream(stream grpc.BidiStreamingServer[pb.AgentMessage, pb.ServerMessage]) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		switch msg.Message.(type) {
+		case *pb.AgentMessage_Ready:
+			if err := stream.Send(&pb.ServerMessage{Message: &pb.ServerMessage_Job{Job: &pb.Job{Id: "job-1"}}}); err != nil {
+				return err
+			}
+		case *pb.AgentMessage_Ack:
+			if err := stream.Send(&pb.ServerMessage{Message: &pb.ServerMessage_Cancel{Cancel: &pb.CancelJob{JobId: "job-1"}}}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func TestCancelJobOverStream(t *testing.T) {
+	a := newTestAgent(t, &cancelServer{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Register the job before the server gets the ack and cancels it
+	jobCtx, done := a.StartJob(ctx, "job-1", 0)
+	defer done()
+
+	if _, err := a.NextJob(ctx, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-jobCtx.Done():
+	case <-ctx.Done():
+		t.Fatal("job was not cancelled")
+	}
+	if !JobCancelled(jobCtx) {
+		t.Errorf("unexpected cancellation cause: %v", context.Cause(jobCtx))
+	}
+}
+
+func TestCancelJobFromHeartbeat(t *testing.T) {
+	a := newTestAgent(t, &cancelServer{})
+	ctx := context.Background()
+
+	jobCtx, done := a.StartJob(ctx, "job-1", 0)
+	defer done()
+	otherCtx, otherDone := a.StartJob(ctx, "job-2", 0)
+	defer otherDone()
+
+	if err := a.SendHeartbeat(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !JobCancelled(jobCtx) {
+		t.Error("expected job-1 to be cancelled")
+	}
+	if otherCtx.Err() != nil {
+		t.Error("job-2 should keep running")
+	}
+}
+
+func TestJobNotCancelledWhenDone(t *testing.T) {
+	a := newTestAgent(t, &cancelServer{})
+
+	jobCtx, done := a.StartJob(context.Background(), "job-1", 0)
+	done()
+
+	if a.CancelJob("job-1") {
+		t.Error("finished job should not be cancellable")
+	}
+	if JobCancelled(jobCtx) {
+		t.Error("finished job reported as cancelled")
+	}
+}
+
+func TestJobTimedOut(t *testing.T) {
+	a := newTestAgent(t, &cancelServer{})
+
+	jobCtx, done := a.StartJob(context.Background(), "job-1", 10*time.Millisecond)
+	defer done()
+
+	<-jobCtx.Done()
+	if !JobTimedOut(jobCtx) || JobCancelled(jobCtx) {
+		t.Errorf("unexpected cancellation cause: %v", context.Cause(jobCtx))
+	}
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+
+	"starless/kadath/configs"
+)
+
+// serverScheme names the resolver serving the configured server addresses
+const serverScheme = "kadath"
+
+// pick_first connects to the first address that accepts a connection and
+// moves on to the next ones when it fails
+const serviceConfig = `{"loadBalancingConfig": [{"pick_first": {}}]}`
+
+// dialServer creates the connection to the server. It does not wait for
+// the connection to be established: RPCs fail while no address is
+// reachable and gRPC keeps reconnecting in the background.
+func dialServer(cfg *configs.Config, logger *slog.Logger) (*grpc.ClientConn, error) {
+	if len(cfg.ServerAddrs) == 0 {
+		return nil, fmt.Errorf("no server address configured")
+	}
+
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	if cfg.ServerInsecure {
+		logger.Warn("Connecting to the server without TLS, the auth token is sent in cleartext", "servers", cfg.ServerAddrs)
+	}
+
+	addrs := make([]resolver.Address, len(cfg.ServerAddrs))
+	for i, addr := range cfg.ServerAddrs {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server address %q: %w", addr, err)
+		}
+		// gRPC verifies the certificate against the address server name,
+		// which defaults to the name of the resolver target
+		if cfg.ServerName != "" {
+			host = cfg.ServerName
+		}
+		addrs[i] = resolver.Address{Addr: addr, ServerName: host}
+	}
+
+	r := manual.NewBuilderWithScheme(serverScheme)
+	r.InitialState(resolver.State{Addresses: addrs})
+
+	conn, err := grpc.NewClient(serverScheme+":///server",
+		grpc.WithResolvers(r),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxPayloadBytes)),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           reconnectBackoff(cfg),
+			MinConnectTimeout: 20 * time.Second,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection to %v: %w", cfg.ServerAddrs, err)
+	}
+
+	conn.Connect()
+	return conn, nil
+}
+
+// reconnectBackoff returns the gRPC reconnect backoff with the delays of
+// cfg, keeping the gRPC defaults for unset delays
+func reconnectBackoff(cfg *configs.Config) backoff.Config {
+	b := backoff.DefaultConfig
+	if cfg.ReconnectBaseDelay > 0 {
+		b.BaseDelay = cfg.ReconnectBaseDelay
+	}
+	if cfg.ReconnectMaxDelay > 0 {
+		b.MaxDelay = cfg.ReconnectMaxDelay
+	}
+	return b
+}
+
+// watchConnState logs the state changes of the server connection until ctx
+// is done or the connection is closed
+func (a *Agent) watchConnState(ctx context.Context) {
+	state := a.conn.GetState()
+	for a.conn.WaitForStateChange(ctx, state) {
+		state = a.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			a.logger.Info("Connected to server")
+		case connectivity.TransientFailure:
+			a.logger.Warn("Server connection failed, reconnecting")
+		case connectivity.Shutdown:
+			return
+		default:
+			a.logger.Debug("Server connection state changed", "state", state)
+		}
+	}
+}
+
+// Close closes the connection to the server
+func (a *Agent) Close() error {
+	return a.conn.Close()
+}
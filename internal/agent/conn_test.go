@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"starless/kadath/configs"
+	pb "starless/kadath/gen/proto"
+)
+
+func TestNewAgentFailover(t *testing.T) {
+	// Nothing listens on the first address anymore
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	closed.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterSqlRunnerServer(server, &heartbeatServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg := &configs.Config{
+		ServerAddrs:    []string{closed.Addr().String(), lis.Addr().String()},
+		ServerInsecure: true,
+	}
+	a, err := NewAgent(ctx, cfg, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer a.Close()
+
+	if err := a.SendHeartbeat(ctx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewAgentServerAddrs(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := NewAgent(ctx, &configs.Config{ServerInsecure: true}, nil, slog.Default()); err == nil {
+		t.Error("expected error without server address")
+	}
+
+	cfg := &configs.Config{ServerAddrs: []string{"localhost"}, ServerInsecure: true}
+	if _, err := NewAgent(ctx, cfg, nil, slog.Default()); err == nil {
+		t.Error("expected error for an address without port")
+	}
+}
@@ -0,0 +1,277 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "starless/kadath/gen/proto"
+	"starless/kadath/internal/types"
+)
+
+// setPayload converts the typed payload of a job, if it has one
+func (j *JobResponse) setPayload(job *pb.Job) {
+	switch p := job.Payload.(type) {
+	case *pb.Job_DslQuery:
+		j.DslQuery = dslQueryFromProto(p.DslQuery)
+	case *pb.Job_RawQuery:
+		j.RawQuery = &types.RawQueryParams{
+			SQL:          p.RawQuery.Sql,
+			Params:       valuesFromProto(p.RawQuery.Params),
+			ResultFormat: types.ResultFormat(p.RawQuery.ResultFormat),
+		}
+	case *pb.Job_FetchColumns:
+		j.FetchColumns = &types.FetchColumnsParams{SchemaName: p.FetchColumns.SchemaName, Table: p.FetchColumns.Table}
+	}
+}
+
+func dslQueryFromProto(p *pb.DslQueryPayload) *types.QueryParams {
+	params := &types.QueryParams{
+		DatabaseType: p.DatabaseType,
+		SchemaName:   p.SchemaName,
+		Table:        p.Table,
+		Distinct:     p.Distinct,
+		Select:       p.Select,
+		Conditions:   conditionsFromProto(p.Conditions),
+		Limit:        intFromProto(p.Limit),
+		GroupBy:      p.GroupBy,
+		Having:       conditionsFromProto(p.Having),
+		Offset:       intFromProto(p.Offset),
+		Cursor:       p.Cursor,
+		ResultFormat: types.ResultFormat(p.ResultFormat),
+	}
+
+	for _, proj := range p.Projections {
+		params.Projections = append(params.Projections, types.Projection{
+			Column:    proj.Column,
+			Aggregate: types.AggregateFunc(proj.Aggregate),
+			Alias:     proj.Alias,
+		})
+	}
+	for _, term := range p.OrderBy {
+		params.OrderBy = append(params.OrderBy, types.OrderTerm{
+			Column:    term.Column,
+			Aggregate: types.AggregateFunc(term.Aggregate),
+			Direction: types.SortDirection(term.Direction),
+			Nulls:     types.NullsOrder(term.Nulls),
+		})
+	}
+
+	return params
+}
+
+// conditionsFromProto converts conditions, keeping nil for none as a group
+// is told apart from a comparison by its non-nil children
+func conditionsFromProto(conds []*pb.Condition) []types.Condition {
+	if len(conds) == 0 {
+		return nil
+	}
+
+	converted := make([]types.Condition, len(conds))
+	for i, c := range conds {
+		converted[i] = types.Condition{
+			Column:    c.Column,
+			Aggregate: types.AggregateFunc(c.Aggregate),
+			Type:      types.ConditionType(c.Type),
+			All:       conditionsFromProto(c.All),
+			Any:       conditionsFromProto(c.Any),
+		}
+		if c.Value != nil {
+			converted[i].Value = c.Value.AsInterface()
+		}
+		if c.Not != nil {
+			converted[i].Not = &conditionsFromProto([]*pb.Condition{c.Not})[0]
+		}
+	}
+	return converted
+}
+
+func intFromProto(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
+
+// valuesFromProto converts values the way JSON payloads are decoded, with
+// numbers as float64
+func valuesFromProto(values []*structpb.Value) []interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+
+	converted := make([]interface{}, len(values))
+	for i, v := range values {
+		converted[i] = v.AsInterface()
+	}
+	return converted
+}
+
+// setTypedResult sets the typed result of req from result. It reports false
+// when the result has none.
+func setTypedResult(req *pb.UpdateJobRequest, result JobResult) (bool, error) {
+	switch {
+	case result.Query != nil:
+		query, err := queryResultToProto(result.Query)
+		if err != nil {
+			return false, err
+		}
+		req.Result = &pb.UpdateJobRequest_QueryResult{QueryResult: query}
+	case result.Columnar != nil:
+		columnar, err := columnarToProto(result.Columnar)
+		if err != nil {
+			return false, err
+		}
+		req.Result = &pb.UpdateJobRequest_ColumnarResult{ColumnarResult: columnar}
+	case result.Schema != nil:
+		req.Result = &pb.UpdateJobRequest_SchemaResult{SchemaResult: schemaToProto(result.Schema)}
+	case result.Columns != nil:
+		req.Result = &pb.UpdateJobRequest_ColumnsResult{ColumnsResult: columnsToProto(result.Columns)}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// resultJSON returns the JSON form of result for servers without typed
+// results
+func resultJSON(result JobResult) (string, error) {
+	var v interface{}
+	switch {
+	case result.ResultJSON != "":
+		return result.ResultJSON, nil
+	case result.Query != nil:
+		v = result.Query
+	case result.Columnar != nil:
+		v = result.Columnar
+	case result.Schema != nil:
+		v = result.Schema
+	case result.Columns != nil:
+		v = result.Columns
+	default:
+		return "", nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize result: %w", err)
+	}
+	return string(data), nil
+}
+
+func queryResultToProto(resp *types.QueryResponse) (*pb.QueryResult, error) {
+	rows := make([]*structpb.Struct, len(resp.Rows))
+	for i, row := range resp.Rows {
+		fields := make(map[string]*structpb.Value, len(row))
+		for col, val := range row {
+			v, err := valueToProto(val)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert column %s: %w", col, err)
+			}
+			fields[col] = v
+		}
+		rows[i] = &structpb.Struct{Fields: fields}
+	}
+
+	return &pb.QueryResult{
+		Rows:       rows,
+		RowCount:   int64(resp.RowCount),
+		NextCursor: resp.NextCursor,
+	}, nil
+}
+
+func columnarToProto(resp *types.ColumnarResponse) (*pb.ColumnarResult, error) {
+	rows := make([]*structpb.ListValue, len(resp.Rows))
+	for i, row := range resp.Rows {
+		values := make([]*structpb.Value, len(row))
+		for j, val := range row {
+			v, err := valueToProto(val)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert column %s: %w", resp.Columns[j].Name, err)
+			}
+			values[j] = v
+		}
+		rows[i] = &structpb.ListValue{Values: values}
+	}
+
+	return &pb.ColumnarResult{
+		Columns:    resultColumnsToProto(resp.Columns),
+		Rows:       rows,
+		RowCount:   int64(resp.RowCount),
+		NextCursor: resp.NextCursor,
+	}, nil
+}
+
+func resultColumnsToProto(columns []types.ResultColumn) []*pb.ResultColumn {
+	converted := make([]*pb.ResultColumn, len(columns))
+	for i, col := range columns {
+		converted[i] = &pb.ResultColumn{
+			Name:        col.Name,
+			NativeType:  col.NativeType,
+			LogicalType: string(col.LogicalType),
+			Nullable:    col.Nullable,
+		}
+	}
+	return converted
+}
+
+// valueToProto converts a column value. Values structpb does not know, such
+// as times, are converted as they are in JSON results.
+func valueToProto(val interface{}) (*structpb.Value, error) {
+	if v, err := structpb.NewValue(val); err == nil {
+		return v, nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return structpb.NewValue(decoded)
+}
+
+func schemaToProto(resp *types.SchemaResponse) *pb.SchemaResult {
+	result := &pb.SchemaResult{}
+	for _, s := range resp.Schemas {
+		schema := &pb.SchemaDescription{Name: s.Name, Comment: s.Comment}
+		for _, t := range s.Tables {
+			table := &pb.TableDescription{Name: t.Name, Type: string(t.Type), Comment: t.Comment}
+			for _, c := range t.Columns {
+				table.Columns = append(table.Columns, &pb.ColumnDescription{
+					Name:     c.Name,
+					DataType: c.DataType,
+					Nullable: c.Nullable,
+					Default:  c.Default,
+					Comment:  c.Comment,
+				})
+			}
+			schema.Tables = append(schema.Tables, table)
+		}
+		result.Schemas = append(result.Schemas, schema)
+	}
+	return result
+}
+
+func columnsToProto(resp *types.ColumnsResponse) *pb.ColumnsResult {
+	result := &pb.ColumnsResult{SchemaName: resp.SchemaName, Table: resp.Table}
+	for _, c := range resp.Columns {
+		result.Columns = append(result.Columns, &pb.ColumnDetail{
+			Name:               c.Name,
+			Position:           int32(c.Position),
+			NativeType:         c.NativeType,
+			LogicalType:        string(c.LogicalType),
+			Nullable:           c.Nullable,
+			Default:            c.Default,
+			PrimaryKey:         c.PrimaryKey,
+			CharacterMaxLength: c.CharacterMaxLength,
+			NumericPrecision:   c.NumericPrecision,
+			NumericScale:       c.NumericScale,
+		})
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "starless/kadath/gen/proto"
+	"starless/kadath/internal/types"
+)
+
+func TestSetPayloadDslQuery(t *testing.T) {
+	limit := int32(10)
+	job := &pb.Job{Payload: &pb.Job_DslQuery{DslQuery: &pb.DslQueryPayload{
+		Table: "users",
+		Limit: &limit,
+		Conditions: []*pb.Condition{{
+			Any: []*pb.Condition{
+				{Column: "age", Type: "greater_than", Value: structpb.NewNumberValue(18)},
+				{Not: &pb.Condition{Column: "name", Type: "equal", Value: structpb.NewStringValue("bob")}},
+			},
+		}},
+	}}}
+
+	var resp JobResponse
+	resp.setPayload(job)
+
+	params := resp.DslQuery
+	if params == nil || params.Table != "users" || params.Limit == nil || *params.Limit != 10 {
+		t.Fatalf("unexpected params: %+v", params)
+	}
+	if params.Offset != nil || params.Having != nil {
+		t.Errorf("expected unset offset and having: %+v", params)
+	}
+	if err := params.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	group := params.Conditions[0].Any
+	if len(group) != 2 || group[0].Value != float64(18) {
+		t.Fatalf("unexpected conditions: %+v", params.Conditions)
+	}
+	if group[1].Not == nil || group[1].Not.Column != "name" || group[1].Not.Value != "bob" {
+		t.Errorf("unexpected not condition: %+v", group[1])
+	}
+}
+
+func TestQueryResultToProto(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	resp := &types.QueryResponse{
+		Rows:     []types.QueryResult{{"id": int64(1), "created_at": at, "note": nil}},
+		RowCount: 1,
+	}
+
+	result, err := queryResultToProto(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RowCount != 1 || len(result.Rows) != 1 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+
+	row := result.Rows[0].AsMap()
+	if row["id"] != float64(1) || row["created_at"] != "2024-05-01T12:00:00Z" || row["note"] != nil {
+		t.Errorf("unexpected row: %v", row)
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	got, err := resultJSON(JobResult{Query: &types.QueryResponse{Rows: []types.QueryResult{}, RowCount: 0}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != `{"rows":[],"row_count":0}` {
+		t.Errorf("unexpected JSON: %s", got)
+	}
+
+	// Handlers of version 1 encode the result themselves
+	got, _ = resultJSON(JobResult{ResultJSON: "[]", Query: &types.QueryResponse{}})
+	if got != "[]" {
+		t.Errorf("expected the encoded result, got %s", got)
+	}
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "starless/kadath/gen/proto"
+	"starless/kadath/internal/types"
+)
+
+// Version is the agent version, set at build time with
+// -ldflags "-X starless/kadath/internal/agent.Version=v1.2.3"
+var Version = "dev"
+
+// ProtocolVersion is the highest version of the agent protocol this agent
+// speaks. Version 2 adds typed job payloads and results; the server picks
+// the version to use when the agent registers.
+const ProtocolVersion = 2
+
+// MaxPayloadBytes is the largest message the agent accepts from the server
+const MaxPayloadBytes = 16 << 20
+
+// Features lists the optional protocol features of the agent
+var Features = []string{
+	"job_stream",
+	"result_stream",
+	"cancel_job",
+	"job_timeout",
+	"columnar_results",
+	"arrow_results",
+}
+
+// Rejected is returned by Register when the server refuses the agent or
+// chooses a protocol version the agent does not speak. Retrying does not
+// help, so it is fatal at startup.
+type Rejected struct {
+	Reason string
+}
+
+func (e *Rejected) Error() string {
+	return fmt.Sprintf("registration rejected by server: %s", e.Reason)
+}
+
+// Register announces the agent and the capabilities of its engine to the
+// server. Servers without registration accept any agent, so Unimplemented
+// is not an error.
+func (a *Agent) Register(ctx context.Context, info *types.EngineInfo) error {
+	conditionTypes := make([]string, len(types.ConditionTypes))
+	for i, ct := range types.ConditionTypes {
+		conditionTypes[i] = string(ct)
+	}
+
+	features := make([]string, 0, len(types.DSLFeatures)+len(Features))
+	features = append(features, types.DSLFeatures...)
+	features = append(features, Features...)
+
+	resp, err := a.client.Register(a.authCtx(ctx), &pb.RegisterRequest{
+		AgentId:         a.agentID,
+		AgentVersion:    Version,
+		ProtocolVersion: ProtocolVersion,
+		EngineType:      info.Type,
+		DatabaseVersion: info.Version,
+		SupportedKinds:  a.supportedKinds,
+		ConditionTypes:  conditionTypes,
+		Features:        features,
+		MaxPayloadBytes: MaxPayloadBytes,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		a.logger.Info("Server does not support registration")
+		a.protocolVersion = 1
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !resp.Accepted {
+		return &Rejected{Reason: resp.Reason}
+	}
+
+	// Servers predating negotiation only speak version 1
+	version := resp.ProtocolVersion
+	if version == 0 {
+		version = 1
+	}
+	if version > ProtocolVersion {
+		return &Rejected{Reason: fmt.Sprintf("server chose unsupported protocol version %d", version)}
+	}
+	a.protocolVersion = version
+
+	a.logger.Info("Agent registered", "version", Version, "engine", info.Type, "database_version", info.Version, "protocol_version", version)
+	return nil
+}
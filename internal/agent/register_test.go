@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "starless/kadath/gen/proto"
+	"starless/kadath/internal/types"
+)
+
+// registerServer accepts agents running postgres, choosing version as
+// protocol version
+type registerServer struct {
+	pb.UnimplementedSqlRunnerServer
+	version int32
+	request *pb.RegisterRequest
+	result  *pb.UpdateJobRequest
+}
+
+func (s *registerServer) Register(_ context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	s.request = req
+	if req.EngineType != "postgres" {
+		return &pb.RegisterResponse{Reason: "unsupported engine " + req.EngineType}, nil
+	}
+	return &pb.RegisterResponse{Accepted: true, ProtocolVersion: s.version}, nil
+}
+
+func (s *registerServer) UpdateJob(_ context.Context, req *pb.UpdateJobRequest) (*pb.UpdateJobResponse, error) {
+	s.result = req
+	return &pb.UpdateJobResponse{Success: true}, nil
+}
+
+func TestRegister(t *testing.T) {
+	srv := &registerServer{}
+	a := newTestAgent(t, srv)
+	ctx := context.Background()
+
+	if err := a.Register(ctx, &types.EngineInfo{Type: "postgres", Version: "16.2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := srv.request
+	if req.AgentId != "agent-1" || req.ProtocolVersion != ProtocolVersion || req.DatabaseVersion != "16.2" {
+		t.Errorf("unexpected request: %v", req)
+	}
+	if len(req.ConditionTypes) != len(types.ConditionTypes) || len(req.SupportedKinds) != 1 {
+		t.Errorf("capabilities missing from request: %v", req)
+	}
+
+	err := a.Register(ctx, &types.EngineInfo{Type: "mysql"})
+	var rejected *Rejected
+	if !errors.As(err, &rejected) || rejected.Reason != "unsupported engine mysql" {
+		t.Errorf("expected rejection, got %v", err)
+	}
+}
+
+func TestRegisterProtocolVersion(t *testing.T) {
+	ctx := context.Background()
+	result := JobResult{Success: true, Query: &types.QueryResponse{Rows: []types.QueryResult{{"id": 1}}, RowCount: 1}}
+
+	// Servers predating negotiation get JSON results
+	srv := &registerServer{}
+	a := newTestAgent(t, srv)
+	if err := a.Register(ctx, &types.EngineInfo{Type: "postgres"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.TypedResults() {
+		t.Error("expected JSON results for protocol version 1")
+	}
+	if err := a.UpdateJob(ctx, "job-1", result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if srv.result.ResultJson != `{"rows":[{"id":1}],"row_count":1}` || srv.result.Result != nil {
+		t.Errorf("unexpected result: %v", srv.result)
+	}
+
+	srv = &registerServer{version: 2}
+	a = newTestAgent(t, srv)
+	if err := a.Register(ctx, &types.EngineInfo{Type: "postgres"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.UpdateJob(ctx, "job-1", result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if srv.result.ResultJson != "" || srv.result.GetQueryResult().GetRowCount() != 1 {
+		t.Errorf("unexpected result: %v", srv.result)
+	}
+
+	srv = &registerServer{version: ProtocolVersion + 1}
+	a = newTestAgent(t, srv)
+	err := a.Register(ctx, &types.EngineInfo{Type: "postgres"})
+	var rejected *Rejected
+	if !errors.As(err, &rejected) {
+		t.Errorf("expected a rejection for a protocol version the agent does not speak, got %v", err)
+	}
+}
+
+// deregisterServer records the heartbeats it receives
+type deregisterServer struct {
+	pb.UnimplementedSqlRunnerServer
+	heartbeat *pb.HeartbeatRequest
+}
+
+func (s *deregisterServer) Heartbeat(_ context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	s.heartbeat = req
+	return &pb.HeartbeatResponse{Success: true}, nil
+}
+
+func TestDeregister(t *testing.T) {
+	srv := &deregisterServer{}
+	a := newTestAgent(t, srv)
+
+	if err := a.Deregister(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if srv.heartbeat.AgentId != "agent-1" || !srv.heartbeat.ShuttingDown {
+		t.Errorf("unexpected heartbeat: %v", srv.heartbeat)
+	}
+}
+
+func TestRegisterUnimplemented(t *testing.T) {
+	a := newTestAgent(t, &pollServer{})
+
+	if err := a.Register(context.Background(), &types.EngineInfo{Type: "postgres"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
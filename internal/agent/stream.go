@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "starless/kadath/gen/proto"
+)
+
+// resultAckTimeout bounds the wait for the server to ack a result sent over
+// the job stream before falling back to UpdateJob
+const resultAckTimeout = 30 * time.Second
+
+// jobStream is an open JobStream. A reader goroutine delivers pushed jobs
+// on jobs, result acks to the waiting UpdateJob calls and cancellations to
+// cancel; done is closed with err set once the stream fails.
+type jobStream struct {
+	stream pb.SqlRunner_JobStreamClient
+	cancel func(jobID string) bool
+
+	sendMu sync.Mutex
+	jobs   chan *JobResponse
+	done   chan struct{}
+	err    error
+
+	acksMu sync.Mutex
+	acks   map[string]chan bool
+}
+
+// openJobStream starts a JobStream and announces the agent on it
+func (a *Agent) openJobStream(ctx context.Context) (*jobStream, error) {
+	stream, err := a.client.JobStream(a.authCtx(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	js := &jobStream{
+		stream: stream,
+		cancel: a.CancelJob,
+		jobs:   make(chan *JobResponse, 16),
+		done:   make(chan struct{}),
+		acks:   make(map[string]chan bool),
+	}
+
+	if err := js.send(&pb.AgentMessage{Message: &pb.AgentMessage_Hello{Hello: &pb.AgentHello{
+		AgentId:        a.agentID,
+		SupportedKinds: a.supportedKinds,
+	}}}); err != nil {
+		return nil, err
+	}
+
+	go js.receive()
+
+	return js, nil
+}
+
+// send writes one message; gRPC streams do not allow concurrent sends
+func (js *jobStream) send(msg *pb.AgentMessage) error {
+	js.sendMu.Lock()
+	defer js.sendMu.Unlock()
+	return js.stream.Send(msg)
+}
+
+// receive dispatches server messages until the stream fails
+func (js *jobStream) receive() {
+	for {
+		msg, err := js.stream.Recv()
+		if err != nil {
+			js.fail(err)
+			return
+		}
+
+		switch m := msg.Message.(type) {
+		case *pb.ServerMessage_Job:
+			js.jobs <- jobFromProto(m.Job)
+		case *pb.ServerMessage_ResultAck:
+			js.acksMu.Lock()
+			if ack, ok := js.acks[m.ResultAck.JobId]; ok {
+				ack <- m.ResultAck.Success
+				delete(js.acks, m.ResultAck.JobId)
+			}
+			js.acksMu.Unlock()
+		case *pb.ServerMessage_Cancel:
+			js.cancel(m.Cancel.JobId)
+		}
+	}
+}
+
+// fail records the error that ended the stream and releases every waiter
+func (js *jobStream) fail(err error) {
+	js.acksMu.Lock()
+	defer js.acksMu.Unlock()
+
+	js.err = err
+	close(js.done)
+	for jobID, ack := range js.acks {
+		close(ack)
+		delete(js.acks, jobID)
+	}
+}
+
+// sendResult reports a job result and waits for the server to ack it
+func (js *jobStream) sendResult(ctx context.Context, req *pb.UpdateJobRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, resultAckTimeout)
+	defer cancel()
+
+	ack := make(chan bool, 1)
+
+	js.acksMu.Lock()
+	select {
+	case <-js.done:
+		js.acksMu.Unlock()
+		return fmt.Errorf("job stream closed: %w", js.err)
+	default:
+	}
+	js.acks[req.JobId] = ack
+	js.acksMu.Unlock()
+
+	if err := js.send(&pb.AgentMessage{Message: &pb.AgentMessage_Result{Result: req}}); err != nil {
+		return err
+	}
+
+	select {
+	case success, ok := <-ack:
+		if !ok {
+			return fmt.Errorf("job stream closed: %w", js.err)
+		}
+		if !success {
+			return fmt.Errorf("server rejected the result of job %s", req.JobId)
+		}
+		return nil
+	case <-ctx.Done():
+		js.acksMu.Lock()
+		delete(js.acks, req.JobId)
+		js.acksMu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// NextJob returns the next job to process, of one of kinds or of any
+// supported kind when kinds is empty. It waits for a job pushed over
+// JobStream, opening the stream when needed, and falls back to GetJob
+// polling when the server does not implement it. In polling mode it
+// returns NoJobs when the server has nothing to do.
+func (a *Agent) NextJob(ctx context.Context, kinds []pb.JobKind) (*JobResponse, error) {
+	js, err := a.activeJobStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if js == nil {
+		return a.GetJob(ctx, kinds)
+	}
+
+	if err := js.send(&pb.AgentMessage{Message: &pb.AgentMessage_Ready{Ready: &pb.JobReady{Credits: 1, Kinds: kinds}}}); err != nil {
+		a.dropJobStream(js)
+		return nil, fmt.Errorf("job stream failed: %w", err)
+	}
+
+	select {
+	case job := <-js.jobs:
+		if err := js.send(&pb.AgentMessage{Message: &pb.AgentMessage_Ack{Ack: &pb.JobAck{JobId: job.Id}}}); err != nil {
+			a.logger.Warn("Failed to ack job", "job_id", job.Id, "error", err)
+		}
+		return job, nil
+
+	case <-js.done:
+		a.dropJobStream(js)
+		if status.Code(js.err) == codes.Unimplemented {
+			a.logger.Info("Server does not support job streaming, polling for jobs")
+			a.streamMu.Lock()
+			a.pollOnly = true
+			a.streamMu.Unlock()
+			return a.GetJob(ctx, kinds)
+		}
+		return nil, fmt.Errorf("job stream failed: %w", js.err)
+
+	case <-ctx.Done():
+		return nil, &NoJobs{}
+	}
+}
+
+// Streaming reports whether jobs are pushed by the server, in which case
+// NextJob blocks until a job arrives instead of returning NoJobs
+func (a *Agent) Streaming() bool {
+	a.streamMu.Lock()
+	defer a.streamMu.Unlock()
+	return !a.pollOnly
+}
+
+// activeJobStream returns the open job stream, opening it if needed, or
+// nil when the agent polls for jobs
+func (a *Agent) activeJobStream(ctx context.Context) (*jobStream, error) {
+	a.streamMu.Lock()
+	defer a.streamMu.Unlock()
+
+	if a.pollOnly {
+		return nil, nil
+	}
+	if a.jobStream != nil {
+		return a.jobStream, nil
+	}
+
+	js, err := a.openJobStream(ctx)
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			a.pollOnly = true
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open job stream: %w", err)
+	}
+
+	a.logger.Info("Job stream opened")
+	a.jobStream = js
+	return js, nil
+}
+
+// dropJobStream forgets a failed stream so the next call reopens it
+func (a *Agent) dropJobStream(js *jobStream) {
+	a.streamMu.Lock()
+	defer a.streamMu.Unlock()
+
+	if a.jobStream == js {
+		a.jobStream = nil
+		js.stream.CloseSend()
+	}
+}
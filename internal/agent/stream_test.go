@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "starless/kadath/gen/proto"
+)
+
+// pushServer pushes one job on JobStream once the agent is ready, and acks
+// the result reported for it
+type pushServer struct {
+	pb.UnimplementedSqlRunnerServer
+	results chan *pb.UpdateJobRequest
+}
+
+func (s *pushServer) JobStream(stream grpc.BidiStreamingServer[pb.AgentMessage, pb.ServerMessage]) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		switch m := msg.Message.(type) {
+		case *pb.AgentMessage_Ready:
+			if err := stream.Send(&pb.ServerMessage{Message: &pb.ServerMessage_Job{Job: &pb.Job{
+				Id:          "job-1",
+				Kind:        pb.JobKind_JOB_KIND_QUERY,
+				PayloadJson: `{"query":"SELECT 1"}`,
+			}}}); err != nil {
+				return err
+			}
+		case *pb.AgentMessage_Result:
+			s.results <- m.Result
+			if err := stream.Send(&pb.ServerMessage{Message: &pb.ServerMessage_ResultAck{ResultAck: &pb.JobResultAck{
+				JobId:   m.Result.JobId,
+				Success: true,
+			}}}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pollServer predates JobStream and only serves GetJob, recording the kinds
+// asked for
+type pollServer struct {
+	pb.UnimplementedSqlRunnerServer
+	kinds []pb.JobKind
+}
+
+func (s *pollServer) GetJob(_ context.Context, req *pb.GetJobRequest) (*pb.GetJobResponse, error) {
+	s.kinds = req.SupportedKinds
+	return &pb.GetJobResponse{HasJob: true, Job: &pb.Job{Id: "job-2", Kind: pb.JobKind_JOB_KIND_QUERY}}, nil
+}
+
+func newTestAgent(t *testing.T, srv pb.SqlRunnerServer) *Agent {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterSqlRunnerServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &Agent{
+		client:         pb.NewSqlRunnerClient(conn),
+		agentID:        "agent-1",
+		logger:         slog.Default(),
+		supportedKinds: []pb.JobKind{pb.JobKind_JOB_KIND_QUERY},
+	}
+}
+
+func TestNextJobPushed(t *testing.T) {
+	srv := &pushServer{results: make(chan *pb.UpdateJobRequest, 1)}
+	a := newTestAgent(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job, err := a.NextJob(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Id != "job-1" || job.PayloadJSON != `{"query":"SELECT 1"}` {
+		t.Errorf("unexpected job: %+v", job)
+	}
+	if !a.Streaming() {
+		t.Error("expected the agent to be streaming")
+	}
+
+	if err := a.UpdateJob(ctx, job.Id, JobResult{Success: true, ResultJSON: "[]"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case result := <-srv.results:
+		if result.JobId != "job-1" || !result.Success || result.AgentId != "agent-1" {
+			t.Errorf("unexpected result: %v", result)
+		}
+	default:
+		t.Error("result was not sent over the job stream")
+	}
+}
+
+func TestNextJobFallsBackToPolling(t *testing.T) {
+	srv := &pollServer{}
+	a := newTestAgent(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job, err := a.NextJob(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Id != "job-2" {
+		t.Errorf("unexpected job: %+v", job)
+	}
+	if a.Streaming() {
+		t.Error("expected the agent to poll")
+	}
+	if len(srv.kinds) != 1 || srv.kinds[0] != pb.JobKind_JOB_KIND_QUERY {
+		t.Errorf("expected all supported kinds, got %v", srv.kinds)
+	}
+
+	// Only the kinds with a free worker slot
+	if _, err := a.NextJob(ctx, []pb.JobKind{pb.JobKind_JOB_KIND_PING}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(srv.kinds) != 1 || srv.kinds[0] != pb.JobKind_JOB_KIND_PING {
+		t.Errorf("unexpected kinds: %v", srv.kinds)
+	}
+}
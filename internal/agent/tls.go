@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"starless/kadath/configs"
+)
+
+// tlsVersions are the accepted values of the minimum TLS version
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// transportCredentials returns the credentials of the connection to the
+// server. TLS is always used unless insecure mode is explicitly enabled, in
+// which case no TLS setting may be given.
+func transportCredentials(cfg *configs.Config) (credentials.TransportCredentials, error) {
+	if cfg.ServerInsecure {
+		if cfg.ServerCAFile != "" || cfg.ServerName != "" || cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+			return nil, fmt.Errorf("TLS settings cannot be used with SERVER_INSECURE")
+		}
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig, err := clientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// clientTLSConfig builds the TLS configuration from the files named in cfg
+func clientTLSConfig(cfg *configs.Config) (*tls.Config, error) {
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.TLSMinVersion != "" {
+		v, ok := tlsVersions[cfg.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version: %s", cfg.TLSMinVersion)
+		}
+		minVersion = v
+	}
+
+	// The server name override is set on the resolved addresses, see
+	// dialServer
+	tlsConfig := &tls.Config{MinVersion: minVersion}
+
+	if cfg.ServerCAFile != "" {
+		pem, err := os.ReadFile(cfg.ServerCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.ServerCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (cfg.ClientCertFile == "") != (cfg.ClientKeyFile == "") {
+		return nil, fmt.Errorf("client certificate and key must be set together")
+	}
+	if cfg.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,249 @@
+package agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"starless/kadath/configs"
+	pb "starless/kadath/gen/proto"
+)
+
+// heartbeatServer only answers heartbeats
+type heartbeatServer struct {
+	pb.UnimplementedSqlRunnerServer
+}
+
+func (s *heartbeatServer) Heartbeat(context.Context, *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	return &pb.HeartbeatResponse{Success: true}, nil
+}
+
+// testPKI is a CA with a server certificate for kadath.test and a client
+// certificate, written as PEM files in a temporary directory
+type testPKI struct {
+	dir        string
+	caPool     *x509.CertPool
+	serverCert tls.Certificate
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kadath test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", caDER)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) tls.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("failed to marshal key: %v", err)
+		}
+		writePEM(t, filepath.Join(dir, name+".pem"), "CERTIFICATE", der)
+		writePEM(t, filepath.Join(dir, name+"-key.pem"), "EC PRIVATE KEY", keyDER)
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	pki := &testPKI{dir: dir, caPool: pool}
+	pki.serverCert = issue(2, "kadath.test", x509.ExtKeyUsageServerAuth)
+	issue(3, "agent", x509.ExtKeyUsageClientAuth)
+	return pki
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func (p *testPKI) file(name string) string {
+	return filepath.Join(p.dir, name)
+}
+
+// serve starts a TLS server, requiring client certificates when mutual is
+// set, and returns its address
+func (p *testPKI) serve(t *testing.T, mutual bool) string {
+	t.Helper()
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{p.serverCert}}
+	if mutual {
+		tlsConfig.ClientCAs = p.caPool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	pb.RegisterSqlRunnerServer(server, &heartbeatServer{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+// heartbeat connects an agent with cfg and sends a heartbeat
+func heartbeat(t *testing.T, addr string, cfg *configs.Config) error {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cfg.ServerAddrs = []string{addr}
+	a, err := NewAgent(ctx, cfg, nil, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	return a.SendHeartbeat(ctx)
+}
+
+func TestNewAgentTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	addr := pki.serve(t, false)
+
+	cfg := &configs.Config{ServerCAFile: pki.file("ca.pem"), ServerName: "kadath.test"}
+	if err := heartbeat(t, addr, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The certificate is not valid for the address dialed
+	if err := heartbeat(t, addr, &configs.Config{ServerCAFile: pki.file("ca.pem")}); err == nil {
+		t.Error("expected error without the server name override")
+	}
+
+	// The test CA is not in the system roots
+	if err := heartbeat(t, addr, &configs.Config{ServerName: "kadath.test"}); err == nil {
+		t.Error("expected error for an untrusted server")
+	}
+}
+
+func TestNewAgentMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	addr := pki.serve(t, true)
+
+	cfg := &configs.Config{
+		ServerCAFile:   pki.file("ca.pem"),
+		ServerName:     "kadath.test",
+		ClientCertFile: pki.file("agent.pem"),
+		ClientKeyFile:  pki.file("agent-key.pem"),
+		TLSMinVersion:  "1.3",
+	}
+	if err := heartbeat(t, addr, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.ClientCertFile, cfg.ClientKeyFile = "", ""
+	if err := heartbeat(t, addr, cfg); err == nil {
+		t.Error("expected error without a client certificate")
+	}
+}
+
+func TestTransportCredentials(t *testing.T) {
+	pki := newTestPKI(t)
+
+	tests := []struct {
+		name        string
+		cfg         *configs.Config
+		expectError bool
+	}{
+		{
+			name: "system roots",
+			cfg:  &configs.Config{},
+		},
+		{
+			name: "explicit insecure",
+			cfg:  &configs.Config{ServerInsecure: true},
+		},
+		{
+			name:        "insecure with TLS settings",
+			cfg:         &configs.Config{ServerInsecure: true, ServerCAFile: pki.file("ca.pem")},
+			expectError: true,
+		},
+		{
+			name:        "missing CA bundle",
+			cfg:         &configs.Config{ServerCAFile: pki.file("missing.pem")},
+			expectError: true,
+		},
+		{
+			name:        "CA bundle without certificates",
+			cfg:         &configs.Config{ServerCAFile: pki.file("agent-key.pem")},
+			expectError: true,
+		},
+		{
+			name:        "certificate without key",
+			cfg:         &configs.Config{ClientCertFile: pki.file("agent.pem")},
+			expectError: true,
+		},
+		{
+			name:        "outdated minimum version",
+			cfg:         &configs.Config{TLSMinVersion: "1.0"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := transportCredentials(tt.cfg)
+			if tt.expectError && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	pb "starless/kadath/gen/proto"
+	"starless/kadath/internal/arrowipc"
+	"starless/kadath/internal/types"
+)
+
+// DefaultChunkBytes is the approximate size of the rows_json or data of each
+// chunk, well below the 4 MiB default gRPC message limit
+const DefaultChunkBytes = 1 << 20
+
+// ResultStream uploads a job result through UploadJobResult. Rows are
+// buffered into JSON chunks of about chunkBytes and sent as they fill up,
+// so only one chunk is held in memory at a time. Arrow results are encoded
+// as an IPC stream instead, sent in binary chunks of chunkBytes as record
+// batches are written. It implements types.RowWriter.
+type ResultStream struct {
+	stream     pb.SqlRunner_UploadJobResultClient
+	jobID      string
+	agentID    string
+	format     types.ResultFormat
+	chunkBytes int
+
+	headerSent bool
+	columns    []types.ResultColumn
+	buf        bytes.Buffer
+	pending    int
+	rowCount   int64
+
+	// Arrow IPC stream and its bytes not sent yet
+	arrow *arrowipc.Writer
+	data  bytes.Buffer
+}
+
+// OpenResultStream starts the upload of the result of a job, with rows
+// encoded in format
+func (a *Agent) OpenResultStream(ctx context.Context, jobID string, format types.ResultFormat) (*ResultStream, error) {
+	stream, err := a.client.UploadJobResult(a.authCtx(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open result stream: %w", err)
+	}
+
+	return &ResultStream{
+		stream:     stream,
+		jobID:      jobID,
+		agentID:    a.agentID,
+		format:     format,
+		chunkBytes: DefaultChunkBytes,
+	}, nil
+}
+
+// WriteHeader sends the header with the result columns
+func (s *ResultStream) WriteHeader(columns []types.ResultColumn) error {
+	if s.headerSent {
+		return fmt.Errorf("header already sent")
+	}
+	s.headerSent = true
+	s.columns = columns
+
+	if s.format == types.ResultFormatArrow {
+		s.arrow = arrowipc.NewWriter(&s.data)
+		if err := s.arrow.WriteHeader(columns); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+
+	format := s.format
+	if format == "" {
+		format = types.ResultFormatRows
+	}
+
+	return s.send(&pb.UploadJobResultRequest{
+		Part: &pb.UploadJobResultRequest_Header{Header: &pb.ResultHeader{
+			JobId:        s.jobID,
+			AgentId:      s.agentID,
+			Columns:      names,
+			ColumnTypes:  resultColumnsToProto(columns),
+			ResultFormat: string(format),
+		}},
+	})
+}
+
+// WriteRow adds a row to the current chunk, sending the chunk once it
+// reaches the chunk size. A single row larger than the chunk size is sent
+// as a chunk of its own.
+func (s *ResultStream) WriteRow(values []interface{}) error {
+	if !s.headerSent {
+		if err := s.WriteHeader(nil); err != nil {
+			return err
+		}
+	}
+
+	if s.arrow != nil {
+		if err := s.arrow.WriteRow(values); err != nil {
+			return fmt.Errorf("failed to encode row: %w", err)
+		}
+		s.rowCount++
+		return s.sendData(false)
+	}
+
+	var v interface{} = values
+	if s.format != types.ResultFormatColumnar {
+		v = types.RowObject(s.columns, values)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal row: %w", err)
+	}
+
+	if s.pending > 0 && s.buf.Len()+len(data)+2 > s.chunkBytes {
+		if err := s.flush(); err != nil {
+			return err
+		}
+	}
+
+	if s.pending == 0 {
+		s.buf.WriteByte('[')
+	} else {
+		s.buf.WriteByte(',')
+	}
+	s.buf.Write(data)
+	s.pending++
+	s.rowCount++
+
+	return nil
+}
+
+// send sends one message. When the server has already closed the stream
+// Send only reports io.EOF, and the actual status comes from CloseAndRecv.
+func (s *ResultStream) send(req *pb.UploadJobResultRequest) error {
+	err := s.stream.Send(req)
+	if errors.Is(err, io.EOF) {
+		if _, closeErr := s.stream.CloseAndRecv(); closeErr != nil {
+			return closeErr
+		}
+	}
+	return err
+}
+
+// flush sends the buffered rows as one chunk
+func (s *ResultStream) flush() error {
+	if s.pending == 0 {
+		return nil
+	}
+	s.buf.WriteByte(']')
+
+	err := s.send(&pb.UploadJobResultRequest{
+		Part: &pb.UploadJobResultRequest_Chunk{Chunk: &pb.ResultChunk{
+			RowsJson: s.buf.String(),
+			RowCount: int32(s.pending),
+		}},
+	})
+	s.buf.Reset()
+	s.pending = 0
+
+	if err != nil {
+		return fmt.Errorf("failed to send result chunk: %w", err)
+	}
+	return nil
+}
+
+// sendData sends the encoded Arrow data in chunks of chunkBytes, keeping
+// the remainder for the next chunk unless all is set
+func (s *ResultStream) sendData(all bool) error {
+	for s.data.Len() >= s.chunkBytes || (all && s.data.Len() > 0) {
+		data := bytes.Clone(s.data.Next(s.chunkBytes))
+		if err := s.send(&pb.UploadJobResultRequest{
+			Part: &pb.UploadJobResultRequest_Chunk{Chunk: &pb.ResultChunk{Data: data}},
+		}); err != nil {
+			return fmt.Errorf("failed to send result chunk: %w", err)
+		}
+	}
+	return nil
+}
+
+// flushAll sends the rows not sent yet, ending the Arrow stream
+func (s *ResultStream) flushAll() error {
+	if s.arrow == nil {
+		return s.flush()
+	}
+	if err := s.arrow.Close(); err != nil {
+		return err
+	}
+	return s.sendData(true)
+}
+
+// Finish flushes the remaining rows and completes the upload with the
+// summary, which also completes the job on the server. A failed job still
+// sends its summary so the server can discard any rows already received;
+// its error code is taken from the types.QueryError in jobErr.
+func (s *ResultStream) Finish(summary *types.StreamSummary, jobErr error) error {
+	if !s.headerSent {
+		if err := s.WriteHeader(nil); err != nil {
+			return fmt.Errorf("failed to send result header: %w", err)
+		}
+	}
+
+	result := &pb.ResultSummary{Success: jobErr == nil, RowCount: s.rowCount}
+	if jobErr != nil {
+		result.ErrorMessage = jobErr.Error()
+		result.ErrorCode = pb.ErrorCode_ERROR_CODE_INTERNAL
+
+		var queryErr *types.QueryError
+		if errors.As(jobErr, &queryErr) {
+			result.ErrorCode = errorCodes[queryErr.Code]
+			result.NativeErrorCode = queryErr.NativeCode
+			result.NativeErrorMessage = queryErr.NativeMessage
+		}
+	} else {
+		if err := s.flushAll(); err != nil {
+			return err
+		}
+		if summary != nil {
+			result.NextCursor = summary.NextCursor
+		}
+	}
+
+	if err := s.send(&pb.UploadJobResultRequest{
+		Part: &pb.UploadJobResultRequest_Summary{Summary: result},
+	}); err != nil {
+		return fmt.Errorf("failed to send result summary: %w", err)
+	}
+
+	resp, err := s.stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("result upload failed: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("server rejected the result upload")
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"google.golang.org/grpc"
+
+	pb "starless/kadath/gen/proto"
+	"starless/kadath/internal/types"
+)
+
+// fakeUploadStream records the messages of an upload
+type fakeUploadStream struct {
+	grpc.ClientStream
+	sent     []*pb.UploadJobResultRequest
+	closed   bool
+	response *pb.UploadJobResultResponse
+}
+
+func (f *fakeUploadStream) Send(req *pb.UploadJobResultRequest) error {
+	f.sent = append(f.sent, req)
+	return nil
+}
+
+func (f *fakeUploadStream) CloseAndRecv() (*pb.UploadJobResultResponse, error) {
+	f.closed = true
+	return f.response, nil
+}
+
+func newTestResultStream(chunkBytes int) (*ResultStream, *fakeUploadStream) {
+	fake := &fakeUploadStream{response: &pb.UploadJobResultResponse{Success: true}}
+	return &ResultStream{
+		stream:     fake,
+		jobID:      "job-1",
+		agentID:    "agent-1",
+		chunkBytes: chunkBytes,
+	}, fake
+}
+
+func TestResultStreamChunks(t *testing.T) {
+	stream, fake := newTestResultStream(64)
+
+	if err := stream.WriteHeader([]types.ResultColumn{{Name: "id"}, {Name: "name"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		row := []interface{}{i, strings.Repeat("x", 10)}
+		if err := stream.WriteRow(row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := stream.Finish(&types.StreamSummary{RowCount: 5, NextCursor: "next"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	header := fake.sent[0].GetHeader()
+	if header == nil || header.JobId != "job-1" || header.AgentId != "agent-1" || len(header.Columns) != 2 {
+		t.Fatalf("unexpected header: %v", fake.sent[0])
+	}
+
+	rows := 0
+	for _, msg := range fake.sent[1 : len(fake.sent)-1] {
+		chunk := msg.GetChunk()
+		if chunk == nil {
+			t.Fatalf("expected a chunk, got %v", msg)
+		}
+		if len(chunk.RowsJson) > 64 {
+			t.Errorf("chunk of %d bytes exceeds the chunk size", len(chunk.RowsJson))
+		}
+
+		var decoded []map[string]interface{}
+		if err := json.Unmarshal([]byte(chunk.RowsJson), &decoded); err != nil {
+			t.Fatalf("chunk is not a JSON array: %v", err)
+		}
+		if len(decoded) != int(chunk.RowCount) {
+			t.Errorf("chunk has %d rows, row_count says %d", len(decoded), chunk.RowCount)
+		}
+		rows += len(decoded)
+	}
+	if rows != 5 || len(fake.sent) < 4 {
+		t.Errorf("expected 5 rows over several chunks, got %d rows in %d messages", rows, len(fake.sent))
+	}
+
+	summary := fake.sent[len(fake.sent)-1].GetSummary()
+	if summary == nil || !summary.Success || summary.RowCount != 5 || summary.NextCursor != "next" {
+		t.Errorf("unexpected summary: %v", fake.sent[len(fake.sent)-1])
+	}
+	if !fake.closed {
+		t.Error("expected the stream to be closed")
+	}
+}
+
+func TestResultStreamFailure(t *testing.T) {
+	stream, fake := newTestResultStream(DefaultChunkBytes)
+
+	if err := stream.WriteRow([]interface{}{1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := stream.Finish(nil, errors.New("connection reset")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Header, then the summary: buffered rows of a failed job are dropped
+	if len(fake.sent) != 2 || fake.sent[0].GetHeader() == nil {
+		t.Fatalf("unexpected messages: %v", fake.sent)
+	}
+	summary := fake.sent[1].GetSummary()
+	if summary == nil || summary.Success || summary.ErrorMessage != "connection reset" {
+		t.Errorf("unexpected summary: %v", fake.sent[1])
+	}
+	if summary.GetErrorCode() != pb.ErrorCode_ERROR_CODE_INTERNAL {
+		t.Errorf("expected unclassified errors to be internal, got %v", summary.GetErrorCode())
+	}
+}
+
+func TestResultStreamFailureCode(t *testing.T) {
+	stream, fake := newTestResultStream(DefaultChunkBytes)
+
+	err := &types.QueryError{
+		Code:          types.ErrorUnknownColumn,
+		NativeCode:    "42703",
+		NativeMessage: `column "nme" does not exist`,
+		Err:           errors.New(`pq: column "nme" does not exist`),
+	}
+	if err := stream.Finish(nil, fmt.Errorf("Query execution failed: %w", err)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := fake.sent[len(fake.sent)-1].GetSummary()
+	if summary.ErrorCode != pb.ErrorCode_ERROR_CODE_UNKNOWN_COLUMN || summary.NativeErrorCode != "42703" ||
+		summary.NativeErrorMessage != `column "nme" does not exist` {
+		t.Errorf("unexpected summary: %v", summary)
+	}
+	if summary.ErrorMessage != `Query execution failed: pq: column "nme" does not exist` {
+		t.Errorf("unexpected error message: %s", summary.ErrorMessage)
+	}
+}
+
+func TestResultStreamRejected(t *testing.T) {
+	stream, fake := newTestResultStream(DefaultChunkBytes)
+	fake.response = &pb.UploadJobResultResponse{Success: false}
+
+	if err := stream.Finish(&types.StreamSummary{}, nil); err == nil {
+		t.Error("expected error when the server rejects the upload")
+	}
+}
+
+func TestResultStreamColumnar(t *testing.T) {
+	stream, fake := newTestResultStream(DefaultChunkBytes)
+	stream.format = types.ResultFormatColumnar
+
+	nullable := true
+	columns := []types.ResultColumn{
+		{Name: "id", NativeType: "INT4", LogicalType: types.LogicalTypeInt},
+		{Name: "name", NativeType: "TEXT", LogicalType: types.LogicalTypeString, Nullable: &nullable},
+	}
+	if err := stream.WriteHeader(columns); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := stream.WriteRow([]interface{}{1, "bob"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := stream.Finish(&types.StreamSummary{RowCount: 1}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	header := fake.sent[0].GetHeader()
+	if header.ResultFormat != "columnar" || len(header.ColumnTypes) != 2 {
+		t.Fatalf("unexpected header: %v", header)
+	}
+	if header.ColumnTypes[0].NativeType != "INT4" || header.ColumnTypes[0].Nullable != nil || !header.ColumnTypes[1].GetNullable() {
+		t.Errorf("unexpected column types: %v", header.ColumnTypes)
+	}
+	if chunk := fake.sent[1].GetChunk(); chunk.RowsJson != `[[1,"bob"]]` {
+		t.Errorf("unexpected chunk: %v", chunk)
+	}
+}
+
+func TestResultStreamArrow(t *testing.T) {
+	stream, fake := newTestResultStream(64)
+	stream.format = types.ResultFormatArrow
+
+	if err := stream.WriteHeader([]types.ResultColumn{{Name: "id", NativeType: "INT8", LogicalType: types.LogicalTypeInt}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := stream.WriteRow([]interface{}{int64(i)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := stream.Finish(&types.StreamSummary{RowCount: 3}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if header := fake.sent[0].GetHeader(); header.ResultFormat != "arrow" {
+		t.Fatalf("unexpected header: %v", header)
+	}
+
+	var data []byte
+	for _, msg := range fake.sent[1 : len(fake.sent)-1] {
+		chunk := msg.GetChunk()
+		if chunk == nil || chunk.RowsJson != "" || len(chunk.Data) > 64 {
+			t.Fatalf("unexpected chunk: %v", msg)
+		}
+		data = append(data, chunk.Data...)
+	}
+
+	r, err := ipc.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to read arrow stream: %v", err)
+	}
+	defer r.Release()
+
+	rows := int64(0)
+	for r.Next() {
+		rows += r.RecordBatch().NumRows()
+	}
+	if r.Err() != nil || rows != 3 {
+		t.Errorf("expected 3 rows, got %d: %v", rows, r.Err())
+	}
+	if summary := fake.sent[len(fake.sent)-1].GetSummary(); summary.RowCount != 3 {
+		t.Errorf("unexpected summary: %v", summary)
+	}
+}
@@ -0,0 +1,388 @@
+// Package arrowipc encodes query results as Apache Arrow IPC streams
+package arrowipc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"starless/kadath/internal/types"
+)
+
+// DefaultBatchRows is the number of rows of each record batch
+const DefaultBatchRows = 4096
+
+// floatTypes are the native decimal types holding binary floating point
+// values. Other decimals are sent as strings so no precision is lost.
+var floatTypes = map[string]bool{
+	"FLOAT4": true,
+	"FLOAT8": true,
+	"FLOAT":  true,
+	"DOUBLE": true,
+	"REAL":   true,
+}
+
+// timestampTypes are the native time types sent as timestamps. Times of day
+// and intervals have no exact Arrow equivalent and are sent as strings.
+var timestampTypes = map[string]bool{
+	"TIMESTAMP":   true,
+	"TIMESTAMPTZ": true,
+	"DATETIME":    true,
+}
+
+// timeLayouts are the layouts of times returned as text, as MySQL does
+// without parseTime
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Writer encodes rows as an Arrow IPC stream written to out. Rows are
+// buffered into record batches of batchRows rows. It implements
+// types.RowWriter; Close must be called to write the last batch and the end
+// of the stream.
+type Writer struct {
+	out       io.Writer
+	batchRows int
+	mem       memory.Allocator
+
+	columns []types.ResultColumn
+	builder *array.RecordBuilder
+	ipc     *ipc.Writer
+	pending int
+}
+
+// NewWriter returns a Writer writing to out
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{
+		out:       out,
+		batchRows: DefaultBatchRows,
+		mem:       memory.NewGoAllocator(),
+	}
+}
+
+// Schema returns the Arrow schema of a result with columns. The native type
+// of each column is kept in the field metadata. Date and timestamp fields
+// are always nullable, since MySQL zero dates are written as nulls.
+func Schema(columns []types.ResultColumn) *arrow.Schema {
+	fields := make([]arrow.Field, len(columns))
+	for i, col := range columns {
+		typ := dataType(col)
+		nullable := col.Nullable == nil || *col.Nullable
+		if typ.ID() == arrow.DATE32 || typ.ID() == arrow.TIMESTAMP {
+			nullable = true
+		}
+		fields[i] = arrow.Field{
+			Name:     col.Name,
+			Type:     typ,
+			Nullable: nullable,
+			Metadata: arrow.NewMetadata([]string{"native_type"}, []string{col.NativeType}),
+		}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+func dataType(col types.ResultColumn) arrow.DataType {
+	native := strings.ToUpper(col.NativeType)
+
+	switch col.LogicalType {
+	case types.LogicalTypeInt:
+		if strings.HasPrefix(native, "UNSIGNED BIGINT") {
+			return arrow.PrimitiveTypes.Uint64
+		}
+		return arrow.PrimitiveTypes.Int64
+	case types.LogicalTypeDecimal:
+		if floatTypes[native] {
+			return arrow.PrimitiveTypes.Float64
+		}
+	case types.LogicalTypeTime:
+		if native == "DATE" {
+			return arrow.FixedWidthTypes.Date32
+		}
+		if timestampTypes[native] {
+			return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+		}
+	case types.LogicalTypeBool:
+		return arrow.FixedWidthTypes.Boolean
+	case types.LogicalTypeBinary:
+		return arrow.BinaryTypes.Binary
+	}
+	return arrow.BinaryTypes.String
+}
+
+// WriteHeader implements types.RowWriter
+func (w *Writer) WriteHeader(columns []types.ResultColumn) error {
+	if w.ipc != nil {
+		return fmt.Errorf("header already written")
+	}
+
+	schema := Schema(columns)
+	w.columns = columns
+	w.builder = array.NewRecordBuilder(w.mem, schema)
+	w.ipc = ipc.NewWriter(w.out, ipc.WithSchema(schema), ipc.WithAllocator(w.mem))
+	return nil
+}
+
+// WriteRow implements types.RowWriter
+func (w *Writer) WriteRow(values []interface{}) error {
+	if w.ipc == nil {
+		if err := w.WriteHeader(nil); err != nil {
+			return err
+		}
+	}
+
+	for i, col := range w.columns {
+		var val interface{}
+		if i < len(values) {
+			val = values[i]
+		}
+		if err := appendValue(w.builder.Field(i), val); err != nil {
+			return fmt.Errorf("column %s: %w", col.Name, err)
+		}
+	}
+
+	w.pending++
+	if w.pending >= w.batchRows {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows as a record batch
+func (w *Writer) Flush() error {
+	if w.pending == 0 {
+		return nil
+	}
+
+	rec := w.builder.NewRecordBatch()
+	defer rec.Release()
+	w.pending = 0
+
+	if err := w.ipc.Write(rec); err != nil {
+		return fmt.Errorf("failed to write record batch: %w", err)
+	}
+	return nil
+}
+
+// Close writes the remaining rows and the end of the stream. A result
+// without a header is written with an empty schema.
+func (w *Writer) Close() error {
+	if w.ipc == nil {
+		if err := w.WriteHeader(nil); err != nil {
+			return err
+		}
+	}
+	defer w.builder.Release()
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := w.ipc.Close(); err != nil {
+		return fmt.Errorf("failed to close arrow stream: %w", err)
+	}
+	return nil
+}
+
+// appendValue appends a result value to b. Values normalized to text, such
+// as timestamps and integers beyond the range of JSON numbers, are parsed
+// into the column type. MySQL zero dates, which have no Arrow time, are
+// appended as null.
+func appendValue(b array.Builder, val interface{}) error {
+	if val == nil {
+		b.AppendNull()
+		return nil
+	}
+
+	switch b := b.(type) {
+	case *array.Int64Builder:
+		v, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Uint64Builder:
+		v, err := toUint64(val)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Float64Builder:
+		v, err := toFloat64(val)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.BooleanBuilder:
+		v, err := toBool(val)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.TimestampBuilder:
+		if isZeroDate(val) {
+			b.AppendNull()
+			break
+		}
+		t, err := toTime(val)
+		if err != nil {
+			return err
+		}
+		b.Append(arrow.Timestamp(t.UnixMicro()))
+	case *array.Date32Builder:
+		if isZeroDate(val) {
+			b.AppendNull()
+			break
+		}
+		t, err := toTime(val)
+		if err != nil {
+			return err
+		}
+		b.Append(arrow.Date32FromTime(t))
+	case *array.BinaryBuilder:
+		switch v := val.(type) {
+		case []byte:
+			b.Append(v)
+		case string:
+			b.AppendString(v)
+		default:
+			return fmt.Errorf("cannot convert %T to binary", val)
+		}
+	case *array.StringBuilder:
+		b.Append(toString(val))
+	default:
+		return fmt.Errorf("unsupported arrow type %s", b.Type())
+	}
+	return nil
+}
+
+func toInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case uint64:
+		if v > 1<<63-1 {
+			return 0, fmt.Errorf("%d overflows int64", v)
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to int64", val)
+	}
+}
+
+func toUint64(val interface{}) (uint64, error) {
+	switch v := val.(type) {
+	case uint64:
+		return v, nil
+	case int64:
+		if v < 0 {
+			return 0, fmt.Errorf("%d is negative", v)
+		}
+		return uint64(v), nil
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	case []byte:
+		return strconv.ParseUint(string(v), 10, 64)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to uint64", val)
+	}
+}
+
+func toFloat64(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to float64", val)
+	}
+}
+
+func toBool(val interface{}) (bool, error) {
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	case string:
+		return strconv.ParseBool(v)
+	default:
+		return false, fmt.Errorf("cannot convert %T to bool", val)
+	}
+}
+
+func toTime(val interface{}) (time.Time, error) {
+	var s string
+	switch v := val.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to time", val)
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time %q", s)
+}
+
+// isZeroDate reports whether val is a MySQL zero date or datetime
+func isZeroDate(val interface{}) bool {
+	switch v := val.(type) {
+	case string:
+		return strings.HasPrefix(v, "0000-00-00")
+	case []byte:
+		return strings.HasPrefix(string(v), "0000-00-00")
+	}
+	return false
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case json.RawMessage:
+		return string(v)
+	case []interface{}:
+		// Arrays, sent as JSON like JSON columns
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+		return fmt.Sprint(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
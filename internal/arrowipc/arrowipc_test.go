@@ -0,0 +1,162 @@
+package arrowipc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+
+	"starless/kadath/internal/types"
+)
+
+func TestSchema(t *testing.T) {
+	notNull := false
+	schema := Schema([]types.ResultColumn{
+		{Name: "id", NativeType: "INT8", LogicalType: types.LogicalTypeInt, Nullable: &notNull},
+		{Name: "hits", NativeType: "UNSIGNED BIGINT", LogicalType: types.LogicalTypeInt},
+		{Name: "ratio", NativeType: "FLOAT8", LogicalType: types.LogicalTypeDecimal},
+		{Name: "price", NativeType: "NUMERIC", LogicalType: types.LogicalTypeDecimal},
+		{Name: "day", NativeType: "DATE", LogicalType: types.LogicalTypeTime},
+		{Name: "at", NativeType: "TIMESTAMPTZ", LogicalType: types.LogicalTypeTime},
+		{Name: "span", NativeType: "INTERVAL", LogicalType: types.LogicalTypeTime},
+	})
+
+	want := []arrow.Type{arrow.INT64, arrow.UINT64, arrow.FLOAT64, arrow.STRING, arrow.DATE32, arrow.TIMESTAMP, arrow.STRING}
+	for i, field := range schema.Fields() {
+		if field.Type.ID() != want[i] {
+			t.Errorf("%s: expected %s, got %s", field.Name, want[i], field.Type)
+		}
+	}
+	if schema.Field(0).Nullable || !schema.Field(1).Nullable {
+		t.Errorf("unexpected nullability: %v", schema)
+	}
+	if native, _ := schema.Field(3).Metadata.GetValue("native_type"); native != "NUMERIC" {
+		t.Errorf("expected the native type in metadata, got %q", native)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.batchRows = 2
+
+	columns := []types.ResultColumn{
+		{Name: "id", NativeType: "INT", LogicalType: types.LogicalTypeInt},
+		{Name: "name", NativeType: "VARCHAR", LogicalType: types.LogicalTypeString},
+		{Name: "at", NativeType: "DATETIME", LogicalType: types.LogicalTypeTime},
+	}
+	if err := w.WriteHeader(columns); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := [][]interface{}{
+		{int64(1), "a", at},
+		// MySQL text protocol values
+		{"2", "b", "2024-05-01 12:00:00"},
+		{nil, nil, nil},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+	defer r.Release()
+
+	var ids []int64
+	var times []arrow.Timestamp
+	batches := 0
+	for r.Next() {
+		rec := r.RecordBatch()
+		batches++
+		idCol := rec.Column(0).(*array.Int64)
+		atCol := rec.Column(2).(*array.Timestamp)
+		for i := 0; i < int(rec.NumRows()); i++ {
+			if idCol.IsNull(i) {
+				if !atCol.IsNull(i) {
+					t.Errorf("expected a null row")
+				}
+				continue
+			}
+			ids = append(ids, idCol.Value(i))
+			times = append(times, atCol.Value(i))
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if batches != 2 || len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("unexpected rows: %d batches, ids %v", batches, ids)
+	}
+	if times[0] != times[1] || times[0] != arrow.Timestamp(at.UnixMicro()) {
+		t.Errorf("unexpected times: %v", times)
+	}
+}
+
+func TestWriterInvalidValue(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{})
+	if err := w.WriteHeader([]types.ResultColumn{{Name: "id", LogicalType: types.LogicalTypeInt}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.WriteRow([]interface{}{"abc"}); err == nil {
+		t.Error("expected an error for a value that is not an integer")
+	}
+}
+
+func TestWriterZeroDate(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	notNull := false
+	columns := []types.ResultColumn{
+		{Name: "at", NativeType: "DATETIME", LogicalType: types.LogicalTypeTime, Nullable: &notNull},
+		{Name: "day", NativeType: "DATE", LogicalType: types.LogicalTypeTime, Nullable: &notNull},
+	}
+	if err := w.WriteHeader(columns); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// MySQL returns zero dates as text, which Arrow has no time for
+	for _, row := range [][]interface{}{{"0000-00-00 00:00:00", "0000-00-00"}, {"2024-05-01 12:00:00", "2024-05-01"}} {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("zero dates should not fail the result: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+	defer r.Release()
+
+	if !r.Schema().Field(0).Nullable || !r.Schema().Field(1).Nullable {
+		t.Error("expected date and timestamp fields to be nullable")
+	}
+	if !r.Next() {
+		t.Fatalf("expected a record batch: %v", r.Err())
+	}
+	rec := r.RecordBatch()
+	atCol := rec.Column(0).(*array.Timestamp)
+	dayCol := rec.Column(1).(*array.Date32)
+	if rec.NumRows() != 2 || !atCol.IsNull(0) || !dayCol.IsNull(0) {
+		t.Errorf("expected zero dates to be null, got %v", rec)
+	}
+	if atCol.IsNull(1) || dayCol.IsNull(1) {
+		t.Errorf("unexpected second row: %v", rec)
+	}
+}
@@ -10,3 +10,10 @@ import (
 func NewEngine(cfg *configs.Config) (types.Engine, error) {
 	return newEngine(cfg)
 }
+
+// ClassifyError returns the error code of an error of the engine, with the
+// native code and message of the database error it comes from. It returns
+// nil for errors the engine does not know.
+func ClassifyError(err error) *types.QueryError {
+	return classifyError(err)
+}
@@ -8,6 +8,13 @@ import (
 	"starless/kadath/internal/types"
 )
 
+// Type is the database engine the agent is built for
+const Type = "mysql"
+
 func newEngine(cfg *configs.Config) (types.Engine, error) {
 	return mysql.NewEngine(cfg)
 }
+
+func classifyError(err error) *types.QueryError {
+	return mysql.ClassifyError(err)
+}
@@ -8,6 +8,13 @@ import (
 	"starless/kadath/internal/types"
 )
 
+// Type is the database engine the agent is built for
+const Type = "postgres"
+
 func newEngine(cfg *configs.Config) (types.Engine, error) {
 	return postgres.NewEngine(cfg)
 }
+
+func classifyError(err error) *types.QueryError {
+	return postgres.ClassifyError(err)
+}
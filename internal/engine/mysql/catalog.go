@@ -0,0 +1,223 @@
+//go:build mysql
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"starless/kadath/internal/types"
+)
+
+const systemSchemaList = `'mysql', 'information_schema', 'performance_schema', 'sys'`
+
+const describeSchemasQuery = `SELECT SCHEMA_NAME
+FROM information_schema.SCHEMATA
+WHERE SCHEMA_NAME NOT IN (` + systemSchemaList + `)
+ORDER BY SCHEMA_NAME`
+
+const describeRelationsQuery = `SELECT t.TABLE_SCHEMA, t.TABLE_NAME, t.TABLE_TYPE, t.TABLE_COMMENT,
+	c.COLUMN_NAME, c.COLUMN_TYPE, c.IS_NULLABLE, c.COLUMN_DEFAULT, c.COLUMN_COMMENT
+FROM information_schema.TABLES t
+LEFT JOIN information_schema.COLUMNS c ON c.TABLE_SCHEMA = t.TABLE_SCHEMA AND c.TABLE_NAME = t.TABLE_NAME
+WHERE t.TABLE_SCHEMA NOT IN (` + systemSchemaList + `)
+ORDER BY t.TABLE_SCHEMA, t.TABLE_NAME, c.ORDINAL_POSITION`
+
+// tableType maps an information_schema TABLE_TYPE to a TableType
+func tableType(tableType string) types.TableType {
+	switch tableType {
+	case "VIEW", "SYSTEM VIEW":
+		return types.TableTypeView
+	default:
+		return types.TableTypeTable
+	}
+}
+
+// commentPtr returns nil for missing or empty comments. MySQL reports an
+// empty string rather than NULL for uncommented objects, and "VIEW" as the
+// comment of every view.
+func commentPtr(s sql.NullString, kind types.TableType) *string {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	if kind == types.TableTypeView && s.String == "VIEW" {
+		return nil
+	}
+	return &s.String
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func (e *mysqlEngine) DescribeSchema(ctx context.Context) (*types.SchemaResponse, error) {
+	builder := types.NewSchemaBuilder()
+
+	conn, release, err := e.queryConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	schemaRows, err := conn.QueryContext(ctx, describeSchemasQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", timeoutError(ctx, err))
+	}
+	defer schemaRows.Close()
+
+	for schemaRows.Next() {
+		var name string
+		if err := schemaRows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan schema: %w", err)
+		}
+		builder.AddSchema(name, nil)
+	}
+	if err := schemaRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schemas: %w", timeoutError(ctx, err))
+	}
+
+	rows, err := conn.QueryContext(ctx, describeRelationsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relations: %w", timeoutError(ctx, err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table, kind string
+		var tableComment, column, dataType, isNullable, columnDefault, columnComment sql.NullString
+		if err := rows.Scan(&schema, &table, &kind, &tableComment,
+			&column, &dataType, &isNullable, &columnDefault, &columnComment); err != nil {
+			return nil, fmt.Errorf("failed to scan relation: %w", err)
+		}
+
+		tt := tableType(kind)
+		builder.AddTable(schema, types.TableDescription{
+			Name:    table,
+			Type:    tt,
+			Comment: commentPtr(tableComment, tt),
+		})
+
+		// Tables the user cannot see the columns of come back as NULLs
+		if !column.Valid {
+			continue
+		}
+		builder.AddColumn(schema, table, types.ColumnDescription{
+			Name:     column.String,
+			DataType: dataType.String,
+			Nullable: isNullable.String == "YES",
+			Default:  nullStringPtr(columnDefault),
+			Comment:  commentPtr(columnComment, types.TableTypeTable),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating relations: %w", timeoutError(ctx, err))
+	}
+
+	return builder.Response(), nil
+}
+
+const fetchColumnsQuery = `SELECT TABLE_SCHEMA, COLUMN_NAME, ORDINAL_POSITION, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT,
+	CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE, COLUMN_KEY = 'PRI'
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = COALESCE(?, DATABASE()) AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`
+
+// logicalType maps a MySQL column type, either a full COLUMN_TYPE such as
+// "int(10) unsigned" or a driver type name such as "UNSIGNED INT", to a
+// LogicalType. tinyint(1) is MySQL's conventional boolean.
+func logicalType(columnType string) types.LogicalType {
+	name := strings.ToLower(strings.TrimSpace(columnType))
+	name = strings.TrimPrefix(name, "unsigned ")
+
+	base := name
+	if i := strings.IndexAny(base, "( "); i >= 0 {
+		base = base[:i]
+	}
+
+	switch base {
+	case "tinyint":
+		if strings.HasPrefix(name, "tinyint(1)") {
+			return types.LogicalTypeBool
+		}
+		return types.LogicalTypeInt
+	case "bool", "boolean":
+		return types.LogicalTypeBool
+	case "smallint", "mediumint", "int", "integer", "bigint", "year":
+		return types.LogicalTypeInt
+	case "decimal", "numeric", "float", "double", "real":
+		return types.LogicalTypeDecimal
+	case "date", "datetime", "timestamp", "time":
+		return types.LogicalTypeTime
+	case "json":
+		return types.LogicalTypeJSON
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit", "geometry":
+		return types.LogicalTypeBinary
+	default:
+		return types.LogicalTypeString
+	}
+}
+
+func nullInt64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func (e *mysqlEngine) FetchColumns(ctx context.Context, params *types.FetchColumnsParams) (*types.ColumnsResponse, error) {
+	var schemaName interface{}
+	if params.SchemaName != nil {
+		schemaName = *params.SchemaName
+	}
+
+	conn, release, err := e.queryConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, fetchColumnsQuery, schemaName, params.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch columns: %w", timeoutError(ctx, err))
+	}
+	defer rows.Close()
+
+	resp := &types.ColumnsResponse{
+		Table:   params.Table,
+		Columns: []types.ColumnDetail{},
+	}
+
+	for rows.Next() {
+		var col types.ColumnDetail
+		var isNullable string
+		var columnDefault sql.NullString
+		var charLength, precision, scale sql.NullInt64
+		if err := rows.Scan(&resp.SchemaName, &col.Name, &col.Position, &col.NativeType, &isNullable,
+			&columnDefault, &charLength, &precision, &scale, &col.PrimaryKey); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+
+		col.LogicalType = logicalType(col.NativeType)
+		col.Nullable = isNullable == "YES"
+		col.Default = nullStringPtr(columnDefault)
+		col.CharacterMaxLength = nullInt64Ptr(charLength)
+		col.NumericPrecision = nullInt64Ptr(precision)
+		col.NumericScale = nullInt64Ptr(scale)
+
+		resp.Columns = append(resp.Columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns: %w", timeoutError(ctx, err))
+	}
+
+	if len(resp.Columns) == 0 {
+		return nil, &types.QueryError{Code: types.ErrorUnknownTable, Err: fmt.Errorf("table %s not found", params.Table)}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,208 @@
+//go:build mysql
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"starless/kadath/internal/types"
+)
+
+func TestMySQLDescribeSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+
+	mock.ExpectQuery("SELECT SCHEMA_NAME\\s+FROM information_schema.SCHEMATA").
+		WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME"}).
+			AddRow("app").
+			AddRow("empty"))
+
+	mock.ExpectQuery("FROM information_schema.TABLES t").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"TABLE_SCHEMA", "TABLE_NAME", "TABLE_TYPE", "TABLE_COMMENT",
+			"COLUMN_NAME", "COLUMN_TYPE", "IS_NULLABLE", "COLUMN_DEFAULT", "COLUMN_COMMENT",
+		}).
+			AddRow("app", "active_users", "VIEW", "VIEW", "id", "int", "NO", nil, "").
+			AddRow("app", "users", "BASE TABLE", "Registered users", "id", "int unsigned", "NO", nil, "").
+			AddRow("app", "users", "BASE TABLE", "Registered users", "status", "varchar(20)", "YES", "active", "Account state"))
+
+	result, err := eng.DescribeSchema(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+
+	if len(result.Schemas) != 2 {
+		t.Fatalf("expected 2 schemas, got %d", len(result.Schemas))
+	}
+
+	app := result.Schemas[0]
+	if len(app.Tables) != 2 {
+		t.Fatalf("expected 2 tables, got %d", len(app.Tables))
+	}
+
+	view := app.Tables[0]
+	if view.Type != types.TableTypeView || view.Comment != nil {
+		t.Errorf("expected uncommented view, got %+v", view)
+	}
+
+	users := app.Tables[1]
+	if users.Comment == nil || *users.Comment != "Registered users" {
+		t.Errorf("expected table comment, got %v", users.Comment)
+	}
+	if len(users.Columns) != 2 {
+		t.Fatalf("expected 2 columns, got %d", len(users.Columns))
+	}
+	if users.Columns[0].Nullable || users.Columns[0].Comment != nil {
+		t.Errorf("unexpected id column: %+v", users.Columns[0])
+	}
+	status := users.Columns[1]
+	if !status.Nullable || status.Default == nil || *status.Default != "active" || status.Comment == nil {
+		t.Errorf("unexpected status column: %+v", status)
+	}
+
+	if len(result.Schemas[1].Tables) != 0 {
+		t.Errorf("expected empty schema, got %d tables", len(result.Schemas[1].Tables))
+	}
+}
+
+func TestMySQLDescribeSchemaError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+
+	mock.ExpectQuery("SELECT SCHEMA_NAME").WillReturnError(sqlmock.ErrCancelled)
+
+	if _, err := eng.DescribeSchema(context.Background()); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestMySQLFetchColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+
+	mock.ExpectQuery("FROM information_schema.COLUMNS").
+		WithArgs(nil, "orders").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"TABLE_SCHEMA", "COLUMN_NAME", "ORDINAL_POSITION", "COLUMN_TYPE", "IS_NULLABLE", "COLUMN_DEFAULT",
+			"CHARACTER_MAXIMUM_LENGTH", "NUMERIC_PRECISION", "NUMERIC_SCALE", "pk",
+		}).
+			AddRow("app", "id", 1, "bigint unsigned", "NO", nil, nil, 20, 0, true).
+			AddRow("app", "paid", 2, "tinyint(1)", "NO", "0", nil, 3, 0, false).
+			AddRow("app", "total", 3, "decimal(10,2)", "YES", nil, nil, 10, 2, false).
+			AddRow("app", "created_at", 4, "datetime", "NO", "CURRENT_TIMESTAMP", nil, nil, nil, false).
+			AddRow("app", "note", 5, "varchar(255)", "YES", nil, 255, nil, nil, false))
+
+	result, err := eng.FetchColumns(context.Background(), &types.FetchColumnsParams{Table: "orders"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+
+	if result.SchemaName != "app" {
+		t.Errorf("expected schema app, got %s", result.SchemaName)
+	}
+	if len(result.Columns) != 5 {
+		t.Fatalf("expected 5 columns, got %d", len(result.Columns))
+	}
+
+	expected := []types.LogicalType{
+		types.LogicalTypeInt,
+		types.LogicalTypeBool,
+		types.LogicalTypeDecimal,
+		types.LogicalTypeTime,
+		types.LogicalTypeString,
+	}
+	for i, col := range result.Columns {
+		if col.LogicalType != expected[i] {
+			t.Errorf("column %s: expected %s, got %s", col.Name, expected[i], col.LogicalType)
+		}
+	}
+
+	if !result.Columns[0].PrimaryKey || result.Columns[1].PrimaryKey {
+		t.Error("expected only id to be the primary key")
+	}
+	if result.Columns[4].CharacterMaxLength == nil || *result.Columns[4].CharacterMaxLength != 255 {
+		t.Errorf("unexpected note length: %v", result.Columns[4].CharacterMaxLength)
+	}
+}
+
+func TestMySQLFetchColumnsTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Catalog queries run on a connection watched like any other query
+	mock.ExpectQuery("SELECT CONNECTION_ID\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID()"}).AddRow(42))
+	mock.ExpectExec("SET SESSION max_execution_time = [0-9]+").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM information_schema.COLUMNS").
+		WithArgs(nil, "orders").
+		WillReturnError(&mysqldriver.MySQLError{Number: 3024, Message: "Query execution was interrupted, maximum statement execution time exceeded"})
+	mock.ExpectExec("SET SESSION max_execution_time = DEFAULT").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = eng.FetchColumns(ctx, &types.FetchColumnsParams{Table: "orders"})
+	if !errors.Is(err, types.ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMySQLLogicalType(t *testing.T) {
+	tests := map[string]types.LogicalType{
+		"int(11)":         types.LogicalTypeInt,
+		"UNSIGNED BIGINT": types.LogicalTypeInt,
+		"tinyint(1)":      types.LogicalTypeBool,
+		"tinyint(4)":      types.LogicalTypeInt,
+		"TINYINT":         types.LogicalTypeInt,
+		"decimal(10,2)":   types.LogicalTypeDecimal,
+		"DOUBLE":          types.LogicalTypeDecimal,
+		"datetime(6)":     types.LogicalTypeTime,
+		"json":            types.LogicalTypeJSON,
+		"varbinary(16)":   types.LogicalTypeBinary,
+		"BLOB":            types.LogicalTypeBinary,
+		"enum('a','b')":   types.LogicalTypeString,
+		"VARCHAR":         types.LogicalTypeString,
+	}
+
+	for name, expected := range tests {
+		if got := logicalType(name); got != expected {
+			t.Errorf("logicalType(%q) = %s, want %s", name, got, expected)
+		}
+	}
+}
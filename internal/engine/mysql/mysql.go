@@ -5,51 +5,147 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
 
 	"starless/kadath/configs"
+	"starless/kadath/internal/sqlguard"
 	"starless/kadath/internal/types"
 )
 
 type mysqlEngine struct {
-	db *sql.DB
+	db             *sql.DB
+	allowRawSelect bool
+
+	// sessionTimeZone is set when the DSN sets its own session time zone,
+	// which is read once into timestampLocation
+	sessionTimeZone   bool
+	mu                sync.Mutex
+	timestampLocation *time.Location
+}
+
+// identifiers quotes every table and column name with backticks
+var identifiers = types.MySQLIdentifiers
+
+// buildDSN sets the session time zone to UTC unless baseDSN sets one, which
+// it reports. TIMESTAMP values are returned as text in the session time
+// zone.
+func buildDSN(baseDSN string) (string, bool, error) {
+	cfg, err := mysqldriver.ParseDSN(baseDSN)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid mysql DSN: %w", err)
+	}
+	if _, ok := cfg.Params["time_zone"]; ok {
+		return baseDSN, true, nil
+	}
+
+	if cfg.Params == nil {
+		cfg.Params = map[string]string{}
+	}
+	// The driver runs SET time_zone on every new connection
+	cfg.Params["time_zone"] = "'+00:00'"
+
+	return cfg.FormatDSN(), false, nil
 }
 
 func NewEngine(cfg *configs.Config) (types.Engine, error) {
-	db, err := sql.Open("mysql", cfg.DSN)
+	dsn, sessionTimeZone, err := buildDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open mysql connection: %w", err)
 	}
 
 	return &mysqlEngine{
-		db: db,
+		db:              db,
+		allowRawSelect:  cfg.AllowRawSelect,
+		sessionTimeZone: sessionTimeZone,
 	}, nil
 }
 
+// timestampZone returns the zone of the TIMESTAMP text the server returns.
+// A session time zone set by the DSN is read from the server on first use.
+func (e *mysqlEngine) timestampZone(ctx context.Context) (*time.Location, error) {
+	if !e.sessionTimeZone {
+		return time.UTC, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.timestampLocation != nil {
+		return e.timestampLocation, nil
+	}
+
+	var zone string
+	var offset int
+	if err := e.db.QueryRowContext(ctx, "SELECT @@session.time_zone, TIMESTAMPDIFF(SECOND, UTC_TIMESTAMP(), NOW())").Scan(&zone, &offset); err != nil {
+		return nil, fmt.Errorf("failed to get session time zone: %w", err)
+	}
+	e.timestampLocation = sessionLocation(zone, offset)
+	return e.timestampLocation, nil
+}
+
+// sessionLocation returns the location of a MySQL time zone setting: a
+// "+HH:MM" offset or a named zone. Zones Go does not know, such as SYSTEM,
+// fall back to offset, the current offset from UTC in seconds.
+func sessionLocation(zone string, offset int) *time.Location {
+	if len(zone) > 1 && (zone[0] == '+' || zone[0] == '-') {
+		hours, minutes, _ := strings.Cut(zone[1:], ":")
+		h, errH := strconv.Atoi(hours)
+		m, errM := strconv.Atoi(minutes)
+		if errH == nil && errM == nil {
+			secs := h*3600 + m*60
+			if zone[0] == '-' {
+				secs = -secs
+			}
+			return time.FixedZone(zone, secs)
+		}
+	}
+	if zone != "SYSTEM" {
+		if loc, err := time.LoadLocation(zone); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(zone, offset)
+}
+
 func (e *mysqlEngine) buildQuery(params *types.QueryParams) (string, []interface{}, error) {
 	var query string
 	var args []interface{}
 
 	// SELECT clause
-	selectClause := "*"
-	if params.Select != nil && *params.Select != "" {
-		selectClause = *params.Select
+	selectClause, err := e.buildSelect(params)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid select: %w", err)
 	}
 
 	// FROM clause with optional schema
-	tableName := params.Table
-	if params.SchemaName != nil && *params.SchemaName != "" {
-		tableName = fmt.Sprintf("%s.%s", *params.SchemaName, params.Table)
+	tableName, err := identifiers.QuoteTable(params.SchemaName, params.Table)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid table: %w", err)
 	}
 
 	query = fmt.Sprintf("SELECT %s FROM %s", selectClause, tableName)
 
-	// WHERE clause
-	if len(params.Conditions) > 0 {
+	// WHERE clause, including the keyset condition of a cursor. MySQL
+	// sorts NULLs as smaller than any other value.
+	conditions, skip, err := params.PageConditions(false)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if len(conditions) > 0 {
 		whereClauses := []string{}
-		for _, cond := range params.Conditions {
+		for _, cond := range conditions {
 			clause, condArgs, err := e.buildCondition(cond)
 			if err != nil {
 				return "", nil, fmt.Errorf("failed to build condition: %w", err)
@@ -62,7 +158,13 @@ func (e *mysqlEngine) buildQuery(params *types.QueryParams) (string, []interface
 
 	// GROUP BY clause
 	if len(params.GroupBy) > 0 {
-		query += " GROUP BY " + types.JoinColumns(params.GroupBy)
+		groupBy := make([]string, len(params.GroupBy))
+		for i, col := range params.GroupBy {
+			if groupBy[i], err = identifiers.QuoteColumn(col); err != nil {
+				return "", nil, fmt.Errorf("invalid group by: %w", err)
+			}
+		}
+		query += " GROUP BY " + types.JoinColumns(groupBy)
 	}
 
 	// HAVING clause
@@ -79,60 +181,232 @@ func (e *mysqlEngine) buildQuery(params *types.QueryParams) (string, []interface
 		query += " HAVING " + types.JoinWithAnd(havingClauses)
 	}
 
-	// LIMIT clause
+	// ORDER BY clause
+	if len(params.OrderBy) > 0 {
+		orderBy, err := e.buildOrderBy(params)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid order by: %w", err)
+		}
+		query += " ORDER BY " + orderBy
+	}
+
+	// LIMIT and OFFSET clauses. MySQL only accepts OFFSET after a LIMIT, so
+	// an offset without a limit uses the largest row count it supports.
 	if params.Limit != nil && *params.Limit > 0 {
 		query += " LIMIT ?"
 		args = append(args, *params.Limit)
+	} else if skip > 0 {
+		query += " LIMIT 18446744073709551615"
+	}
+
+	if skip > 0 {
+		query += " OFFSET ?"
+		args = append(args, skip)
 	}
 
 	return query, args, nil
 }
 
+// rawSelect reports whether the legacy select string of params is pasted
+// into the query verbatim
+func (e *mysqlEngine) rawSelect(params *types.QueryParams) bool {
+	return e.allowRawSelect && len(params.Projections) == 0 && params.Select != nil && *params.Select != ""
+}
+
+// buildSelect renders the select list from structured projections or the
+// legacy select string
+func (e *mysqlEngine) buildSelect(params *types.QueryParams) (string, error) {
+	selectClause := "*"
+
+	switch {
+	case len(params.Projections) > 0:
+		items := make([]string, len(params.Projections))
+		for i, p := range params.Projections {
+			item, err := identifiers.QuoteProjection(p)
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		selectClause = types.JoinColumns(items)
+
+	case e.rawSelect(params):
+		// Legacy contract, only enabled by explicit configuration. The
+		// string is checked by sqlguard before it is pasted in.
+		if err := sqlguard.ValidateSelectList(*params.Select, sqlguard.DialectMySQL); err != nil {
+			return "", err
+		}
+		selectClause = *params.Select
+
+	case params.Select != nil && *params.Select != "":
+		items, err := types.ParseSelectList(*params.Select)
+		if err != nil {
+			return "", err
+		}
+		for i, item := range items {
+			if items[i], err = identifiers.QuoteSelectItem(item); err != nil {
+				return "", err
+			}
+		}
+		selectClause = types.JoinColumns(items)
+	}
+
+	if params.Distinct {
+		selectClause = "DISTINCT " + selectClause
+	}
+
+	return selectClause, nil
+}
+
+// buildOrderBy renders the ORDER BY list. MySQL has no NULLS FIRST or
+// NULLS LAST; it sorts NULLs first when ascending and last when descending,
+// so the opposite orderings are emulated with a leading IS NULL sort key.
+func (e *mysqlEngine) buildOrderBy(params *types.QueryParams) (string, error) {
+	terms := make([]string, 0, len(params.OrderBy))
+	for _, term := range params.OrderBy {
+		resolved := params.ResolveOrderTerm(term)
+		if err := resolved.Validate(); err != nil {
+			return "", err
+		}
+
+		expr, err := identifiers.QuoteAggregate(resolved.Aggregate, resolved.Column)
+		if err != nil {
+			return "", err
+		}
+
+		nullsFirst := resolved.Nulls == types.NullsFirst
+		if resolved.Nulls != "" && nullsFirst == resolved.Descending() {
+			if nullsFirst {
+				terms = append(terms, expr+" IS NULL DESC")
+			} else {
+				terms = append(terms, expr+" IS NULL ASC")
+			}
+		}
+
+		if resolved.Descending() {
+			terms = append(terms, expr+" DESC")
+		} else {
+			terms = append(terms, expr+" ASC")
+		}
+	}
+
+	return types.JoinColumns(terms), nil
+}
+
+// quoteConditionColumn renders the left hand side of a condition, which is
+// an aggregate in HAVING conditions
+func quoteConditionColumn(cond types.Condition) (string, error) {
+	if cond.Aggregate != "" {
+		return identifiers.QuoteAggregate(cond.Aggregate, cond.Column)
+	}
+	return identifiers.QuoteColumn(cond.Column)
+}
+
 func (e *mysqlEngine) buildCondition(cond types.Condition) (string, []interface{}, error) {
+	if cond.IsGroup() {
+		return e.buildConditionGroup(cond)
+	}
+
 	var clause string
 	var args []interface{}
 
+	column, err := quoteConditionColumn(cond)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid column: %w", err)
+	}
+
 	switch cond.Type {
 	case types.ConditionTypeEqual:
-		clause = fmt.Sprintf("%s = ?", cond.Column)
+		clause = fmt.Sprintf("%s = ?", column)
 		args = append(args, cond.Value)
 
 	case types.ConditionTypeNotEqual:
-		clause = fmt.Sprintf("%s != ?", cond.Column)
+		clause = fmt.Sprintf("%s != ?", column)
 		args = append(args, cond.Value)
 
 	case types.ConditionTypeGreaterThan:
-		clause = fmt.Sprintf("%s > ?", cond.Column)
+		clause = fmt.Sprintf("%s > ?", column)
 		args = append(args, cond.Value)
 
 	case types.ConditionTypeGreaterThanOrEqual:
-		clause = fmt.Sprintf("%s >= ?", cond.Column)
+		clause = fmt.Sprintf("%s >= ?", column)
 		args = append(args, cond.Value)
 
 	case types.ConditionTypeLessThan:
-		clause = fmt.Sprintf("%s < ?", cond.Column)
+		clause = fmt.Sprintf("%s < ?", column)
 		args = append(args, cond.Value)
 
 	case types.ConditionTypeLessThanOrEqual:
-		clause = fmt.Sprintf("%s <= ?", cond.Column)
+		clause = fmt.Sprintf("%s <= ?", column)
 		args = append(args, cond.Value)
 
 	case types.ConditionTypeLike:
-		clause = fmt.Sprintf("%s LIKE ?", cond.Column)
+		clause = fmt.Sprintf("%s LIKE ?", column)
 		args = append(args, cond.Value)
 
-	case types.ConditionTypeIn:
-		// For MySQL IN clause with a slice
-		// Note: This is a simplified version. For proper IN support,
-		// we'd need to expand the slice into multiple ? placeholders
-		clause = fmt.Sprintf("%s IN (?)", cond.Column)
+	case types.ConditionTypeNotLike:
+		clause = fmt.Sprintf("%s NOT LIKE ?", column)
 		args = append(args, cond.Value)
 
+	case types.ConditionTypeILike:
+		// MySQL has no ILIKE; lower both sides so the match does not depend
+		// on the column collation
+		clause = fmt.Sprintf("LOWER(%s) LIKE LOWER(?)", column)
+		args = append(args, cond.Value)
+
+	case types.ConditionTypeStartsWith, types.ConditionTypeEndsWith, types.ConditionTypeContains:
+		pattern, err := types.LikePattern(cond)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid %s value: %w", cond.Type, err)
+		}
+		// Backslashes are escapes in MySQL string literals, so the
+		// single character escape is written twice
+		clause = fmt.Sprintf(`%s LIKE ? ESCAPE '\\'`, column)
+		args = append(args, pattern)
+
+	case types.ConditionTypeRegex:
+		clause = fmt.Sprintf("%s REGEXP ?", column)
+		args = append(args, cond.Value)
+
+	case types.ConditionTypeBetween:
+		low, high, err := types.BetweenValues(cond.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid between value: %w", err)
+		}
+		clause = fmt.Sprintf("%s BETWEEN ? AND ?", column)
+		args = append(args, low, high)
+
+	case types.ConditionTypeIn, types.ConditionTypeNotIn:
+		values, err := types.ListValues(cond.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid %s value: %w", cond.Type, err)
+		}
+
+		// MySQL rejects an empty list, so it is rendered as a constant that
+		// matches nothing for in and everything for not_in
+		if len(values) == 0 {
+			if cond.Type == types.ConditionTypeIn {
+				clause = "1 = 0"
+			} else {
+				clause = "1 = 1"
+			}
+			break
+		}
+
+		// Expand the list into one placeholder per value
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		if cond.Type == types.ConditionTypeIn {
+			clause = fmt.Sprintf("%s IN (%s)", column, placeholders)
+		} else {
+			clause = fmt.Sprintf("%s NOT IN (%s)", column, placeholders)
+		}
+		args = append(args, values...)
+
 	case types.ConditionTypeIsNull:
-		clause = fmt.Sprintf("%s IS NULL", cond.Column)
+		clause = fmt.Sprintf("%s IS NULL", column)
 
 	case types.ConditionTypeIsNotNull:
-		clause = fmt.Sprintf("%s IS NOT NULL", cond.Column)
+		clause = fmt.Sprintf("%s IS NOT NULL", column)
 
 	default:
 		return "", nil, fmt.Errorf("unsupported condition type: %s", cond.Type)
@@ -141,6 +415,42 @@ func (e *mysqlEngine) buildCondition(cond types.Condition) (string, []interface{
 	return clause, args, nil
 }
 
+// buildConditionGroup renders an all/any/not group in parentheses
+func (e *mysqlEngine) buildConditionGroup(cond types.Condition) (string, []interface{}, error) {
+	if cond.Not != nil {
+		clause, args, err := e.buildCondition(*cond.Not)
+		if err != nil {
+			return "", nil, err
+		}
+		// Groups are already parenthesized
+		if cond.Not.IsGroup() {
+			return "NOT " + clause, args, nil
+		}
+		return fmt.Sprintf("NOT (%s)", clause), args, nil
+	}
+
+	children, join := cond.All, types.JoinWithAnd
+	if cond.Any != nil {
+		children, join = cond.Any, types.JoinWithOr
+	}
+	if len(children) == 0 {
+		return "", nil, fmt.Errorf("empty condition group")
+	}
+
+	var clauses []string
+	var args []interface{}
+	for _, child := range children {
+		clause, childArgs, err := e.buildCondition(child)
+		if err != nil {
+			return "", nil, err
+		}
+		clauses = append(clauses, clause)
+		args = append(args, childArgs...)
+	}
+
+	return fmt.Sprintf("(%s)", join(clauses)), args, nil
+}
+
 func (e *mysqlEngine) Ping(ctx context.Context) error {
 	// Use SELECT 1 to check if database is reachable
 	var result int
@@ -156,24 +466,314 @@ func (e *mysqlEngine) Ping(ctx context.Context) error {
 	return nil
 }
 
+func (e *mysqlEngine) Info(ctx context.Context) (*types.EngineInfo, error) {
+	var version string
+	if err := e.db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to get mysql version: %w", err)
+	}
+
+	return &types.EngineInfo{Type: "mysql", Version: version}, nil
+}
+
 func (e *mysqlEngine) ExecuteQuery(ctx context.Context, params *types.QueryParams) (*types.QueryResponse, error) {
+	collector := &types.RowCollector{}
+	summary, err := e.StreamQuery(ctx, params, collector)
+	if err != nil {
+		return nil, err
+	}
+
+	result := collector.Response()
+	result.NextCursor = summary.NextCursor
+	return result, nil
+}
+
+func (e *mysqlEngine) StreamQuery(ctx context.Context, params *types.QueryParams, w types.RowWriter) (*types.StreamSummary, error) {
 	query, args, err := e.buildQuery(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build query: %w", err)
+		return nil, types.ValidationError(fmt.Errorf("failed to build query: %w", err))
 	}
 
-	rows, err := e.db.QueryContext(ctx, query, args...)
+	loc, err := e.timestampZone(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, err
+	}
+
+	conn, release, err := e.queryConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", timeoutError(ctx, err))
 	}
 	defer rows.Close()
 
-	columns, err := rows.Columns()
+	// The tracker keeps the values as scanned, which are the ones the
+	// cursor passes back as query arguments
+	tracker := params.NewPageTracker(types.NormalizeRows(w))
+	count, err := streamRows(rows, tracker, loc)
+	if err != nil {
+		return nil, timeoutError(ctx, err)
+	}
+
+	cursor, err := tracker.NextCursor()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cursor: %w", err)
+	}
+
+	return &types.StreamSummary{RowCount: count, NextCursor: cursor}, nil
+}
+
+func (e *mysqlEngine) ExecuteRawQuery(ctx context.Context, params *types.RawQueryParams) (*types.QueryResponse, error) {
+	collector := &types.RowCollector{}
+	if _, err := e.StreamRawQuery(ctx, params, collector); err != nil {
+		return nil, err
+	}
+	return collector.Response(), nil
+}
+
+func (e *mysqlEngine) StreamRawQuery(ctx context.Context, params *types.RawQueryParams, w types.RowWriter) (*types.StreamSummary, error) {
+	stmt, err := sqlguard.ValidateReadOnly(params.SQL, sqlguard.DialectMySQL)
+	if err != nil {
+		return nil, types.ValidationError(fmt.Errorf("rejected query: %w", err))
+	}
+	if stmt.ParamCount != len(params.Params) {
+		return nil, types.ValidationError(fmt.Errorf("query expects %d parameters, got %d", stmt.ParamCount, len(params.Params)))
+	}
+
+	loc, err := e.timestampZone(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, release, err := e.queryConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	// Nothing is ever committed; the transaction only exists to make the
+	// database enforce read-only access
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, params.SQL, params.Params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", timeoutError(ctx, err))
+	}
+	defer rows.Close()
+
+	count, err := streamRows(rows, types.NormalizeRows(w), loc)
+	if err != nil {
+		return nil, timeoutError(ctx, err)
+	}
+
+	return &types.StreamSummary{RowCount: count}, nil
+}
+
+// killTimeout bounds the KILL QUERY issued for a cancelled query
+const killTimeout = 5 * time.Second
+
+// queryConn returns a connection to run a query on. The driver only drops
+// its connection when ctx is cancelled, leaving the statement running on
+// the server, so the query is stopped with KILL QUERY from another
+// connection. The deadline of ctx is also set as the session
+// max_execution_time. release must be called once the query is done.
+func (e *mysqlEngine) queryConn(ctx context.Context) (*sql.Conn, func(), error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	// A context that is never cancelled needs no watcher
+	if ctx.Done() == nil {
+		return conn, func() { conn.Close() }, nil
+	}
+
+	var id uint64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to get connection id: %w", err)
+	}
+
+	ms, timeout := types.StatementTimeout(ctx)
+	if timeout {
+		// SET does not take parameters
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", ms)); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("failed to set statement timeout: %w", timeoutError(ctx, err))
+		}
+	}
+
+	killed := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(killed)
+		e.killQuery(id)
+	})
+
+	return conn, func() {
+		// Wait for a pending kill so it cannot hit the next query run on
+		// the connection
+		if !stop() {
+			<-killed
+		}
+		if timeout {
+			resetExecutionTime(conn)
+		}
+		conn.Close()
+	}, nil
+}
+
+// resetExecutionTime restores the server default max_execution_time before
+// the connection goes back to the pool, discarding the connection when that
+// fails
+func resetExecutionTime(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, "SET SESSION max_execution_time = DEFAULT"); err != nil {
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+}
+
+// timeoutError marks err as a timeout when the statement was stopped by
+// max_execution_time or the deadline of ctx
+func timeoutError(ctx context.Context, err error) error {
+	var mysqlErr *mysqldriver.MySQLError
+	// 3024: maximum statement execution time exceeded
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &mysqlErr) && mysqlErr.Number == 3024) {
+		return fmt.Errorf("%w: %w", types.ErrTimeout, err)
+	}
+	return err
+}
+
+// ClassifyError returns the error code of err, keeping the number and
+// message of the MySQL error it comes from. It returns nil for errors it
+// does not know.
+func ClassifyError(err error) *types.QueryError {
+	var mysqlErr *mysqldriver.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		if errors.Is(err, mysqldriver.ErrInvalidConn) {
+			return &types.QueryError{Code: types.ErrorConnection, Err: err}
+		}
+		return types.ClassifyCommonError(err)
+	}
+
+	code := errorNumberCode(mysqlErr.Number)
+	if errors.Is(err, types.ErrTimeout) {
+		code = types.ErrorTimeout
+	}
+	return &types.QueryError{
+		Code:          code,
+		NativeCode:    strconv.Itoa(int(mysqlErr.Number)),
+		NativeMessage: mysqlErr.Message,
+		Err:           err,
+	}
+}
+
+// errorNumberCode maps a MySQL error number to an error code
+func errorNumberCode(number uint16) types.ErrorCode {
+	switch number {
+	case 1054: // ER_BAD_FIELD_ERROR
+		return types.ErrorUnknownColumn
+	case 1146, 1049: // ER_NO_SUCH_TABLE, ER_BAD_DB_ERROR
+		return types.ErrorUnknownTable
+	case 1044, 1045, 1142, 1143, 1227: // access denied to a database, user, table, column or operation
+		return types.ErrorPermissionDenied
+	case 1064, 1149: // ER_PARSE_ERROR, ER_SYNTAX_ERROR
+		return types.ErrorSyntax
+	case 3024: // ER_QUERY_TIMEOUT
+		return types.ErrorTimeout
+	case 1317: // ER_QUERY_INTERRUPTED
+		return types.ErrorCancelled
+	case 1040, 1053, 1152, 1153: // too many connections, server shutdown, aborted connection, packet too large
+		return types.ErrorConnection
+	case 1292, 1366, 1367, 1792: // incorrect values, write in a read-only transaction
+		return types.ErrorValidation
+	}
+	return types.ErrorInternal
+}
+
+// killQuery stops the statement running on the connection with the given
+// id. It is best effort: the statement may already be done.
+func (e *mysqlEngine) killQuery(id uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+
+	e.db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", id))
+}
+
+// resultColumns describes the columns of rows, labelling TIMESTAMP text
+// with loc. The driver reports TINYINT(1) as TINYINT, so booleans have the
+// int logical type.
+func resultColumns(rows *sql.Rows, loc *time.Location) ([]types.ResultColumn, error) {
+	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
-	results := []types.QueryResult{}
+	columns := make([]types.ResultColumn, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = types.ResultColumn{
+			Name:        ct.Name(),
+			NativeType:  ct.DatabaseTypeName(),
+			LogicalType: logicalType(ct.DatabaseTypeName()),
+		}
+		columns[i].Kind, columns[i].ElemKind = valueKind(ct.DatabaseTypeName())
+		if strings.EqualFold(ct.DatabaseTypeName(), "TIMESTAMP") {
+			columns[i].Location = loc
+		}
+		if nullable, ok := ct.Nullable(); ok {
+			columns[i].Nullable = &nullable
+		}
+	}
+	return columns, nil
+}
+
+// valueKind returns how the values of a type reported by the driver are
+// normalized. MySQL has no arrays, so the element kind is always text.
+func valueKind(typeName string) (types.ValueKind, types.ValueKind) {
+	name := strings.TrimPrefix(strings.ToUpper(typeName), "UNSIGNED ")
+
+	switch name {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		return types.ValueInt, types.ValueText
+	case "FLOAT", "DOUBLE":
+		return types.ValueFloat, types.ValueText
+	case "DECIMAL":
+		return types.ValueDecimal, types.ValueText
+	case "DATETIME", "TIMESTAMP":
+		return types.ValueTimestamp, types.ValueText
+	case "DATE":
+		return types.ValueDate, types.ValueText
+	case "JSON":
+		return types.ValueJSON, types.ValueText
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "GEOMETRY":
+		return types.ValueBinary, types.ValueText
+	default:
+		return types.ValueText, types.ValueText
+	}
+}
+
+// streamRows scans every row of rows into w and returns the row count.
+// TIMESTAMP text is in the zone loc.
+func streamRows(rows *sql.Rows, w types.RowWriter, loc *time.Location) (int, error) {
+	columns, err := resultColumns(rows, loc)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := w.WriteHeader(columns); err != nil {
+		return 0, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	count := 0
 	for rows.Next() {
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
@@ -182,30 +782,28 @@ func (e *mysqlEngine) ExecuteQuery(ctx context.Context, params *types.QueryParam
 		}
 
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return count, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		row := make(types.QueryResult)
-		for i, col := range columns {
-			val := values[i]
-			// Convert byte arrays to strings for better JSON serialization
+		for i, val := range values {
+			// Byte arrays are kept as strings, which cursors can encode;
+			// types.NormalizeRows converts them by column type
 			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
+				values[i] = string(b)
 			}
 		}
-		results = append(results, row)
+
+		if err := w.WriteRow(values); err != nil {
+			return count, fmt.Errorf("failed to write row: %w", err)
+		}
+		count++
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		return count, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return &types.QueryResponse{
-		Rows:     results,
-		RowCount: len(results),
-	}, nil
+	return count, nil
 }
 
 func (e *mysqlEngine) Close() error {
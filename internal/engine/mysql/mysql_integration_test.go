@@ -4,10 +4,14 @@ package mysql
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"starless/kadath/internal/types"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
 )
 
 func TestMySQLExecuteQuery(t *testing.T) {
@@ -36,7 +40,7 @@ func TestMySQLExecuteQuery(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "name", "email"}).
 					AddRow(1, "Alice", "alice@example.com").
 					AddRow(2, "Bob", "bob@example.com")
-				m.ExpectQuery("SELECT \\* FROM users").WillReturnRows(rows)
+				m.ExpectQuery("SELECT \\* FROM `users`").WillReturnRows(rows)
 			},
 			expectError: false,
 			validateResult: func(t *testing.T, result *types.QueryResponse) {
@@ -62,7 +66,7 @@ func TestMySQLExecuteQuery(t *testing.T) {
 			setupMock: func(m sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "name"}).
 					AddRow(1, "Alice")
-				m.ExpectQuery("SELECT \\* FROM users WHERE status = \\?").
+				m.ExpectQuery("SELECT \\* FROM `users` WHERE `status` = \\?").
 					WithArgs("active").
 					WillReturnRows(rows)
 			},
@@ -82,7 +86,7 @@ func TestMySQLExecuteQuery(t *testing.T) {
 			setupMock: func(m sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "name"}).
 					AddRow(1, "Alice")
-				m.ExpectQuery("SELECT \\* FROM users LIMIT \\?").
+				m.ExpectQuery("SELECT \\* FROM `users` LIMIT \\?").
 					WithArgs(10).
 					WillReturnRows(rows)
 			},
@@ -106,7 +110,7 @@ func TestMySQLExecuteQuery(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "total"}).
 					AddRow(1, 150.50).
 					AddRow(2, 200.00)
-				m.ExpectQuery("SELECT \\* FROM orders WHERE status = \\? AND total > \\?").
+				m.ExpectQuery("SELECT \\* FROM `orders` WHERE `status` = \\? AND `total` > \\?").
 					WithArgs("completed", 100).
 					WillReturnRows(rows)
 			},
@@ -117,6 +121,29 @@ func TestMySQLExecuteQuery(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "query with in and not in conditions",
+			params: &types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "status", Type: types.ConditionTypeIn, Value: []interface{}{"new", "paid"}},
+					{Column: "id", Type: types.ConditionTypeNotIn, Value: []interface{}{float64(3), float64(4)}},
+				},
+			},
+			setupMock: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "status"}).
+					AddRow(1, "new")
+				m.ExpectQuery("SELECT \\* FROM `orders` WHERE `status` IN \\(\\?, \\?\\) AND `id` NOT IN \\(\\?, \\?\\)").
+					WithArgs("new", "paid", float64(3), float64(4)).
+					WillReturnRows(rows)
+			},
+			expectError: false,
+			validateResult: func(t *testing.T, result *types.QueryResponse) {
+				if result.RowCount != 1 {
+					t.Errorf("expected 1 row, got %d", result.RowCount)
+				}
+			},
+		},
 		{
 			name: "empty result set",
 			params: &types.QueryParams{
@@ -124,7 +151,7 @@ func TestMySQLExecuteQuery(t *testing.T) {
 			},
 			setupMock: func(m sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "name"})
-				m.ExpectQuery("SELECT \\* FROM users").WillReturnRows(rows)
+				m.ExpectQuery("SELECT \\* FROM `users`").WillReturnRows(rows)
 			},
 			expectError: false,
 			validateResult: func(t *testing.T, result *types.QueryResponse) {
@@ -140,18 +167,18 @@ func TestMySQLExecuteQuery(t *testing.T) {
 			name: "query with group by and having",
 			params: &types.QueryParams{
 				Table:   "orders",
-				Select:  stringPtr("status, COUNT(*) as cnt"),
+				Select:  stringPtr("status"),
 				GroupBy: []string{"status"},
 				Having: []types.Condition{
-					{Column: "COUNT(*)", Type: types.ConditionTypeGreaterThan, Value: 5},
+					{Column: "status", Type: types.ConditionTypeNotEqual, Value: "cancelled"},
 				},
 			},
 			setupMock: func(m sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"status", "cnt"}).
-					AddRow("completed", 10).
-					AddRow("pending", 7)
-				m.ExpectQuery("SELECT status, COUNT\\(\\*\\) as cnt FROM orders GROUP BY status HAVING COUNT\\(\\*\\) > \\?").
-					WithArgs(5).
+				rows := sqlmock.NewRows([]string{"status"}).
+					AddRow("completed").
+					AddRow("pending")
+				m.ExpectQuery("SELECT `status` FROM `orders` GROUP BY `status` HAVING `status` != \\?").
+					WithArgs("cancelled").
 					WillReturnRows(rows)
 			},
 			expectError: false,
@@ -231,6 +258,31 @@ func TestMySQLPing(t *testing.T) {
 	})
 }
 
+func TestMySQLInfo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+
+	mock.ExpectQuery("SELECT VERSION\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.36"))
+
+	info, err := eng.Info(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Type != "mysql" || info.Version != "8.0.36" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
 func TestMySQLClose(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -257,3 +309,375 @@ func TestMySQLClose(t *testing.T) {
 		t.Errorf("unexpected error on close nil db: %v", err)
 	}
 }
+
+func TestMySQLExecuteRawQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+	ctx := context.Background()
+
+	t.Run("read query runs in a rolled back transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, name FROM users WHERE status = \\?").
+			WithArgs("active").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+				AddRow(1, "Alice").
+				AddRow(2, "Bob"))
+		mock.ExpectRollback()
+
+		result, err := eng.ExecuteRawQuery(ctx, &types.RawQueryParams{
+			SQL:    "SELECT id, name FROM users WHERE status = ?",
+			Params: []interface{}{"active"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.RowCount != 2 {
+			t.Errorf("expected 2 rows, got %d", result.RowCount)
+		}
+		if result.Rows[1]["name"] != "Bob" {
+			t.Errorf("expected Bob, got %v", result.Rows[1]["name"])
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unfulfilled expectations: %v", err)
+		}
+	})
+
+	rejected := []struct {
+		name   string
+		params *types.RawQueryParams
+	}{
+		{name: "write statement", params: &types.RawQueryParams{SQL: "DELETE FROM users"}},
+		{name: "multiple statements", params: &types.RawQueryParams{SQL: "SELECT 1; DROP TABLE users"}},
+		{name: "locking clause", params: &types.RawQueryParams{SQL: "SELECT * FROM users FOR UPDATE"}},
+		{name: "parameter count mismatch", params: &types.RawQueryParams{SQL: "SELECT id, name FROM users WHERE status = ?"}},
+	}
+
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := eng.ExecuteRawQuery(ctx, tt.params); err == nil {
+				t.Error("expected error, got nil")
+			}
+
+			// Rejected statements must never reach the database
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unexpected database activity: %v", err)
+			}
+		})
+	}
+}
+
+func TestMySQLExecuteQueryPagination(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+	ctx := context.Background()
+
+	params := &types.QueryParams{
+		Table:   "users",
+		OrderBy: []types.OrderTerm{{Column: "id"}},
+		Limit:   intPtr(2),
+	}
+
+	mock.ExpectQuery("SELECT \\* FROM `users` ORDER BY `id` ASC LIMIT \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Alice").
+			AddRow(2, "Bob"))
+
+	first, err := eng.ExecuteQuery(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("expected a cursor for a full page")
+	}
+
+	params.Cursor = first.NextCursor
+	mock.ExpectQuery("SELECT \\* FROM `users` WHERE `id` >= \\? ORDER BY `id` ASC LIMIT \\? OFFSET \\?").
+		WithArgs(int64(2), 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(3, "Carol"))
+
+	second, err := eng.ExecuteQuery(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.RowCount != 1 || second.NextCursor != "" {
+		t.Errorf("expected a final page of 1 row, got %d rows and cursor %q", second.RowCount, second.NextCursor)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMySQLStreamQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT \\* FROM `users` ORDER BY `id` ASC LIMIT \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Alice").
+			AddRow(2, "Bob"))
+
+	collector := &types.RowCollector{}
+	summary, err := eng.StreamQuery(ctx, &types.QueryParams{
+		Table:   "users",
+		OrderBy: []types.OrderTerm{{Column: "id"}},
+		Limit:   intPtr(2),
+	}, collector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(collector.Columns) != 2 || len(collector.Rows) != 2 {
+		t.Errorf("unexpected rows written: %v %v", collector.Columns, collector.Rows)
+	}
+	if summary.RowCount != 2 || summary.NextCursor == "" {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	collector = &types.RowCollector{}
+	summary, err = eng.StreamRawQuery(ctx, &types.RawQueryParams{SQL: "SELECT id FROM users"}, collector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.RowCount != 1 || len(collector.Rows) != 1 {
+		t.Errorf("unexpected raw result: %+v %v", summary, collector.Rows)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMySQLStreamQueryCancelled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	mock.ExpectQuery("SELECT CONNECTION_ID\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID()"}).AddRow(42))
+	mock.ExpectQuery("SELECT \\* FROM `users`").
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("KILL QUERY 42").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if _, err := eng.StreamQuery(ctx, &types.QueryParams{Table: "users"}, &types.RowCollector{}); err == nil {
+		t.Fatal("expected error for cancelled query")
+	}
+
+	// The kill has completed by the time the query returns
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMySQLStreamQueryTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	mock.ExpectQuery("SELECT CONNECTION_ID\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID()"}).AddRow(42))
+	mock.ExpectExec("SET SESSION max_execution_time = [0-9]+").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT SLEEP\\(120\\)").
+		WillReturnError(&mysqldriver.MySQLError{Number: 3024, Message: "Query execution was interrupted, maximum statement execution time exceeded"})
+	mock.ExpectRollback()
+	mock.ExpectExec("SET SESSION max_execution_time = DEFAULT").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = eng.StreamRawQuery(ctx, &types.RawQueryParams{SQL: "SELECT SLEEP(120)"}, &types.RowCollector{})
+	if !errors.Is(err, types.ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMySQLStreamQueryResultColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+
+	mock.ExpectQuery("SELECT \\* FROM `users`").
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("UNSIGNED BIGINT", uint64(0)).Nullable(false),
+			sqlmock.NewColumn("price").OfType("DECIMAL", "").Nullable(true),
+		).AddRow(1, "9.99"))
+
+	result, err := eng.ExecuteQuery(context.Background(), &types.QueryParams{Table: "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Columns) != 2 {
+		t.Fatalf("unexpected columns: %+v", result.Columns)
+	}
+	id, price := result.Columns[0], result.Columns[1]
+	if id.LogicalType != types.LogicalTypeInt || id.Nullable == nil || *id.Nullable {
+		t.Errorf("unexpected id column: %+v", id)
+	}
+	if price.NativeType != "DECIMAL" || price.LogicalType != types.LogicalTypeDecimal || price.Nullable == nil || !*price.Nullable {
+		t.Errorf("unexpected price column: %+v", price)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMySQLExecuteRawQueryDuplicateColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT a.id, b.id FROM a JOIN b USING \\(code\\)").
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("INT", int64(0)),
+			sqlmock.NewColumn("id").OfType("INT", int64(0)),
+		).AddRow(1, 2))
+	mock.ExpectRollback()
+
+	result, err := eng.ExecuteRawQuery(context.Background(), &types.RawQueryParams{SQL: "SELECT a.id, b.id FROM a JOIN b USING (code)"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	columnar := result.Columnar()
+	if len(columnar.Columns) != 2 || len(columnar.Rows) != 1 {
+		t.Fatalf("unexpected result: %+v", columnar)
+	}
+	if columnar.Rows[0][0] != int64(1) || columnar.Rows[0][1] != int64(2) {
+		t.Errorf("expected each id column to keep its value, got %v", columnar.Rows[0])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMySQLExecuteQueryNormalizesValues(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db}
+
+	// Values as returned by the text protocol
+	mock.ExpectQuery("SELECT \\* FROM `orders`").
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("INT", []byte{}),
+			sqlmock.NewColumn("created_at").OfType("DATETIME", []byte{}),
+			sqlmock.NewColumn("price").OfType("DECIMAL", []byte{}),
+			sqlmock.NewColumn("ratio").OfType("DOUBLE", []byte{}),
+		).AddRow([]byte("7"), []byte("2024-05-01 12:30:00"), []byte("9.90"), []byte("0.25")))
+
+	result, err := eng.ExecuteQuery(context.Background(), &types.QueryParams{Table: "orders"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(result.Rows[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"created_at":"2024-05-01T12:30:00Z","id":7,"price":"9.90","ratio":0.25}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMySQLExecuteQuerySessionTimeZone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &mysqlEngine{db: db, sessionTimeZone: true}
+
+	// The zone is only read once
+	mock.ExpectQuery("SELECT @@session.time_zone").
+		WillReturnRows(sqlmock.NewRows([]string{"zone", "offset"}).AddRow("+02:00", 7200))
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("SELECT \\* FROM `events`").
+			WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+				sqlmock.NewColumn("created_at").OfType("TIMESTAMP", []byte{}),
+				sqlmock.NewColumn("starts_at").OfType("DATETIME", []byte{}),
+			).AddRow([]byte("2024-05-01 12:30:00"), []byte("2024-05-01 12:30:00")))
+	}
+
+	for i := 0; i < 2; i++ {
+		result, err := eng.ExecuteQuery(context.Background(), &types.QueryParams{Table: "events"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// DATETIME values have no zone of their own
+		data, err := json.Marshal(result.Rows[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := `{"created_at":"2024-05-01T12:30:00+02:00","starts_at":"2024-05-01T12:30:00Z"}`
+		if string(data) != want {
+			t.Errorf("got %s, want %s", data, want)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
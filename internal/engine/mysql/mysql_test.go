@@ -3,9 +3,15 @@
 package mysql
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"starless/kadath/internal/types"
+	"strings"
 	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
 
 	"starless/kadath/configs"
 )
@@ -25,7 +31,7 @@ func TestMySQLBuildQuery(t *testing.T) {
 			params: &types.QueryParams{
 				Table: "users",
 			},
-			expectedQuery: "SELECT * FROM users",
+			expectedQuery: "SELECT * FROM `users`",
 			expectedArgs:  []interface{}{},
 			expectError:   false,
 		},
@@ -35,7 +41,7 @@ func TestMySQLBuildQuery(t *testing.T) {
 				Table:      "users",
 				SchemaName: stringPtr("mydb"),
 			},
-			expectedQuery: "SELECT * FROM mydb.users",
+			expectedQuery: "SELECT * FROM `mydb`.`users`",
 			expectedArgs:  []interface{}{},
 			expectError:   false,
 		},
@@ -45,7 +51,7 @@ func TestMySQLBuildQuery(t *testing.T) {
 				Table:  "users",
 				Select: stringPtr("id, name, email"),
 			},
-			expectedQuery: "SELECT id, name, email FROM users",
+			expectedQuery: "SELECT `id`, `name`, `email` FROM `users`",
 			expectedArgs:  []interface{}{},
 			expectError:   false,
 		},
@@ -57,7 +63,7 @@ func TestMySQLBuildQuery(t *testing.T) {
 					{Column: "status", Type: types.ConditionTypeEqual, Value: "active"},
 				},
 			},
-			expectedQuery: "SELECT * FROM users WHERE status = ?",
+			expectedQuery: "SELECT * FROM `users` WHERE `status` = ?",
 			expectedArgs:  []interface{}{"active"},
 			expectError:   false,
 		},
@@ -70,7 +76,7 @@ func TestMySQLBuildQuery(t *testing.T) {
 					{Column: "age", Type: types.ConditionTypeGreaterThan, Value: 18},
 				},
 			},
-			expectedQuery: "SELECT * FROM users WHERE status = ? AND age > ?",
+			expectedQuery: "SELECT * FROM `users` WHERE `status` = ? AND `age` > ?",
 			expectedArgs:  []interface{}{"active", 18},
 			expectError:   false,
 		},
@@ -80,7 +86,7 @@ func TestMySQLBuildQuery(t *testing.T) {
 				Table: "users",
 				Limit: intPtr(10),
 			},
-			expectedQuery: "SELECT * FROM users LIMIT ?",
+			expectedQuery: "SELECT * FROM `users` LIMIT ?",
 			expectedArgs:  []interface{}{10},
 			expectError:   false,
 		},
@@ -88,10 +94,10 @@ func TestMySQLBuildQuery(t *testing.T) {
 			name: "with group by",
 			params: &types.QueryParams{
 				Table:   "orders",
-				Select:  stringPtr("status, COUNT(*) as count"),
+				Select:  stringPtr("status"),
 				GroupBy: []string{"status"},
 			},
-			expectedQuery: "SELECT status, COUNT(*) as count FROM orders GROUP BY status",
+			expectedQuery: "SELECT `status` FROM `orders` GROUP BY `status`",
 			expectedArgs:  []interface{}{},
 			expectError:   false,
 		},
@@ -99,14 +105,14 @@ func TestMySQLBuildQuery(t *testing.T) {
 			name: "with having",
 			params: &types.QueryParams{
 				Table:   "orders",
-				Select:  stringPtr("status, COUNT(*) as count"),
+				Select:  stringPtr("status"),
 				GroupBy: []string{"status"},
 				Having: []types.Condition{
-					{Column: "COUNT(*)", Type: types.ConditionTypeGreaterThan, Value: 5},
+					{Column: "status", Type: types.ConditionTypeNotEqual, Value: "cancelled"},
 				},
 			},
-			expectedQuery: "SELECT status, COUNT(*) as count FROM orders GROUP BY status HAVING COUNT(*) > ?",
-			expectedArgs:  []interface{}{5},
+			expectedQuery: "SELECT `status` FROM `orders` GROUP BY `status` HAVING `status` != ?",
+			expectedArgs:  []interface{}{"cancelled"},
 			expectError:   false,
 		},
 		{
@@ -121,10 +127,214 @@ func TestMySQLBuildQuery(t *testing.T) {
 				},
 				Limit: intPtr(50),
 			},
-			expectedQuery: "SELECT id, name, created_at FROM mydb.users WHERE status = ? AND email LIKE ? LIMIT ?",
+			expectedQuery: "SELECT `id`, `name`, `created_at` FROM `mydb`.`users` WHERE `status` = ? AND `email` LIKE ? LIMIT ?",
 			expectedArgs:  []interface{}{"active", "%@example.com", 50},
 			expectError:   false,
 		},
+		{
+			name: "schema qualified table",
+			params: &types.QueryParams{
+				Table:  "sales.orders",
+				Select: stringPtr("orders.*, customers.name"),
+			},
+			expectedQuery: "SELECT `orders`.*, `customers`.`name` FROM `sales`.`orders`",
+			expectedArgs:  []interface{}{},
+			expectError:   false,
+		},
+		{
+			name: "injection attempts are quoted",
+			params: &types.QueryParams{
+				Table:  "users`; DROP TABLE users; --",
+				Select: stringPtr("id, (SELECT password FROM admins)"),
+				Conditions: []types.Condition{
+					{Column: "1=1 OR name", Type: types.ConditionTypeEqual, Value: "x"},
+				},
+			},
+			expectedQuery: "SELECT `id`, `(SELECT password FROM admins)` FROM `users``; DROP TABLE users; --` WHERE `1=1 OR name` = ?",
+			expectedArgs:  []interface{}{"x"},
+			expectError:   false,
+		},
+		{
+			name: "schema with qualified table",
+			params: &types.QueryParams{
+				Table:      "mydb.users",
+				SchemaName: stringPtr("mydb"),
+			},
+			expectError: true,
+		},
+		{
+			name: "trailing space in identifier",
+			params: &types.QueryParams{
+				Table:   "users",
+				GroupBy: []string{"status "},
+			},
+			expectError: true,
+		},
+		{
+			name: "character outside the BMP",
+			params: &types.QueryParams{
+				Table: "users_\U0001F600",
+			},
+			expectError: true,
+		},
+		{
+			name: "structured projections with aggregates",
+			params: &types.QueryParams{
+				Table: "orders",
+				Projections: []types.Projection{
+					{Column: "status"},
+					{Aggregate: types.AggregateCount},
+					{Column: "customer_id", Aggregate: types.AggregateCountDistinct, Alias: "customers"},
+					{Column: "total", Aggregate: types.AggregateSum},
+					{Column: "total", Aggregate: types.AggregateAvg, Alias: "average"},
+					{Column: "created_at", Aggregate: types.AggregateMin},
+					{Column: "created_at", Aggregate: types.AggregateMax},
+				},
+				GroupBy: []string{"status"},
+				Having: []types.Condition{
+					{Aggregate: types.AggregateCount, Type: types.ConditionTypeGreaterThan, Value: 5},
+					{Column: "total", Aggregate: types.AggregateSum, Type: types.ConditionTypeGreaterThanOrEqual, Value: 100},
+				},
+			},
+			expectedQuery: "SELECT `status`, COUNT(*) AS `count`, COUNT(DISTINCT `customer_id`) AS `customers`, SUM(`total`) AS `sum_total`, AVG(`total`) AS `average`, MIN(`created_at`) AS `min_created_at`, MAX(`created_at`) AS `max_created_at` FROM `orders` GROUP BY `status` HAVING COUNT(*) > ? AND SUM(`total`) >= ?",
+			expectedArgs:  []interface{}{5, 100},
+			expectError:   false,
+		},
+		{
+			name: "distinct projection with alias",
+			params: &types.QueryParams{
+				Table:    "users",
+				Distinct: true,
+				Projections: []types.Projection{
+					{Column: "users.country", Alias: "country code"},
+				},
+			},
+			expectedQuery: "SELECT DISTINCT `users`.`country` AS `country code` FROM `users`",
+			expectedArgs:  []interface{}{},
+			expectError:   false,
+		},
+		{
+			name: "nested or group",
+			params: &types.QueryParams{
+				Table: `orders`,
+				Conditions: []types.Condition{
+					{Column: `deleted_at`, Type: types.ConditionTypeIsNull},
+					{Any: []types.Condition{
+						{Column: `status`, Type: types.ConditionTypeEqual, Value: "a"},
+						{All: []types.Condition{
+							{Column: `status`, Type: types.ConditionTypeEqual, Value: "b"},
+							{Column: `total`, Type: types.ConditionTypeGreaterThan, Value: 10},
+						}},
+					}},
+				},
+				Limit: intPtr(5),
+			},
+			expectedQuery: "SELECT * FROM `orders` WHERE `deleted_at` IS NULL AND (`status` = ? OR (`status` = ? AND `total` > ?)) LIMIT ?",
+			expectedArgs:  []interface{}{"a", "b", 10, 5},
+			expectError:   false,
+		},
+		{
+			name: "negated group in having",
+			params: &types.QueryParams{
+				Table:   `orders`,
+				GroupBy: []string{`status`},
+				Having: []types.Condition{
+					{Not: &types.Condition{Any: []types.Condition{
+						{Aggregate: types.AggregateCount, Type: types.ConditionTypeLessThan, Value: 2},
+						{Column: `total`, Aggregate: types.AggregateSum, Type: types.ConditionTypeGreaterThan, Value: 100},
+					}}},
+				},
+			},
+			expectedQuery: "SELECT * FROM `orders` GROUP BY `status` HAVING NOT (COUNT(*) < ? OR SUM(`total`) > ?)",
+			expectedArgs:  []interface{}{2, 100},
+			expectError:   false,
+		},
+		{
+			name: "empty condition group",
+			params: &types.QueryParams{
+				Table:      `orders`,
+				Conditions: []types.Condition{{Any: []types.Condition{}}},
+			},
+			expectError: true,
+		},
+		{
+			name: "order by with nulls ordering",
+			params: &types.QueryParams{
+				Table: "users",
+				OrderBy: []types.OrderTerm{
+					{Column: "last_login", Direction: types.SortDesc, Nulls: types.NullsLast},
+					{Column: "name", Nulls: types.NullsFirst},
+					{Column: "id"},
+				},
+				Limit: intPtr(50),
+			},
+			expectedQuery: "SELECT * FROM `users` ORDER BY `last_login` DESC, `name` ASC, `id` ASC LIMIT ?",
+			expectedArgs:  []interface{}{50},
+			expectError:   false,
+		},
+		{
+			name: "order by aggregate alias with group by",
+			params: &types.QueryParams{
+				Table: "orders",
+				Projections: []types.Projection{
+					{Column: "status"},
+					{Column: "total", Aggregate: types.AggregateSum, Alias: "revenue"},
+				},
+				GroupBy: []string{"status"},
+				OrderBy: []types.OrderTerm{
+					{Column: "revenue", Direction: types.SortDesc},
+					{Column: "status"},
+				},
+			},
+			expectedQuery: "SELECT `status`, SUM(`total`) AS `revenue` FROM `orders` GROUP BY `status` ORDER BY SUM(`total`) DESC, `status` ASC",
+			expectedArgs:  []interface{}{},
+			expectError:   false,
+		},
+		{
+			name: "order by with emulated nulls ordering",
+			params: &types.QueryParams{
+				Table: "users",
+				OrderBy: []types.OrderTerm{
+					{Column: "last_login", Direction: types.SortDesc, Nulls: types.NullsFirst},
+					{Column: "name", Nulls: types.NullsLast},
+				},
+			},
+			expectedQuery: "SELECT * FROM `users` ORDER BY `last_login` IS NULL DESC, `last_login` DESC, `name` IS NULL ASC, `name` ASC",
+			expectedArgs:  []interface{}{},
+			expectError:   false,
+		},
+		{
+			name: "order by star",
+			params: &types.QueryParams{
+				Table:   "users",
+				OrderBy: []types.OrderTerm{{Column: "*"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "order by unknown direction",
+			params: &types.QueryParams{
+				Table:   "users",
+				OrderBy: []types.OrderTerm{{Column: "id", Direction: "sideways"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "aggregate without column",
+			params: &types.QueryParams{
+				Table:       "orders",
+				Projections: []types.Projection{{Aggregate: types.AggregateSum}},
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown aggregate",
+			params: &types.QueryParams{
+				Table:       "orders",
+				Projections: []types.Projection{{Column: "total", Aggregate: "median"}},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -154,6 +364,137 @@ func TestMySQLBuildQuery(t *testing.T) {
 	}
 }
 
+func TestMySQLBuildQueryRawSelect(t *testing.T) {
+	params := &types.QueryParams{
+		Table:   "orders",
+		Select:  stringPtr("status, COUNT(*) as count"),
+		GroupBy: []string{"status"},
+	}
+
+	// Without the opt-in the legacy string is a list of column names
+	query, _, err := (&mysqlEngine{}).buildQuery(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "SELECT `status`, `COUNT(*) as count` FROM `orders` GROUP BY `status`"
+	if query != expected {
+		t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+	}
+
+	query, _, err = (&mysqlEngine{allowRawSelect: true}).buildQuery(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "SELECT status, COUNT(*) as count FROM `orders` GROUP BY `status`"
+	if query != expected {
+		t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+	}
+}
+
+func TestMySQLBuildQueryRawSelectGuard(t *testing.T) {
+	eng := &mysqlEngine{allowRawSelect: true}
+
+	// Expressions containing commas are pasted verbatim
+	query, _, err := eng.buildQuery(&types.QueryParams{Table: "users", Select: stringPtr("COALESCE(nickname, name)")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "SELECT COALESCE(nickname, name) FROM `users`"
+	if query != expected {
+		t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+	}
+
+	// The select string must be a read-only expression list that cannot
+	// swallow or end the rest of the query
+	for _, sel := range []string{
+		"* INTO copy",
+		"1; DELETE FROM users",
+		"pg_read_file('x') --",
+		"id /* FROM users */",
+		"id) FROM users WHERE (true",
+		"COALESCE(nickname, name",
+		"'abc",
+		"id = ?",
+	} {
+		if _, _, err := eng.buildQuery(&types.QueryParams{Table: "users", Select: stringPtr(sel)}); err == nil {
+			t.Errorf("expected select %q to be rejected", sel)
+		}
+	}
+
+	// Without the opt-in the select string is a list of column names
+	_, _, err = (&mysqlEngine{}).buildQuery(&types.QueryParams{Table: "users", Select: stringPtr("id,,name")})
+	if err == nil || !strings.Contains(err.Error(), "empty item") {
+		t.Errorf("expected empty item error, got %v", err)
+	}
+}
+
+func TestMySQLBuildQueryPagination(t *testing.T) {
+	eng := &mysqlEngine{}
+
+	t.Run("offset", func(t *testing.T) {
+		params := &types.QueryParams{Table: "users", Limit: intPtr(10), Offset: intPtr(20)}
+		query, args, err := eng.buildQuery(params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := "SELECT * FROM `users` LIMIT ? OFFSET ?"; query != expected {
+			t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+		}
+		if !argsEqual(args, []interface{}{10, 20}) {
+			t.Errorf("unexpected args: %v", args)
+		}
+	})
+
+	t.Run("offset without limit", func(t *testing.T) {
+		query, _, err := eng.buildQuery(&types.QueryParams{Table: "users", Offset: intPtr(5)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := "SELECT * FROM `users` LIMIT 18446744073709551615 OFFSET ?"; query != expected {
+			t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+		}
+	})
+
+	t.Run("keyset cursor", func(t *testing.T) {
+		params := &types.QueryParams{
+			Table: "users",
+			Conditions: []types.Condition{
+				{Column: "status", Type: types.ConditionTypeEqual, Value: "active"},
+			},
+			OrderBy: []types.OrderTerm{{Column: "created_at", Direction: types.SortDesc}, {Column: "id"}},
+			Limit:   intPtr(2),
+		}
+		cursor, err := types.EncodeCursor(&types.Cursor{
+			Query:  params.Fingerprint(),
+			After:  []interface{}{"2024-01-01T00:00:00Z", 42},
+			Offset: 1,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		params.Cursor = cursor
+
+		query, args, err := eng.buildQuery(params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := "SELECT * FROM `users` WHERE `status` = ? AND ((`created_at` < ? OR `created_at` IS NULL) OR (`created_at` = ? AND `id` >= ?)) ORDER BY `created_at` DESC, `id` ASC LIMIT ? OFFSET ?"
+		if query != expected {
+			t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+		}
+		if !argsEqual(args, []interface{}{"active", "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", int64(42), 2, 1}) {
+			t.Errorf("unexpected args: %v", args)
+		}
+	})
+
+	t.Run("cursor of another query", func(t *testing.T) {
+		cursor, _ := types.EncodeCursor(&types.Cursor{Query: "0000000000000000", Offset: 5})
+		if _, _, err := eng.buildQuery(&types.QueryParams{Table: "users", Cursor: cursor}); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
 func TestMySQLBuildCondition(t *testing.T) {
 	eng := &mysqlEngine{}
 
@@ -167,70 +508,167 @@ func TestMySQLBuildCondition(t *testing.T) {
 		{
 			name:           "equal condition",
 			condition:      types.Condition{Column: "id", Type: types.ConditionTypeEqual, Value: 123},
-			expectedClause: "id = ?",
+			expectedClause: "`id` = ?",
 			expectedArgs:   []interface{}{123},
 			expectError:    false,
 		},
 		{
 			name:           "not equal condition",
 			condition:      types.Condition{Column: "status", Type: types.ConditionTypeNotEqual, Value: "deleted"},
-			expectedClause: "status != ?",
+			expectedClause: "`status` != ?",
 			expectedArgs:   []interface{}{"deleted"},
 			expectError:    false,
 		},
 		{
 			name:           "greater than condition",
 			condition:      types.Condition{Column: "age", Type: types.ConditionTypeGreaterThan, Value: 18},
-			expectedClause: "age > ?",
+			expectedClause: "`age` > ?",
 			expectedArgs:   []interface{}{18},
 			expectError:    false,
 		},
 		{
 			name:           "greater than or equal condition",
 			condition:      types.Condition{Column: "score", Type: types.ConditionTypeGreaterThanOrEqual, Value: 90},
-			expectedClause: "score >= ?",
+			expectedClause: "`score` >= ?",
 			expectedArgs:   []interface{}{90},
 			expectError:    false,
 		},
 		{
 			name:           "less than condition",
 			condition:      types.Condition{Column: "price", Type: types.ConditionTypeLessThan, Value: 99.99},
-			expectedClause: "price < ?",
+			expectedClause: "`price` < ?",
 			expectedArgs:   []interface{}{99.99},
 			expectError:    false,
 		},
 		{
 			name:           "less than or equal condition",
 			condition:      types.Condition{Column: "price", Type: types.ConditionTypeLessThanOrEqual, Value: 100.50},
-			expectedClause: "price <= ?",
+			expectedClause: "`price` <= ?",
 			expectedArgs:   []interface{}{100.50},
 			expectError:    false,
 		},
 		{
 			name:           "like condition",
 			condition:      types.Condition{Column: "name", Type: types.ConditionTypeLike, Value: "John%"},
-			expectedClause: "name LIKE ?",
+			expectedClause: "`name` LIKE ?",
 			expectedArgs:   []interface{}{"John%"},
 			expectError:    false,
 		},
 		{
 			name:           "in condition",
 			condition:      types.Condition{Column: "status", Type: types.ConditionTypeIn, Value: []string{"active", "pending"}},
-			expectedClause: "status IN (?)",
-			expectedArgs:   []interface{}{[]string{"active", "pending"}},
+			expectedClause: "`status` IN (?, ?)",
+			expectedArgs:   []interface{}{"active", "pending"},
+			expectError:    false,
+		},
+		{
+			name:           "in condition with mixed json values",
+			condition:      types.Condition{Column: "code", Type: types.ConditionTypeIn, Value: []interface{}{float64(1), "2", true}},
+			expectedClause: "`code` IN (?, ?, ?)",
+			expectedArgs:   []interface{}{float64(1), "2", true},
+			expectError:    false,
+		},
+		{
+			name:           "not in condition",
+			condition:      types.Condition{Column: "id", Type: types.ConditionTypeNotIn, Value: []interface{}{float64(1), float64(2)}},
+			expectedClause: "`id` NOT IN (?, ?)",
+			expectedArgs:   []interface{}{float64(1), float64(2)},
+			expectError:    false,
+		},
+		{
+			name:           "empty in condition",
+			condition:      types.Condition{Column: "id", Type: types.ConditionTypeIn, Value: []interface{}{}},
+			expectedClause: "1 = 0",
+			expectedArgs:   []interface{}{},
+			expectError:    false,
+		},
+		{
+			name:           "empty not in condition",
+			condition:      types.Condition{Column: "id", Type: types.ConditionTypeNotIn, Value: []interface{}{}},
+			expectedClause: "1 = 1",
+			expectedArgs:   []interface{}{},
+			expectError:    false,
+		},
+		{
+			name:        "in condition with scalar value",
+			condition:   types.Condition{Column: "id", Type: types.ConditionTypeIn, Value: "1,2"},
+			expectError: true,
+		},
+		{
+			name:        "in condition with nested array",
+			condition:   types.Condition{Column: "id", Type: types.ConditionTypeIn, Value: []interface{}{[]interface{}{1}}},
+			expectError: true,
+		},
+		{
+			name:           "not like condition",
+			condition:      types.Condition{Column: "email", Type: types.ConditionTypeNotLike, Value: "%@test.com"},
+			expectedClause: "`email` NOT LIKE ?",
+			expectedArgs:   []interface{}{"%@test.com"},
+			expectError:    false,
+		},
+		{
+			name:           "ilike condition",
+			condition:      types.Condition{Column: "name", Type: types.ConditionTypeILike, Value: "al%"},
+			expectedClause: "LOWER(`name`) LIKE LOWER(?)",
+			expectedArgs:   []interface{}{"al%"},
+			expectError:    false,
+		},
+		{
+			name:           "starts with condition escapes wildcards",
+			condition:      types.Condition{Column: "code", Type: types.ConditionTypeStartsWith, Value: "50%_off"},
+			expectedClause: "`code` LIKE ? ESCAPE '\\\\'",
+			expectedArgs:   []interface{}{`50\%\_off%`},
+			expectError:    false,
+		},
+		{
+			name:           "ends with condition",
+			condition:      types.Condition{Column: "email", Type: types.ConditionTypeEndsWith, Value: "@test.com"},
+			expectedClause: "`email` LIKE ? ESCAPE '\\\\'",
+			expectedArgs:   []interface{}{"%@test.com"},
+			expectError:    false,
+		},
+		{
+			name:           "contains condition",
+			condition:      types.Condition{Column: "path", Type: types.ConditionTypeContains, Value: `a\b`},
+			expectedClause: "`path` LIKE ? ESCAPE '\\\\'",
+			expectedArgs:   []interface{}{`%a\\b%`},
+			expectError:    false,
+		},
+		{
+			name:           "regex condition",
+			condition:      types.Condition{Column: "sku", Type: types.ConditionTypeRegex, Value: "^[A-Z]{3}-"},
+			expectedClause: "`sku` REGEXP ?",
+			expectedArgs:   []interface{}{"^[A-Z]{3}-"},
+			expectError:    false,
+		},
+		{
+			name:           "between condition",
+			condition:      types.Condition{Column: "total", Type: types.ConditionTypeBetween, Value: []interface{}{float64(10), float64(20)}},
+			expectedClause: "`total` BETWEEN ? AND ?",
+			expectedArgs:   []interface{}{float64(10), float64(20)},
 			expectError:    false,
 		},
+		{
+			name:        "between condition with one bound",
+			condition:   types.Condition{Column: "total", Type: types.ConditionTypeBetween, Value: []interface{}{float64(10)}},
+			expectError: true,
+		},
+		{
+			name:        "contains condition with number",
+			condition:   types.Condition{Column: "code", Type: types.ConditionTypeContains, Value: float64(5)},
+			expectError: true,
+		},
 		{
 			name:           "is null condition",
 			condition:      types.Condition{Column: "deleted_at", Type: types.ConditionTypeIsNull},
-			expectedClause: "deleted_at IS NULL",
+			expectedClause: "`deleted_at` IS NULL",
 			expectedArgs:   []interface{}{},
 			expectError:    false,
 		},
 		{
 			name:           "is not null condition",
 			condition:      types.Condition{Column: "email", Type: types.ConditionTypeIsNotNull},
-			expectedClause: "email IS NOT NULL",
+			expectedClause: "`email` IS NOT NULL",
 			expectedArgs:   []interface{}{},
 			expectError:    false,
 		},
@@ -280,6 +718,63 @@ func TestMySQLNewEngine(t *testing.T) {
 	_ = err
 }
 
+func TestMySQLBuildDSN(t *testing.T) {
+	dsn, sessionTimeZone, err := buildDSN("user:pass@tcp(db:3306)/app?wait_timeout=60")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// TIMESTAMP text is in the session time zone, which results label as UTC
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("invalid DSN %q: %v", dsn, err)
+	}
+	if cfg.Params["time_zone"] != "'+00:00'" || cfg.Params["wait_timeout"] != "60" || sessionTimeZone {
+		t.Errorf("unexpected params: %v", cfg.Params)
+	}
+	if cfg.User != "user" || cfg.Addr != "db:3306" || cfg.DBName != "app" {
+		t.Errorf("unexpected DSN: %s", dsn)
+	}
+
+	// A session time zone of the DSN is kept
+	base := "user@tcp(db:3306)/app?time_zone=%27Europe%2FParis%27"
+	dsn, sessionTimeZone, err = buildDSN(base)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dsn != base || !sessionTimeZone {
+		t.Errorf("expected the DSN to be kept, got %s", dsn)
+	}
+
+	if _, _, err := buildDSN("invalid-dsn"); err == nil {
+		t.Error("expected error for an invalid DSN")
+	}
+}
+
+func TestMySQLSessionLocation(t *testing.T) {
+	at := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		zone   string
+		offset int
+		want   int
+	}{
+		{"+00:00", 0, 0},
+		{"+05:30", 0, 5*3600 + 30*60},
+		{"-08:00", 0, -8 * 3600},
+		{"UTC", 0, 0},
+		// Zones Go cannot load use the current offset
+		{"SYSTEM", 3600, 3600},
+	}
+
+	for _, tt := range tests {
+		_, offset := at.In(sessionLocation(tt.zone, tt.offset)).Zone()
+		if offset != tt.want {
+			t.Errorf("sessionLocation(%q, %d) has offset %d, want %d", tt.zone, tt.offset, offset, tt.want)
+		}
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -297,3 +792,40 @@ func argsEqual(a, b []interface{}) bool {
 	}
 	return reflect.DeepEqual(a, b)
 }
+
+func TestMySQLClassifyError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode types.ErrorCode
+		nativeCode   string
+	}{
+		{"unknown column", &mysqldriver.MySQLError{Number: 1054, Message: "Unknown column 'nme' in 'field list'"}, types.ErrorUnknownColumn, "1054"},
+		{"unknown table", &mysqldriver.MySQLError{Number: 1146}, types.ErrorUnknownTable, "1146"},
+		{"permission denied", &mysqldriver.MySQLError{Number: 1142}, types.ErrorPermissionDenied, "1142"},
+		{"syntax", &mysqldriver.MySQLError{Number: 1064}, types.ErrorSyntax, "1064"},
+		{"statement timeout", &mysqldriver.MySQLError{Number: 3024}, types.ErrorTimeout, "3024"},
+		{"interrupted", &mysqldriver.MySQLError{Number: 1317}, types.ErrorCancelled, "1317"},
+		{"deadline", fmt.Errorf("%w: %w", types.ErrTimeout, &mysqldriver.MySQLError{Number: 1317}), types.ErrorTimeout, "1317"},
+		{"read-only transaction", &mysqldriver.MySQLError{Number: 1792}, types.ErrorValidation, "1792"},
+		{"internal", &mysqldriver.MySQLError{Number: 1205}, types.ErrorInternal, "1205"},
+		{"invalid connection", fmt.Errorf("failed to execute query: %w", mysqldriver.ErrInvalidConn), types.ErrorConnection, ""},
+		{"not found", &types.QueryError{Code: types.ErrorUnknownTable, Err: errors.New("table t not found")}, types.ErrorUnknownTable, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryErr := ClassifyError(tt.err)
+			if queryErr == nil {
+				t.Fatal("expected the error to be classified")
+			}
+			if queryErr.Code != tt.expectedCode || queryErr.NativeCode != tt.nativeCode {
+				t.Errorf("expected %v %q, got %v %q", tt.expectedCode, tt.nativeCode, queryErr.Code, queryErr.NativeCode)
+			}
+		})
+	}
+
+	if queryErr := ClassifyError(errors.New("unexpected")); queryErr != nil {
+		t.Errorf("unknown errors should not be classified, got %v", queryErr.Code)
+	}
+}
@@ -0,0 +1,231 @@
+//go:build postgres
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"starless/kadath/internal/types"
+)
+
+// systemSchemaFilter excludes catalog, toast and temporary schemas
+const systemSchemaFilter = `n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg\_%'`
+
+const describeSchemasQuery = `SELECT n.nspname, obj_description(n.oid, 'pg_namespace')
+FROM pg_catalog.pg_namespace n
+WHERE ` + systemSchemaFilter + `
+ORDER BY n.nspname`
+
+const describeRelationsQuery = `SELECT n.nspname, c.relname, c.relkind, obj_description(c.oid, 'pg_class'),
+	a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+	pg_get_expr(d.adbin, d.adrelid), col_description(c.oid, a.attnum)
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = c.oid AND d.adnum = a.attnum
+WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND ` + systemSchemaFilter + `
+ORDER BY n.nspname, c.relname, a.attnum`
+
+// tableType maps a pg_class.relkind to a TableType
+func tableType(relkind string) types.TableType {
+	switch relkind {
+	case "v":
+		return types.TableTypeView
+	case "m":
+		return types.TableTypeMaterializedView
+	case "f":
+		return types.TableTypeForeignTable
+	default:
+		return types.TableTypeTable
+	}
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func (e *postgresEngine) DescribeSchema(ctx context.Context) (*types.SchemaResponse, error) {
+	builder := types.NewSchemaBuilder()
+
+	conn, release, err := e.queryConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	schemaRows, err := conn.QueryContext(ctx, describeSchemasQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", timeoutError(ctx, err))
+	}
+	defer schemaRows.Close()
+
+	for schemaRows.Next() {
+		var name string
+		var comment sql.NullString
+		if err := schemaRows.Scan(&name, &comment); err != nil {
+			return nil, fmt.Errorf("failed to scan schema: %w", err)
+		}
+		builder.AddSchema(name, nullStringPtr(comment))
+	}
+	if err := schemaRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schemas: %w", timeoutError(ctx, err))
+	}
+
+	rows, err := conn.QueryContext(ctx, describeRelationsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relations: %w", timeoutError(ctx, err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table, relkind string
+		var tableComment, column, dataType, columnDefault, columnComment sql.NullString
+		var nullable sql.NullBool
+		if err := rows.Scan(&schema, &table, &relkind, &tableComment,
+			&column, &dataType, &nullable, &columnDefault, &columnComment); err != nil {
+			return nil, fmt.Errorf("failed to scan relation: %w", err)
+		}
+
+		builder.AddTable(schema, types.TableDescription{
+			Name:    table,
+			Type:    tableType(relkind),
+			Comment: nullStringPtr(tableComment),
+		})
+
+		// Relations without columns come back as a single row of NULLs
+		if !column.Valid {
+			continue
+		}
+		builder.AddColumn(schema, table, types.ColumnDescription{
+			Name:     column.String,
+			DataType: dataType.String,
+			Nullable: nullable.Bool,
+			Default:  nullStringPtr(columnDefault),
+			Comment:  nullStringPtr(columnComment),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating relations: %w", timeoutError(ctx, err))
+	}
+
+	return builder.Response(), nil
+}
+
+// fetchColumnsQuery reads the primary key from pg_index rather than
+// information_schema.table_constraints, which only lists the constraints of
+// tables owned by the current role
+const fetchColumnsQuery = `SELECT c.table_schema, c.column_name, c.ordinal_position, c.data_type, c.udt_name,
+	c.is_nullable, c.column_default, c.character_maximum_length, c.numeric_precision, c.numeric_scale,
+	EXISTS (
+		SELECT 1
+		FROM pg_catalog.pg_index i
+		JOIN pg_catalog.pg_class t ON t.oid = i.indrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indisprimary
+			AND n.nspname = c.table_schema AND t.relname = c.table_name
+			AND a.attname = c.column_name
+	)
+FROM information_schema.columns c
+WHERE c.table_schema = COALESCE($1, current_schema()) AND c.table_name = $2
+ORDER BY c.ordinal_position`
+
+// logicalType maps a Postgres type name, as found in udt_name or reported by
+// the driver, to a LogicalType. Array types are reported as json since they
+// serialize to JSON arrays.
+func logicalType(typeName string) types.LogicalType {
+	name := strings.ToLower(typeName)
+	if strings.HasPrefix(name, "_") {
+		return types.LogicalTypeJSON
+	}
+
+	switch name {
+	case "int2", "int4", "int8", "smallint", "integer", "bigint", "oid",
+		"smallserial", "serial", "bigserial":
+		return types.LogicalTypeInt
+	case "numeric", "decimal", "float4", "float8", "real", "double precision", "money":
+		return types.LogicalTypeDecimal
+	case "date", "time", "timetz", "timestamp", "timestamptz", "interval":
+		return types.LogicalTypeTime
+	case "bool", "boolean":
+		return types.LogicalTypeBool
+	case "json", "jsonb":
+		return types.LogicalTypeJSON
+	case "bytea":
+		return types.LogicalTypeBinary
+	default:
+		return types.LogicalTypeString
+	}
+}
+
+func nullInt64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func (e *postgresEngine) FetchColumns(ctx context.Context, params *types.FetchColumnsParams) (*types.ColumnsResponse, error) {
+	var schemaName interface{}
+	if params.SchemaName != nil {
+		schemaName = *params.SchemaName
+	}
+
+	conn, release, err := e.queryConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, fetchColumnsQuery, schemaName, params.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch columns: %w", timeoutError(ctx, err))
+	}
+	defer rows.Close()
+
+	resp := &types.ColumnsResponse{
+		Table:   params.Table,
+		Columns: []types.ColumnDetail{},
+	}
+
+	for rows.Next() {
+		var col types.ColumnDetail
+		var dataType, udtName, isNullable string
+		var columnDefault sql.NullString
+		var charLength, precision, scale sql.NullInt64
+		if err := rows.Scan(&resp.SchemaName, &col.Name, &col.Position, &dataType, &udtName,
+			&isNullable, &columnDefault, &charLength, &precision, &scale, &col.PrimaryKey); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+
+		// data_type is generic for arrays and user defined types, so fall
+		// back to the underlying type name for those
+		col.NativeType = dataType
+		if dataType == "ARRAY" || dataType == "USER-DEFINED" {
+			col.NativeType = udtName
+		}
+		col.LogicalType = logicalType(udtName)
+		col.Nullable = isNullable == "YES"
+		col.Default = nullStringPtr(columnDefault)
+		col.CharacterMaxLength = nullInt64Ptr(charLength)
+		col.NumericPrecision = nullInt64Ptr(precision)
+		col.NumericScale = nullInt64Ptr(scale)
+
+		resp.Columns = append(resp.Columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns: %w", timeoutError(ctx, err))
+	}
+
+	if len(resp.Columns) == 0 {
+		return nil, &types.QueryError{Code: types.ErrorUnknownTable, Err: fmt.Errorf("table %s not found", params.Table)}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,257 @@
+//go:build postgres
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"starless/kadath/internal/types"
+)
+
+func TestPostgresDescribeSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT n.nspname, obj_description\\(n.oid, 'pg_namespace'\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"nspname", "comment"}).
+			AddRow("empty", nil).
+			AddRow("public", "standard public schema"))
+
+	mock.ExpectQuery("FROM pg_catalog.pg_class c").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"nspname", "relname", "relkind", "table_comment",
+			"attname", "data_type", "nullable", "default", "column_comment",
+		}).
+			AddRow("public", "active_users", "v", nil, "id", "integer", true, nil, nil).
+			AddRow("public", "users", "r", "Registered users", "id", "integer", false, "nextval('users_id_seq'::regclass)", nil).
+			AddRow("public", "users", "r", "Registered users", "email", "character varying(255)", true, nil, "Login email").
+			AddRow("public", "no_columns", "r", nil, nil, nil, nil, nil, nil))
+	mock.ExpectRollback()
+
+	result, err := eng.DescribeSchema(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+
+	if len(result.Schemas) != 2 {
+		t.Fatalf("expected 2 schemas, got %d", len(result.Schemas))
+	}
+
+	if result.Schemas[0].Name != "empty" || len(result.Schemas[0].Tables) != 0 {
+		t.Errorf("expected empty schema without tables, got %+v", result.Schemas[0])
+	}
+
+	public := result.Schemas[1]
+	if public.Comment == nil || *public.Comment != "standard public schema" {
+		t.Errorf("expected schema comment, got %v", public.Comment)
+	}
+	if len(public.Tables) != 3 {
+		t.Fatalf("expected 3 tables, got %d", len(public.Tables))
+	}
+
+	view := public.Tables[0]
+	if view.Type != types.TableTypeView {
+		t.Errorf("expected view type, got %s", view.Type)
+	}
+
+	users := public.Tables[1]
+	if users.Type != types.TableTypeTable {
+		t.Errorf("expected table type, got %s", users.Type)
+	}
+	if len(users.Columns) != 2 {
+		t.Fatalf("expected 2 columns, got %d", len(users.Columns))
+	}
+	if users.Columns[0].Nullable || users.Columns[0].Default == nil {
+		t.Errorf("expected non-null id with default, got %+v", users.Columns[0])
+	}
+	if users.Columns[1].DataType != "character varying(255)" || users.Columns[1].Comment == nil {
+		t.Errorf("unexpected email column: %+v", users.Columns[1])
+	}
+
+	if len(public.Tables[2].Columns) != 0 {
+		t.Errorf("expected table without columns, got %d", len(public.Tables[2].Columns))
+	}
+}
+
+func TestPostgresDescribeSchemaError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT n.nspname").WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectRollback()
+
+	if _, err := eng.DescribeSchema(context.Background()); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestPostgresFetchColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+	schema := "sales"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM information_schema.columns c").
+		WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"table_schema", "column_name", "ordinal_position", "data_type", "udt_name",
+			"is_nullable", "column_default", "character_maximum_length", "numeric_precision", "numeric_scale", "pk",
+		}).
+			AddRow("sales", "id", 1, "bigint", "int8", "NO", "nextval('orders_id_seq'::regclass)", nil, 64, 0, true).
+			AddRow("sales", "total", 2, "numeric", "numeric", "YES", nil, nil, 10, 2, false).
+			AddRow("sales", "note", 3, "character varying", "varchar", "YES", nil, 255, nil, nil, false).
+			AddRow("sales", "tags", 4, "ARRAY", "_text", "YES", nil, nil, nil, nil, false))
+	mock.ExpectRollback()
+
+	result, err := eng.FetchColumns(context.Background(), &types.FetchColumnsParams{SchemaName: &schema, Table: "orders"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+
+	if result.SchemaName != "sales" || result.Table != "orders" {
+		t.Errorf("unexpected table reference: %s.%s", result.SchemaName, result.Table)
+	}
+	if len(result.Columns) != 4 {
+		t.Fatalf("expected 4 columns, got %d", len(result.Columns))
+	}
+
+	id := result.Columns[0]
+	if !id.PrimaryKey || id.Nullable || id.LogicalType != types.LogicalTypeInt || id.Default == nil {
+		t.Errorf("unexpected id column: %+v", id)
+	}
+
+	total := result.Columns[1]
+	if total.LogicalType != types.LogicalTypeDecimal || total.NumericPrecision == nil || *total.NumericPrecision != 10 ||
+		total.NumericScale == nil || *total.NumericScale != 2 {
+		t.Errorf("unexpected total column: %+v", total)
+	}
+
+	note := result.Columns[2]
+	if note.CharacterMaxLength == nil || *note.CharacterMaxLength != 255 || note.LogicalType != types.LogicalTypeString {
+		t.Errorf("unexpected note column: %+v", note)
+	}
+
+	tags := result.Columns[3]
+	if tags.NativeType != "_text" || tags.LogicalType != types.LogicalTypeJSON {
+		t.Errorf("unexpected tags column: %+v", tags)
+	}
+}
+
+func TestPostgresFetchColumnsPrimaryKeyQuery(t *testing.T) {
+	// information_schema.table_constraints hides the constraints of tables
+	// the role does not own, so the primary key must come from the catalog
+	if strings.Contains(fetchColumnsQuery, "table_constraints") || strings.Contains(fetchColumnsQuery, "key_column_usage") {
+		t.Error("expected primary key lookup without information_schema constraint views")
+	}
+	for _, want := range []string{"pg_catalog.pg_index", "i.indisprimary", "pg_catalog.pg_attribute"} {
+		if !strings.Contains(fetchColumnsQuery, want) {
+			t.Errorf("expected fetch columns query to contain %q", want)
+		}
+	}
+}
+
+func TestPostgresFetchColumnsDefaultSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM information_schema.columns c").
+		WithArgs(nil, "missing").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"table_schema", "column_name", "ordinal_position", "data_type", "udt_name",
+			"is_nullable", "column_default", "character_maximum_length", "numeric_precision", "numeric_scale", "pk",
+		}))
+	mock.ExpectRollback()
+
+	if _, err := eng.FetchColumns(context.Background(), &types.FetchColumnsParams{Table: "missing"}); err == nil {
+		t.Error("expected error for unknown table, got nil")
+	}
+}
+
+func TestPostgresFetchColumnsTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Catalog queries run with the statement timeout like any other query
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL statement_timeout = [0-9]+").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM information_schema.columns c").
+		WithArgs(nil, "orders").
+		WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"})
+	mock.ExpectRollback()
+
+	_, err = eng.FetchColumns(ctx, &types.FetchColumnsParams{Table: "orders"})
+	if !errors.Is(err, types.ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresLogicalType(t *testing.T) {
+	tests := map[string]types.LogicalType{
+		"int4":        types.LogicalTypeInt,
+		"INT8":        types.LogicalTypeInt,
+		"numeric":     types.LogicalTypeDecimal,
+		"float8":      types.LogicalTypeDecimal,
+		"timestamptz": types.LogicalTypeTime,
+		"DATE":        types.LogicalTypeTime,
+		"bool":        types.LogicalTypeBool,
+		"jsonb":       types.LogicalTypeJSON,
+		"_int4":       types.LogicalTypeJSON,
+		"bytea":       types.LogicalTypeBinary,
+		"uuid":        types.LogicalTypeString,
+		"varchar":     types.LogicalTypeString,
+	}
+
+	for name, expected := range tests {
+		if got := logicalType(name); got != expected {
+			t.Errorf("logicalType(%q) = %s, want %s", name, got, expected)
+		}
+	}
+}
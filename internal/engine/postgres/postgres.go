@@ -5,18 +5,24 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"starless/kadath/configs"
+	"starless/kadath/internal/sqlguard"
 	"starless/kadath/internal/types"
 )
 
 type postgresEngine struct {
-	db *sql.DB
+	db             *sql.DB
+	allowRawSelect bool
 }
 
+// identifiers quotes every table and column name with double quotes
+var identifiers = types.PostgresIdentifiers
+
 // buildDSN adds SSL mode to the DSN if not already present
 func buildDSN(baseDSN, sslMode string) string {
 	// Check if DSN already contains sslmode parameter
@@ -41,7 +47,8 @@ func NewEngine(cfg *configs.Config) (types.Engine, error) {
 	}
 
 	return &postgresEngine{
-		db: db,
+		db:             db,
+		allowRawSelect: cfg.AllowRawSelect,
 	}, nil
 }
 
@@ -51,23 +58,28 @@ func (e *postgresEngine) buildQuery(params *types.QueryParams) (string, []interf
 	argIndex := 1
 
 	// SELECT clause
-	selectClause := "*"
-	if params.Select != nil && *params.Select != "" {
-		selectClause = *params.Select
+	selectClause, err := e.buildSelect(params)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid select: %w", err)
 	}
 
 	// FROM clause with optional schema
-	tableName := params.Table
-	if params.SchemaName != nil && *params.SchemaName != "" {
-		tableName = fmt.Sprintf("%s.%s", *params.SchemaName, params.Table)
+	tableName, err := identifiers.QuoteTable(params.SchemaName, params.Table)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid table: %w", err)
 	}
 
 	query = fmt.Sprintf("SELECT %s FROM %s", selectClause, tableName)
 
-	// WHERE clause
-	if len(params.Conditions) > 0 {
+	// WHERE clause, including the keyset condition of a cursor. Postgres
+	// sorts NULLs as larger than any other value.
+	conditions, skip, err := params.PageConditions(true)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if len(conditions) > 0 {
 		whereClauses := []string{}
-		for _, cond := range params.Conditions {
+		for _, cond := range conditions {
 			clause, condArgs, newIndex, err := e.buildCondition(cond, argIndex)
 			if err != nil {
 				return "", nil, fmt.Errorf("failed to build condition: %w", err)
@@ -81,7 +93,13 @@ func (e *postgresEngine) buildQuery(params *types.QueryParams) (string, []interf
 
 	// GROUP BY clause
 	if len(params.GroupBy) > 0 {
-		query += " GROUP BY " + types.JoinColumns(params.GroupBy)
+		groupBy := make([]string, len(params.GroupBy))
+		for i, col := range params.GroupBy {
+			if groupBy[i], err = identifiers.QuoteColumn(col); err != nil {
+				return "", nil, fmt.Errorf("invalid group by: %w", err)
+			}
+		}
+		query += " GROUP BY " + types.JoinColumns(groupBy)
 	}
 
 	// HAVING clause
@@ -99,67 +117,239 @@ func (e *postgresEngine) buildQuery(params *types.QueryParams) (string, []interf
 		query += " HAVING " + types.JoinWithAnd(havingClauses)
 	}
 
+	// ORDER BY clause
+	if len(params.OrderBy) > 0 {
+		orderBy, err := e.buildOrderBy(params)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid order by: %w", err)
+		}
+		query += " ORDER BY " + orderBy
+	}
+
 	// LIMIT clause
 	if params.Limit != nil && *params.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argIndex)
 		args = append(args, *params.Limit)
+		argIndex++
+	}
+
+	// OFFSET clause
+	if skip > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argIndex)
+		args = append(args, skip)
 	}
 
 	return query, args, nil
 }
 
+// rawSelect reports whether the legacy select string of params is pasted
+// into the query verbatim
+func (e *postgresEngine) rawSelect(params *types.QueryParams) bool {
+	return e.allowRawSelect && len(params.Projections) == 0 && params.Select != nil && *params.Select != ""
+}
+
+// buildSelect renders the select list from structured projections or the
+// legacy select string
+func (e *postgresEngine) buildSelect(params *types.QueryParams) (string, error) {
+	selectClause := "*"
+
+	switch {
+	case len(params.Projections) > 0:
+		items := make([]string, len(params.Projections))
+		for i, p := range params.Projections {
+			item, err := identifiers.QuoteProjection(p)
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		selectClause = types.JoinColumns(items)
+
+	case e.rawSelect(params):
+		// Legacy contract, only enabled by explicit configuration. The
+		// string is checked by sqlguard before it is pasted in.
+		if err := sqlguard.ValidateSelectList(*params.Select, sqlguard.DialectPostgres); err != nil {
+			return "", err
+		}
+		selectClause = *params.Select
+
+	case params.Select != nil && *params.Select != "":
+		items, err := types.ParseSelectList(*params.Select)
+		if err != nil {
+			return "", err
+		}
+		for i, item := range items {
+			if items[i], err = identifiers.QuoteSelectItem(item); err != nil {
+				return "", err
+			}
+		}
+		selectClause = types.JoinColumns(items)
+	}
+
+	if params.Distinct {
+		selectClause = "DISTINCT " + selectClause
+	}
+
+	return selectClause, nil
+}
+
+// buildOrderBy renders the ORDER BY list, using the native NULLS FIRST and
+// NULLS LAST modifiers
+func (e *postgresEngine) buildOrderBy(params *types.QueryParams) (string, error) {
+	terms := make([]string, 0, len(params.OrderBy))
+	for _, term := range params.OrderBy {
+		resolved := params.ResolveOrderTerm(term)
+		if err := resolved.Validate(); err != nil {
+			return "", err
+		}
+
+		expr, err := identifiers.QuoteAggregate(resolved.Aggregate, resolved.Column)
+		if err != nil {
+			return "", err
+		}
+
+		if resolved.Descending() {
+			expr += " DESC"
+		} else {
+			expr += " ASC"
+		}
+
+		switch resolved.Nulls {
+		case types.NullsFirst:
+			expr += " NULLS FIRST"
+		case types.NullsLast:
+			expr += " NULLS LAST"
+		}
+
+		terms = append(terms, expr)
+	}
+
+	return types.JoinColumns(terms), nil
+}
+
+// quoteConditionColumn renders the left hand side of a condition, which is
+// an aggregate in HAVING conditions
+func quoteConditionColumn(cond types.Condition) (string, error) {
+	if cond.Aggregate != "" {
+		return identifiers.QuoteAggregate(cond.Aggregate, cond.Column)
+	}
+	return identifiers.QuoteColumn(cond.Column)
+}
+
 func (e *postgresEngine) buildCondition(cond types.Condition, startIndex int) (string, []interface{}, int, error) {
+	if cond.IsGroup() {
+		return e.buildConditionGroup(cond, startIndex)
+	}
+
 	var clause string
 	var args []interface{}
 	currentIndex := startIndex
 
+	column, err := quoteConditionColumn(cond)
+	if err != nil {
+		return "", nil, currentIndex, fmt.Errorf("invalid column: %w", err)
+	}
+
 	switch cond.Type {
 	case types.ConditionTypeEqual:
-		clause = fmt.Sprintf("%s = $%d", cond.Column, currentIndex)
+		clause = fmt.Sprintf("%s = $%d", column, currentIndex)
 		args = append(args, cond.Value)
 		currentIndex++
 
 	case types.ConditionTypeNotEqual:
-		clause = fmt.Sprintf("%s != $%d", cond.Column, currentIndex)
+		clause = fmt.Sprintf("%s != $%d", column, currentIndex)
 		args = append(args, cond.Value)
 		currentIndex++
 
 	case types.ConditionTypeGreaterThan:
-		clause = fmt.Sprintf("%s > $%d", cond.Column, currentIndex)
+		clause = fmt.Sprintf("%s > $%d", column, currentIndex)
 		args = append(args, cond.Value)
 		currentIndex++
 
 	case types.ConditionTypeGreaterThanOrEqual:
-		clause = fmt.Sprintf("%s >= $%d", cond.Column, currentIndex)
+		clause = fmt.Sprintf("%s >= $%d", column, currentIndex)
 		args = append(args, cond.Value)
 		currentIndex++
 
 	case types.ConditionTypeLessThan:
-		clause = fmt.Sprintf("%s < $%d", cond.Column, currentIndex)
+		clause = fmt.Sprintf("%s < $%d", column, currentIndex)
 		args = append(args, cond.Value)
 		currentIndex++
 
 	case types.ConditionTypeLessThanOrEqual:
-		clause = fmt.Sprintf("%s <= $%d", cond.Column, currentIndex)
+		clause = fmt.Sprintf("%s <= $%d", column, currentIndex)
 		args = append(args, cond.Value)
 		currentIndex++
 
 	case types.ConditionTypeLike:
-		clause = fmt.Sprintf("%s LIKE $%d", cond.Column, currentIndex)
+		clause = fmt.Sprintf("%s LIKE $%d", column, currentIndex)
+		args = append(args, cond.Value)
+		currentIndex++
+
+	case types.ConditionTypeNotLike:
+		clause = fmt.Sprintf("%s NOT LIKE $%d", column, currentIndex)
+		args = append(args, cond.Value)
+		currentIndex++
+
+	case types.ConditionTypeILike:
+		clause = fmt.Sprintf("%s ILIKE $%d", column, currentIndex)
 		args = append(args, cond.Value)
 		currentIndex++
 
-	case types.ConditionTypeIn:
-		// For IN clause, value should be a slice
-		clause = fmt.Sprintf("%s = ANY($%d)", cond.Column, currentIndex)
+	case types.ConditionTypeStartsWith, types.ConditionTypeEndsWith, types.ConditionTypeContains:
+		pattern, err := types.LikePattern(cond)
+		if err != nil {
+			return "", nil, currentIndex, fmt.Errorf("invalid %s value: %w", cond.Type, err)
+		}
+		clause = fmt.Sprintf(`%s LIKE $%d ESCAPE '\'`, column, currentIndex)
+		args = append(args, pattern)
+		currentIndex++
+
+	case types.ConditionTypeRegex:
+		// POSIX regular expression match
+		clause = fmt.Sprintf("%s ~ $%d", column, currentIndex)
 		args = append(args, cond.Value)
 		currentIndex++
 
+	case types.ConditionTypeBetween:
+		low, high, err := types.BetweenValues(cond.Value)
+		if err != nil {
+			return "", nil, currentIndex, fmt.Errorf("invalid between value: %w", err)
+		}
+		clause = fmt.Sprintf("%s BETWEEN $%d AND $%d", column, currentIndex, currentIndex+1)
+		args = append(args, low, high)
+		currentIndex += 2
+
+	case types.ConditionTypeIn, types.ConditionTypeNotIn:
+		values, err := types.ListValues(cond.Value)
+		if err != nil {
+			return "", nil, currentIndex, fmt.Errorf("invalid %s value: %w", cond.Type, err)
+		}
+
+		// An empty list matches nothing for in and everything for not_in
+		switch {
+		case len(values) == 0 && cond.Type == types.ConditionTypeIn:
+			clause = "1 = 0"
+		case len(values) == 0:
+			clause = "1 = 1"
+		case cond.Type == types.ConditionTypeIn:
+			clause = fmt.Sprintf("%s = ANY($%d)", column, currentIndex)
+		default:
+			clause = fmt.Sprintf("%s <> ALL($%d)", column, currentIndex)
+		}
+
+		// The list is sent as a single array parameter, which lib/pq can
+		// only encode through pq.Array
+		if len(values) > 0 {
+			args = append(args, pq.Array(values))
+			currentIndex++
+		}
+
 	case types.ConditionTypeIsNull:
-		clause = fmt.Sprintf("%s IS NULL", cond.Column)
+		clause = fmt.Sprintf("%s IS NULL", column)
 
 	case types.ConditionTypeIsNotNull:
-		clause = fmt.Sprintf("%s IS NOT NULL", cond.Column)
+		clause = fmt.Sprintf("%s IS NOT NULL", column)
 
 	default:
 		return "", nil, currentIndex, fmt.Errorf("unsupported condition type: %s", cond.Type)
@@ -168,6 +358,45 @@ func (e *postgresEngine) buildCondition(cond types.Condition, startIndex int) (s
 	return clause, args, currentIndex, nil
 }
 
+// buildConditionGroup renders an all/any/not group in parentheses, numbering
+// the placeholders of nested conditions in order
+func (e *postgresEngine) buildConditionGroup(cond types.Condition, startIndex int) (string, []interface{}, int, error) {
+	if cond.Not != nil {
+		clause, args, newIndex, err := e.buildCondition(*cond.Not, startIndex)
+		if err != nil {
+			return "", nil, startIndex, err
+		}
+		// Groups are already parenthesized
+		if cond.Not.IsGroup() {
+			return "NOT " + clause, args, newIndex, nil
+		}
+		return fmt.Sprintf("NOT (%s)", clause), args, newIndex, nil
+	}
+
+	children, join := cond.All, types.JoinWithAnd
+	if cond.Any != nil {
+		children, join = cond.Any, types.JoinWithOr
+	}
+	if len(children) == 0 {
+		return "", nil, startIndex, fmt.Errorf("empty condition group")
+	}
+
+	var clauses []string
+	var args []interface{}
+	currentIndex := startIndex
+	for _, child := range children {
+		clause, childArgs, newIndex, err := e.buildCondition(child, currentIndex)
+		if err != nil {
+			return "", nil, startIndex, err
+		}
+		clauses = append(clauses, clause)
+		args = append(args, childArgs...)
+		currentIndex = newIndex
+	}
+
+	return fmt.Sprintf("(%s)", join(clauses)), args, currentIndex, nil
+}
+
 func (e *postgresEngine) Ping(ctx context.Context) error {
 	// Use SELECT 1 to check if database is reachable
 	var result int
@@ -183,24 +412,267 @@ func (e *postgresEngine) Ping(ctx context.Context) error {
 	return nil
 }
 
+func (e *postgresEngine) Info(ctx context.Context) (*types.EngineInfo, error) {
+	var version string
+	if err := e.db.QueryRowContext(ctx, "SHOW server_version").Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to get postgres version: %w", err)
+	}
+
+	return &types.EngineInfo{Type: "postgres", Version: version}, nil
+}
+
 func (e *postgresEngine) ExecuteQuery(ctx context.Context, params *types.QueryParams) (*types.QueryResponse, error) {
+	collector := &types.RowCollector{}
+	summary, err := e.StreamQuery(ctx, params, collector)
+	if err != nil {
+		return nil, err
+	}
+
+	result := collector.Response()
+	result.NextCursor = summary.NextCursor
+	return result, nil
+}
+
+// StreamQuery runs the query writing its rows to w. When ctx is cancelled
+// lib/pq sends a cancel request for the running statement, so a cancelled
+// job also stops on the server. The query runs in a read-only transaction
+// with the deadline of ctx set as its statement timeout.
+func (e *postgresEngine) StreamQuery(ctx context.Context, params *types.QueryParams, w types.RowWriter) (*types.StreamSummary, error) {
 	query, args, err := e.buildQuery(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build query: %w", err)
+		return nil, types.ValidationError(fmt.Errorf("failed to build query: %w", err))
+	}
+
+	tx, release, err := e.queryConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", timeoutError(ctx, err))
+	}
+	defer rows.Close()
+
+	// The tracker keeps the values as scanned, which are the ones the
+	// cursor passes back as query arguments
+	tracker := params.NewPageTracker(types.NormalizeRows(w))
+	count, err := streamRows(rows, tracker)
+	if err != nil {
+		return nil, timeoutError(ctx, err)
+	}
+
+	cursor, err := tracker.NextCursor()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cursor: %w", err)
+	}
+
+	return &types.StreamSummary{RowCount: count, NextCursor: cursor}, nil
+}
+
+func (e *postgresEngine) ExecuteRawQuery(ctx context.Context, params *types.RawQueryParams) (*types.QueryResponse, error) {
+	collector := &types.RowCollector{}
+	if _, err := e.StreamRawQuery(ctx, params, collector); err != nil {
+		return nil, err
 	}
+	return collector.Response(), nil
+}
 
-	rows, err := e.db.QueryContext(ctx, query, args...)
+func (e *postgresEngine) StreamRawQuery(ctx context.Context, params *types.RawQueryParams, w types.RowWriter) (*types.StreamSummary, error) {
+	stmt, err := sqlguard.ValidateReadOnly(params.SQL, sqlguard.DialectPostgres)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, types.ValidationError(fmt.Errorf("rejected query: %w", err))
+	}
+	if stmt.ParamCount != len(params.Params) {
+		return nil, types.ValidationError(fmt.Errorf("query expects %d parameters, got %d", stmt.ParamCount, len(params.Params)))
+	}
+
+	tx, release, err := e.queryConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := tx.QueryContext(ctx, params.SQL, params.Params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", timeoutError(ctx, err))
 	}
 	defer rows.Close()
 
-	columns, err := rows.Columns()
+	count, err := streamRows(rows, types.NormalizeRows(w))
+	if err != nil {
+		return nil, timeoutError(ctx, err)
+	}
+
+	return &types.StreamSummary{RowCount: count}, nil
+}
+
+// queryConn returns a read-only transaction to run a query on, with the
+// deadline of ctx set as its statement timeout. Nothing is ever committed;
+// the transaction only exists to make the database enforce read-only
+// access. release must be called once the query is done.
+func (e *postgresEngine) queryConn(ctx context.Context) (*sql.Tx, func(), error) {
+	tx, err := e.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	if err := setStatementTimeout(ctx, tx); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	return tx, func() { tx.Rollback() }, nil
+}
+
+// setStatementTimeout makes the database stop the statements of tx once the
+// deadline of ctx has passed, even if the cancel request sent by lib/pq is
+// lost. It does nothing when ctx has no deadline.
+func setStatementTimeout(ctx context.Context, tx *sql.Tx) error {
+	ms, ok := types.StatementTimeout(ctx)
+	if !ok {
+		return nil
+	}
+
+	// SET does not take parameters
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", ms)); err != nil {
+		return fmt.Errorf("failed to set statement timeout: %w", timeoutError(ctx, err))
+	}
+	return nil
+}
+
+// timeoutError marks err as a timeout when the statement was stopped by
+// the statement timeout or the deadline of ctx
+func timeoutError(ctx context.Context, err error) error {
+	var pqErr *pq.Error
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &pqErr) && pqErr.Code == "57014" && strings.Contains(pqErr.Message, "statement timeout")) {
+		return fmt.Errorf("%w: %w", types.ErrTimeout, err)
+	}
+	return err
+}
+
+// ClassifyError returns the error code of err, keeping the SQLSTATE and
+// message of the postgres error it comes from. It returns nil for errors it
+// does not know.
+func ClassifyError(err error) *types.QueryError {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return types.ClassifyCommonError(err)
+	}
+
+	code := sqlStateCode(pqErr)
+	if errors.Is(err, types.ErrTimeout) {
+		code = types.ErrorTimeout
+	}
+	return &types.QueryError{
+		Code:          code,
+		NativeCode:    string(pqErr.Code),
+		NativeMessage: pqErr.Message,
+		Err:           err,
+	}
+}
+
+// sqlStateCode maps a SQLSTATE to an error code
+func sqlStateCode(pqErr *pq.Error) types.ErrorCode {
+	switch pqErr.Code {
+	case "42703": // undefined_column
+		return types.ErrorUnknownColumn
+	case "42P01", "3F000": // undefined_table, invalid_schema_name
+		return types.ErrorUnknownTable
+	case "42501": // insufficient_privilege
+		return types.ErrorPermissionDenied
+	case "42601": // syntax_error
+		return types.ErrorSyntax
+	case "57014": // query_canceled
+		if strings.Contains(pqErr.Message, "statement timeout") {
+			return types.ErrorTimeout
+		}
+		return types.ErrorCancelled
+	case "57P01", "57P02", "57P03": // the server is shutting down or starting
+		return types.ErrorConnection
+	}
+
+	switch pqErr.Code.Class() {
+	case "08": // connection_exception
+		return types.ErrorConnection
+	case "28": // invalid_authorization_specification
+		return types.ErrorPermissionDenied
+	case "22", "25", "42": // data_exception, invalid_transaction_state, syntax_error_or_access_rule_violation
+		return types.ErrorValidation
+	}
+	return types.ErrorInternal
+}
+
+// resultColumns describes the columns of rows. lib/pq does not report
+// nullability, so Nullable is left unset.
+func resultColumns(rows *sql.Rows) ([]types.ResultColumn, error) {
+	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
-	results := []types.QueryResult{}
+	columns := make([]types.ResultColumn, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = types.ResultColumn{
+			Name:        ct.Name(),
+			NativeType:  ct.DatabaseTypeName(),
+			LogicalType: logicalType(ct.DatabaseTypeName()),
+		}
+		columns[i].Kind, columns[i].ElemKind = valueKind(ct.DatabaseTypeName())
+		if nullable, ok := ct.Nullable(); ok {
+			columns[i].Nullable = &nullable
+		}
+	}
+	return columns, nil
+}
+
+// valueKind returns how the values of a type reported by lib/pq are
+// normalized, and for arrays how their elements are
+func valueKind(typeName string) (types.ValueKind, types.ValueKind) {
+	name := strings.ToUpper(typeName)
+	if elem, ok := strings.CutPrefix(name, "_"); ok {
+		kind, _ := valueKind(elem)
+		return types.ValueArray, kind
+	}
+
+	switch name {
+	case "INT2", "INT4", "INT8", "OID":
+		return types.ValueInt, types.ValueText
+	case "FLOAT4", "FLOAT8":
+		return types.ValueFloat, types.ValueText
+	case "NUMERIC":
+		return types.ValueDecimal, types.ValueText
+	case "BOOL":
+		return types.ValueBool, types.ValueText
+	case "TIMESTAMP", "TIMESTAMPTZ":
+		return types.ValueTimestamp, types.ValueText
+	case "DATE":
+		return types.ValueDate, types.ValueText
+	case "INTERVAL":
+		return types.ValueInterval, types.ValueText
+	case "UUID":
+		return types.ValueUUID, types.ValueText
+	case "JSON", "JSONB":
+		return types.ValueJSON, types.ValueText
+	case "BYTEA":
+		return types.ValueBinary, types.ValueText
+	default:
+		return types.ValueText, types.ValueText
+	}
+}
+
+// streamRows scans every row of rows into w and returns the row count
+func streamRows(rows *sql.Rows, w types.RowWriter) (int, error) {
+	columns, err := resultColumns(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := w.WriteHeader(columns); err != nil {
+		return 0, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	count := 0
 	for rows.Next() {
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
@@ -209,30 +681,28 @@ func (e *postgresEngine) ExecuteQuery(ctx context.Context, params *types.QueryPa
 		}
 
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return count, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		row := make(types.QueryResult)
-		for i, col := range columns {
-			val := values[i]
-			// Convert byte arrays to strings for better JSON serialization
+		for i, val := range values {
+			// Byte arrays are kept as strings, which cursors can encode;
+			// types.NormalizeRows converts them by column type
 			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
+				values[i] = string(b)
 			}
 		}
-		results = append(results, row)
+
+		if err := w.WriteRow(values); err != nil {
+			return count, fmt.Errorf("failed to write row: %w", err)
+		}
+		count++
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		return count, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return &types.QueryResponse{
-		Rows:     results,
-		RowCount: len(results),
-	}, nil
+	return count, nil
 }
 
 func (e *postgresEngine) Close() error {
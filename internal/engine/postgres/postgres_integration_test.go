@@ -4,10 +4,14 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"starless/kadath/internal/types"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 )
 
 func TestPostgresExecuteQuery(t *testing.T) {
@@ -36,7 +40,7 @@ func TestPostgresExecuteQuery(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "name", "email"}).
 					AddRow(1, "Alice", "alice@example.com").
 					AddRow(2, "Bob", "bob@example.com")
-				m.ExpectQuery("SELECT \\* FROM users").WillReturnRows(rows)
+				m.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(rows)
 			},
 			expectError: false,
 			validateResult: func(t *testing.T, result *types.QueryResponse) {
@@ -62,7 +66,7 @@ func TestPostgresExecuteQuery(t *testing.T) {
 			setupMock: func(m sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "name"}).
 					AddRow(1, "Alice")
-				m.ExpectQuery("SELECT \\* FROM users WHERE status = \\$1").
+				m.ExpectQuery(`SELECT \* FROM "users" WHERE "status" = \$1`).
 					WithArgs("active").
 					WillReturnRows(rows)
 			},
@@ -82,7 +86,7 @@ func TestPostgresExecuteQuery(t *testing.T) {
 			setupMock: func(m sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "name"}).
 					AddRow(1, "Alice")
-				m.ExpectQuery("SELECT \\* FROM users LIMIT \\$1").
+				m.ExpectQuery(`SELECT \* FROM "users" LIMIT \$1`).
 					WithArgs(10).
 					WillReturnRows(rows)
 			},
@@ -106,7 +110,7 @@ func TestPostgresExecuteQuery(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "total"}).
 					AddRow(1, 150.50).
 					AddRow(2, 200.00)
-				m.ExpectQuery("SELECT \\* FROM orders WHERE status = \\$1 AND total > \\$2").
+				m.ExpectQuery(`SELECT \* FROM "orders" WHERE "status" = \$1 AND "total" > \$2`).
 					WithArgs("completed", 100).
 					WillReturnRows(rows)
 			},
@@ -117,6 +121,29 @@ func TestPostgresExecuteQuery(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "query with in and not in conditions",
+			params: &types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "status", Type: types.ConditionTypeIn, Value: []interface{}{"new", "paid"}},
+					{Column: "id", Type: types.ConditionTypeNotIn, Value: []interface{}{float64(3), float64(4)}},
+				},
+			},
+			setupMock: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "status"}).
+					AddRow(1, "new")
+				m.ExpectQuery(`SELECT \* FROM "orders" WHERE "status" = ANY\(\$1\) AND "id" <> ALL\(\$2\)`).
+					WithArgs(`{"new","paid"}`, `{3,4}`).
+					WillReturnRows(rows)
+			},
+			expectError: false,
+			validateResult: func(t *testing.T, result *types.QueryResponse) {
+				if result.RowCount != 1 {
+					t.Errorf("expected 1 row, got %d", result.RowCount)
+				}
+			},
+		},
 		{
 			name: "empty result set",
 			params: &types.QueryParams{
@@ -124,7 +151,7 @@ func TestPostgresExecuteQuery(t *testing.T) {
 			},
 			setupMock: func(m sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "name"})
-				m.ExpectQuery("SELECT \\* FROM users").WillReturnRows(rows)
+				m.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(rows)
 			},
 			expectError: false,
 			validateResult: func(t *testing.T, result *types.QueryResponse) {
@@ -140,7 +167,9 @@ func TestPostgresExecuteQuery(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectBegin()
 			tt.setupMock(mock)
+			mock.ExpectRollback()
 
 			result, err := eng.ExecuteQuery(ctx, tt.params)
 
@@ -206,6 +235,31 @@ func TestPostgresPing(t *testing.T) {
 	})
 }
 
+func TestPostgresInfo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+
+	mock.ExpectQuery("SHOW server_version").
+		WillReturnRows(sqlmock.NewRows([]string{"server_version"}).AddRow("16.2"))
+
+	info, err := eng.Info(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Type != "postgres" || info.Version != "16.2" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
 func TestPostgresClose(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -232,3 +286,329 @@ func TestPostgresClose(t *testing.T) {
 		t.Errorf("unexpected error on close nil db: %v", err)
 	}
 }
+
+func TestPostgresExecuteRawQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+	ctx := context.Background()
+
+	t.Run("read query runs in a rolled back transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, name FROM users WHERE status = \\$1").
+			WithArgs("active").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+				AddRow(1, "Alice").
+				AddRow(2, "Bob"))
+		mock.ExpectRollback()
+
+		result, err := eng.ExecuteRawQuery(ctx, &types.RawQueryParams{
+			SQL:    "SELECT id, name FROM users WHERE status = $1",
+			Params: []interface{}{"active"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.RowCount != 2 {
+			t.Errorf("expected 2 rows, got %d", result.RowCount)
+		}
+		if result.Rows[1]["name"] != "Bob" {
+			t.Errorf("expected Bob, got %v", result.Rows[1]["name"])
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unfulfilled expectations: %v", err)
+		}
+	})
+
+	rejected := []struct {
+		name   string
+		params *types.RawQueryParams
+	}{
+		{name: "write statement", params: &types.RawQueryParams{SQL: "DELETE FROM users"}},
+		{name: "multiple statements", params: &types.RawQueryParams{SQL: "SELECT 1; DROP TABLE users"}},
+		{name: "locking clause", params: &types.RawQueryParams{SQL: "SELECT * FROM users FOR UPDATE"}},
+		{name: "parameter count mismatch", params: &types.RawQueryParams{SQL: "SELECT id, name FROM users WHERE status = $1"}},
+	}
+
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := eng.ExecuteRawQuery(ctx, tt.params); err == nil {
+				t.Error("expected error, got nil")
+			}
+
+			// Rejected statements must never reach the database
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unexpected database activity: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresExecuteQueryPagination(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+	ctx := context.Background()
+
+	params := &types.QueryParams{
+		Table:   "users",
+		OrderBy: []types.OrderTerm{{Column: "id"}},
+		Limit:   intPtr(2),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "users" ORDER BY "id" ASC LIMIT \$1`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Alice").
+			AddRow(2, "Bob"))
+	mock.ExpectRollback()
+
+	first, err := eng.ExecuteQuery(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("expected a cursor for a full page")
+	}
+
+	params.Cursor = first.NextCursor
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE \("id" >= \$1 OR "id" IS NULL\) ORDER BY "id" ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs(int64(2), 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(3, "Carol"))
+	mock.ExpectRollback()
+
+	second, err := eng.ExecuteQuery(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.RowCount != 1 || second.NextCursor != "" {
+		t.Errorf("expected a final page of 1 row, got %d rows and cursor %q", second.RowCount, second.NextCursor)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStreamQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "users" ORDER BY "id" ASC LIMIT \$1`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Alice").
+			AddRow(2, "Bob"))
+	mock.ExpectRollback()
+
+	collector := &types.RowCollector{}
+	summary, err := eng.StreamQuery(ctx, &types.QueryParams{
+		Table:   "users",
+		OrderBy: []types.OrderTerm{{Column: "id"}},
+		Limit:   intPtr(2),
+	}, collector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(collector.Columns) != 2 || len(collector.Rows) != 2 {
+		t.Errorf("unexpected rows written: %v %v", collector.Columns, collector.Rows)
+	}
+	if summary.RowCount != 2 || summary.NextCursor == "" {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	collector = &types.RowCollector{}
+	summary, err = eng.StreamRawQuery(ctx, &types.RawQueryParams{SQL: "SELECT id FROM users"}, collector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.RowCount != 1 || len(collector.Rows) != 1 {
+		t.Errorf("unexpected raw result: %+v %v", summary, collector.Rows)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresStreamQueryTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL statement_timeout = [0-9]+").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT \\* FROM \"users\"").
+		WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"})
+	mock.ExpectRollback()
+
+	_, err = eng.StreamQuery(ctx, &types.QueryParams{Table: "users"}, &types.RowCollector{})
+	if !errors.Is(err, types.ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+
+	// A cancelled statement is not a timeout
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL statement_timeout = [0-9]+").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT 1").
+		WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement due to user request"})
+	mock.ExpectRollback()
+
+	_, err = eng.StreamRawQuery(ctx, &types.RawQueryParams{SQL: "SELECT 1"}, &types.RowCollector{})
+	if err == nil || errors.Is(err, types.ErrTimeout) {
+		t.Fatalf("expected non timeout error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestStreamQueryResultColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("INT4", int64(0)),
+			sqlmock.NewColumn("tags").OfType("_TEXT", ""),
+			sqlmock.NewColumn("created_at").OfType("TIMESTAMPTZ", time.Time{}),
+		).AddRow(1, "{a}", time.Time{}))
+	mock.ExpectRollback()
+
+	result, err := eng.ExecuteQuery(context.Background(), &types.QueryParams{Table: "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []types.ResultColumn{
+		{Name: "id", NativeType: "INT4", LogicalType: types.LogicalTypeInt},
+		{Name: "tags", NativeType: "_TEXT", LogicalType: types.LogicalTypeJSON},
+		{Name: "created_at", NativeType: "TIMESTAMPTZ", LogicalType: types.LogicalTypeTime},
+	}
+	if len(result.Columns) != len(want) {
+		t.Fatalf("unexpected columns: %+v", result.Columns)
+	}
+	for i, col := range result.Columns {
+		// lib/pq does not report nullability
+		if col.Name != want[i].Name || col.NativeType != want[i].NativeType ||
+			col.LogicalType != want[i].LogicalType || col.Nullable != nil {
+			t.Errorf("columns[%d] = %+v, want %+v", i, col, want[i])
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestExecuteRawQueryDuplicateColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT a.id, b.id FROM a JOIN b USING \(code\)`).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("INT4", int64(0)),
+			sqlmock.NewColumn("id").OfType("INT4", int64(0)),
+		).AddRow(1, 2))
+	mock.ExpectRollback()
+
+	result, err := eng.ExecuteRawQuery(context.Background(), &types.RawQueryParams{SQL: "SELECT a.id, b.id FROM a JOIN b USING (code)"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	columnar := result.Columnar()
+	if len(columnar.Columns) != 2 || len(columnar.Rows) != 1 {
+		t.Fatalf("unexpected result: %+v", columnar)
+	}
+	if columnar.Rows[0][0] != int64(1) || columnar.Rows[0][1] != int64(2) {
+		t.Errorf("expected each id column to keep its value, got %v", columnar.Rows[0])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestExecuteQueryNormalizesValues(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	eng := &postgresEngine{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "orders"`).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("INT8", int64(0)),
+			sqlmock.NewColumn("total").OfType("NUMERIC", ""),
+			sqlmock.NewColumn("receipt").OfType("BYTEA", []byte{}),
+			sqlmock.NewColumn("tags").OfType("_TEXT", ""),
+			sqlmock.NewColumn("meta").OfType("JSONB", []byte{}),
+		).AddRow(int64(1<<60), []byte("10.50"), []byte{0xff, 0x00}, []byte("{a,b}"), []byte(`{"x":1}`)))
+	mock.ExpectRollback()
+
+	result, err := eng.ExecuteQuery(context.Background(), &types.QueryParams{Table: "orders"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(result.Rows[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"id":"1152921504606846976","meta":{"x":1},"receipt":"/wA=","tags":["a","b"],"total":"10.50"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
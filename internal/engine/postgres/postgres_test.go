@@ -3,9 +3,14 @@
 package postgres
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/lib/pq"
 	"starless/kadath/configs"
 	"starless/kadath/internal/types"
 )
@@ -25,7 +30,7 @@ func TestPostgresBuildQuery(t *testing.T) {
 			params: &types.QueryParams{
 				Table: "users",
 			},
-			expectedQuery: "SELECT * FROM users",
+			expectedQuery: `SELECT * FROM "users"`,
 			expectedArgs:  []interface{}{},
 			expectError:   false,
 		},
@@ -35,7 +40,7 @@ func TestPostgresBuildQuery(t *testing.T) {
 				Table:      "users",
 				SchemaName: stringPtr("public"),
 			},
-			expectedQuery: "SELECT * FROM public.users",
+			expectedQuery: `SELECT * FROM "public"."users"`,
 			expectedArgs:  []interface{}{},
 			expectError:   false,
 		},
@@ -45,7 +50,7 @@ func TestPostgresBuildQuery(t *testing.T) {
 				Table:  "users",
 				Select: stringPtr("id, name, email"),
 			},
-			expectedQuery: "SELECT id, name, email FROM users",
+			expectedQuery: `SELECT "id", "name", "email" FROM "users"`,
 			expectedArgs:  []interface{}{},
 			expectError:   false,
 		},
@@ -57,7 +62,7 @@ func TestPostgresBuildQuery(t *testing.T) {
 					{Column: "status", Type: types.ConditionTypeEqual, Value: "active"},
 				},
 			},
-			expectedQuery: "SELECT * FROM users WHERE status = $1",
+			expectedQuery: `SELECT * FROM "users" WHERE "status" = $1`,
 			expectedArgs:  []interface{}{"active"},
 			expectError:   false,
 		},
@@ -70,7 +75,7 @@ func TestPostgresBuildQuery(t *testing.T) {
 					{Column: "age", Type: types.ConditionTypeGreaterThan, Value: 18},
 				},
 			},
-			expectedQuery: "SELECT * FROM users WHERE status = $1 AND age > $2",
+			expectedQuery: `SELECT * FROM "users" WHERE "status" = $1 AND "age" > $2`,
 			expectedArgs:  []interface{}{"active", 18},
 			expectError:   false,
 		},
@@ -80,7 +85,7 @@ func TestPostgresBuildQuery(t *testing.T) {
 				Table: "users",
 				Limit: intPtr(10),
 			},
-			expectedQuery: "SELECT * FROM users LIMIT $1",
+			expectedQuery: `SELECT * FROM "users" LIMIT $1`,
 			expectedArgs:  []interface{}{10},
 			expectError:   false,
 		},
@@ -88,10 +93,10 @@ func TestPostgresBuildQuery(t *testing.T) {
 			name: "with group by",
 			params: &types.QueryParams{
 				Table:   "orders",
-				Select:  stringPtr("status, COUNT(*) as count"),
+				Select:  stringPtr("status"),
 				GroupBy: []string{"status"},
 			},
-			expectedQuery: "SELECT status, COUNT(*) as count FROM orders GROUP BY status",
+			expectedQuery: `SELECT "status" FROM "orders" GROUP BY "status"`,
 			expectedArgs:  []interface{}{},
 			expectError:   false,
 		},
@@ -99,14 +104,14 @@ func TestPostgresBuildQuery(t *testing.T) {
 			name: "with having",
 			params: &types.QueryParams{
 				Table:   "orders",
-				Select:  stringPtr("status, COUNT(*) as count"),
+				Select:  stringPtr("status"),
 				GroupBy: []string{"status"},
 				Having: []types.Condition{
-					{Column: "COUNT(*)", Type: types.ConditionTypeGreaterThan, Value: 5},
+					{Column: "status", Type: types.ConditionTypeNotEqual, Value: "cancelled"},
 				},
 			},
-			expectedQuery: "SELECT status, COUNT(*) as count FROM orders GROUP BY status HAVING COUNT(*) > $1",
-			expectedArgs:  []interface{}{5},
+			expectedQuery: `SELECT "status" FROM "orders" GROUP BY "status" HAVING "status" != $1`,
+			expectedArgs:  []interface{}{"cancelled"},
 			expectError:   false,
 		},
 		{
@@ -121,10 +126,201 @@ func TestPostgresBuildQuery(t *testing.T) {
 				},
 				Limit: intPtr(50),
 			},
-			expectedQuery: "SELECT id, name, created_at FROM public.users WHERE status = $1 AND email LIKE $2 LIMIT $3",
+			expectedQuery: `SELECT "id", "name", "created_at" FROM "public"."users" WHERE "status" = $1 AND "email" LIKE $2 LIMIT $3`,
 			expectedArgs:  []interface{}{"active", "%@example.com", 50},
 			expectError:   false,
 		},
+		{
+			name: "schema qualified table",
+			params: &types.QueryParams{
+				Table:  "sales.orders",
+				Select: stringPtr("orders.*, customers.name"),
+			},
+			expectedQuery: `SELECT "orders".*, "customers"."name" FROM "sales"."orders"`,
+			expectedArgs:  []interface{}{},
+			expectError:   false,
+		},
+		{
+			name: "injection attempts are quoted",
+			params: &types.QueryParams{
+				Table:  `users"; DROP TABLE users; --`,
+				Select: stringPtr("id, (SELECT password FROM admins)"),
+				Conditions: []types.Condition{
+					{Column: "1=1 OR name", Type: types.ConditionTypeEqual, Value: "x"},
+				},
+			},
+			expectedQuery: `SELECT "id", "(SELECT password FROM admins)" FROM "users""; DROP TABLE users; --" WHERE "1=1 OR name" = $1`,
+			expectedArgs:  []interface{}{"x"},
+			expectError:   false,
+		},
+		{
+			name: "schema with qualified table",
+			params: &types.QueryParams{
+				Table:      "public.users",
+				SchemaName: stringPtr("public"),
+			},
+			expectError: true,
+		},
+		{
+			name: "control character in column",
+			params: &types.QueryParams{
+				Table:   "users",
+				GroupBy: []string{"status\x00"},
+			},
+			expectError: true,
+		},
+		{
+			name: "identifier too long",
+			params: &types.QueryParams{
+				Table: "tablenameeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee",
+			},
+			expectError: true,
+		},
+		{
+			name: "structured projections with aggregates",
+			params: &types.QueryParams{
+				Table: "orders",
+				Projections: []types.Projection{
+					{Column: "status"},
+					{Aggregate: types.AggregateCount},
+					{Column: "customer_id", Aggregate: types.AggregateCountDistinct, Alias: "customers"},
+					{Column: "total", Aggregate: types.AggregateSum},
+					{Column: "total", Aggregate: types.AggregateAvg, Alias: "average"},
+					{Column: "created_at", Aggregate: types.AggregateMin},
+					{Column: "created_at", Aggregate: types.AggregateMax},
+				},
+				GroupBy: []string{"status"},
+				Having: []types.Condition{
+					{Aggregate: types.AggregateCount, Type: types.ConditionTypeGreaterThan, Value: 5},
+					{Column: "total", Aggregate: types.AggregateSum, Type: types.ConditionTypeGreaterThanOrEqual, Value: 100},
+				},
+			},
+			expectedQuery: `SELECT "status", COUNT(*) AS "count", COUNT(DISTINCT "customer_id") AS "customers", SUM("total") AS "sum_total", AVG("total") AS "average", MIN("created_at") AS "min_created_at", MAX("created_at") AS "max_created_at" FROM "orders" GROUP BY "status" HAVING COUNT(*) > $1 AND SUM("total") >= $2`,
+			expectedArgs:  []interface{}{5, 100},
+			expectError:   false,
+		},
+		{
+			name: "distinct projection with alias",
+			params: &types.QueryParams{
+				Table:    "users",
+				Distinct: true,
+				Projections: []types.Projection{
+					{Column: "users.country", Alias: "country code"},
+				},
+			},
+			expectedQuery: `SELECT DISTINCT "users"."country" AS "country code" FROM "users"`,
+			expectedArgs:  []interface{}{},
+			expectError:   false,
+		},
+		{
+			name: "nested or group",
+			params: &types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "deleted_at", Type: types.ConditionTypeIsNull},
+					{Any: []types.Condition{
+						{Column: "status", Type: types.ConditionTypeEqual, Value: "a"},
+						{All: []types.Condition{
+							{Column: "status", Type: types.ConditionTypeEqual, Value: "b"},
+							{Column: "total", Type: types.ConditionTypeGreaterThan, Value: 10},
+						}},
+					}},
+				},
+				Limit: intPtr(5),
+			},
+			expectedQuery: `SELECT * FROM "orders" WHERE "deleted_at" IS NULL AND ("status" = $1 OR ("status" = $2 AND "total" > $3)) LIMIT $4`,
+			expectedArgs:  []interface{}{"a", "b", 10, 5},
+			expectError:   false,
+		},
+		{
+			name: "negated group in having",
+			params: &types.QueryParams{
+				Table:   "orders",
+				GroupBy: []string{"status"},
+				Having: []types.Condition{
+					{Not: &types.Condition{Any: []types.Condition{
+						{Aggregate: types.AggregateCount, Type: types.ConditionTypeLessThan, Value: 2},
+						{Column: "total", Aggregate: types.AggregateSum, Type: types.ConditionTypeGreaterThan, Value: 100},
+					}}},
+				},
+			},
+			expectedQuery: `SELECT * FROM "orders" GROUP BY "status" HAVING NOT (COUNT(*) < $1 OR SUM("total") > $2)`,
+			expectedArgs:  []interface{}{2, 100},
+			expectError:   false,
+		},
+		{
+			name: "empty condition group",
+			params: &types.QueryParams{
+				Table:      "orders",
+				Conditions: []types.Condition{{Any: []types.Condition{}}},
+			},
+			expectError: true,
+		},
+		{
+			name: "order by with nulls ordering",
+			params: &types.QueryParams{
+				Table: "users",
+				OrderBy: []types.OrderTerm{
+					{Column: "last_login", Direction: types.SortDesc, Nulls: types.NullsLast},
+					{Column: "name", Nulls: types.NullsFirst},
+					{Column: "id"},
+				},
+				Limit: intPtr(50),
+			},
+			expectedQuery: `SELECT * FROM "users" ORDER BY "last_login" DESC NULLS LAST, "name" ASC NULLS FIRST, "id" ASC LIMIT $1`,
+			expectedArgs:  []interface{}{50},
+			expectError:   false,
+		},
+		{
+			name: "order by aggregate alias with group by",
+			params: &types.QueryParams{
+				Table: "orders",
+				Projections: []types.Projection{
+					{Column: "status"},
+					{Column: "total", Aggregate: types.AggregateSum, Alias: "revenue"},
+				},
+				GroupBy: []string{"status"},
+				OrderBy: []types.OrderTerm{
+					{Column: "revenue", Direction: types.SortDesc},
+					{Column: "status"},
+				},
+			},
+			expectedQuery: `SELECT "status", SUM("total") AS "revenue" FROM "orders" GROUP BY "status" ORDER BY SUM("total") DESC, "status" ASC`,
+			expectedArgs:  []interface{}{},
+			expectError:   false,
+		},
+		{
+			name: "order by star",
+			params: &types.QueryParams{
+				Table:   "users",
+				OrderBy: []types.OrderTerm{{Column: "*"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "order by unknown direction",
+			params: &types.QueryParams{
+				Table:   "users",
+				OrderBy: []types.OrderTerm{{Column: "id", Direction: "sideways"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "aggregate without column",
+			params: &types.QueryParams{
+				Table:       "orders",
+				Projections: []types.Projection{{Aggregate: types.AggregateSum}},
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown aggregate",
+			params: &types.QueryParams{
+				Table:       "orders",
+				Projections: []types.Projection{{Column: "total", Aggregate: "median"}},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -154,6 +350,127 @@ func TestPostgresBuildQuery(t *testing.T) {
 	}
 }
 
+func TestPostgresBuildQueryRawSelect(t *testing.T) {
+	params := &types.QueryParams{
+		Table:   "orders",
+		Select:  stringPtr("status, COUNT(*) as count"),
+		GroupBy: []string{"status"},
+	}
+
+	// Without the opt-in the legacy string is a list of column names
+	query, _, err := (&postgresEngine{}).buildQuery(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `SELECT "status", "COUNT(*) as count" FROM "orders" GROUP BY "status"`
+	if query != expected {
+		t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+	}
+
+	query, _, err = (&postgresEngine{allowRawSelect: true}).buildQuery(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = `SELECT status, COUNT(*) as count FROM "orders" GROUP BY "status"`
+	if query != expected {
+		t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+	}
+}
+
+func TestPostgresBuildQueryRawSelectGuard(t *testing.T) {
+	eng := &postgresEngine{allowRawSelect: true}
+
+	// Expressions containing commas are pasted verbatim
+	query, _, err := eng.buildQuery(&types.QueryParams{Table: "users", Select: stringPtr("COALESCE(nickname, name)")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `SELECT COALESCE(nickname, name) FROM "users"`
+	if query != expected {
+		t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+	}
+
+	// The select string must be a read-only expression list that cannot
+	// swallow or end the rest of the query
+	for _, sel := range []string{
+		"* INTO copy",
+		"1; DELETE FROM users",
+		"pg_read_file('x') --",
+		"id /* FROM users */",
+		"id) FROM users WHERE (true",
+		"COALESCE(nickname, name",
+		"'abc",
+		"id = $1",
+	} {
+		if _, _, err := eng.buildQuery(&types.QueryParams{Table: "users", Select: stringPtr(sel)}); err == nil {
+			t.Errorf("expected select %q to be rejected", sel)
+		}
+	}
+
+	// Without the opt-in the select string is a list of column names
+	_, _, err = (&postgresEngine{}).buildQuery(&types.QueryParams{Table: "users", Select: stringPtr("id,,name")})
+	if err == nil || !strings.Contains(err.Error(), "empty item") {
+		t.Errorf("expected empty item error, got %v", err)
+	}
+}
+
+func TestPostgresBuildQueryPagination(t *testing.T) {
+	eng := &postgresEngine{}
+
+	t.Run("offset", func(t *testing.T) {
+		params := &types.QueryParams{Table: "users", Limit: intPtr(10), Offset: intPtr(20)}
+		query, args, err := eng.buildQuery(params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := `SELECT * FROM "users" LIMIT $1 OFFSET $2`; query != expected {
+			t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+		}
+		if !argsEqual(args, []interface{}{10, 20}) {
+			t.Errorf("unexpected args: %v", args)
+		}
+	})
+
+	t.Run("keyset cursor", func(t *testing.T) {
+		params := &types.QueryParams{
+			Table: "users",
+			Conditions: []types.Condition{
+				{Column: "status", Type: types.ConditionTypeEqual, Value: "active"},
+			},
+			OrderBy: []types.OrderTerm{{Column: "created_at", Direction: types.SortDesc}, {Column: "id"}},
+			Limit:   intPtr(2),
+		}
+		cursor, err := types.EncodeCursor(&types.Cursor{
+			Query:  params.Fingerprint(),
+			After:  []interface{}{"2024-01-01T00:00:00Z", 42},
+			Offset: 1,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		params.Cursor = cursor
+
+		query, args, err := eng.buildQuery(params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := `SELECT * FROM "users" WHERE "status" = $1 AND ("created_at" < $2 OR ("created_at" = $3 AND ("id" >= $4 OR "id" IS NULL))) ORDER BY "created_at" DESC, "id" ASC LIMIT $5 OFFSET $6`
+		if query != expected {
+			t.Errorf("query mismatch:\nexpected: %s\ngot:      %s", expected, query)
+		}
+		if !argsEqual(args, []interface{}{"active", "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", int64(42), 2, 1}) {
+			t.Errorf("unexpected args: %v", args)
+		}
+	})
+
+	t.Run("cursor of another query", func(t *testing.T) {
+		cursor, _ := types.EncodeCursor(&types.Cursor{Query: "0000000000000000", Offset: 5})
+		if _, _, err := eng.buildQuery(&types.QueryParams{Table: "users", Cursor: cursor}); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
 func TestPostgresBuildCondition(t *testing.T) {
 	eng := &postgresEngine{}
 
@@ -170,7 +487,7 @@ func TestPostgresBuildCondition(t *testing.T) {
 			name:           "equal condition",
 			condition:      types.Condition{Column: "id", Type: types.ConditionTypeEqual, Value: 123},
 			startIndex:     1,
-			expectedClause: "id = $1",
+			expectedClause: `"id" = $1`,
 			expectedArgs:   []interface{}{123},
 			expectedIndex:  2,
 			expectError:    false,
@@ -179,7 +496,7 @@ func TestPostgresBuildCondition(t *testing.T) {
 			name:           "not equal condition",
 			condition:      types.Condition{Column: "status", Type: types.ConditionTypeNotEqual, Value: "deleted"},
 			startIndex:     1,
-			expectedClause: "status != $1",
+			expectedClause: `"status" != $1`,
 			expectedArgs:   []interface{}{"deleted"},
 			expectedIndex:  2,
 			expectError:    false,
@@ -188,7 +505,7 @@ func TestPostgresBuildCondition(t *testing.T) {
 			name:           "greater than condition",
 			condition:      types.Condition{Column: "age", Type: types.ConditionTypeGreaterThan, Value: 18},
 			startIndex:     3,
-			expectedClause: "age > $3",
+			expectedClause: `"age" > $3`,
 			expectedArgs:   []interface{}{18},
 			expectedIndex:  4,
 			expectError:    false,
@@ -197,7 +514,7 @@ func TestPostgresBuildCondition(t *testing.T) {
 			name:           "less than or equal condition",
 			condition:      types.Condition{Column: "price", Type: types.ConditionTypeLessThanOrEqual, Value: 100.50},
 			startIndex:     1,
-			expectedClause: "price <= $1",
+			expectedClause: `"price" <= $1`,
 			expectedArgs:   []interface{}{100.50},
 			expectedIndex:  2,
 			expectError:    false,
@@ -206,7 +523,7 @@ func TestPostgresBuildCondition(t *testing.T) {
 			name:           "like condition",
 			condition:      types.Condition{Column: "name", Type: types.ConditionTypeLike, Value: "John%"},
 			startIndex:     1,
-			expectedClause: "name LIKE $1",
+			expectedClause: `"name" LIKE $1`,
 			expectedArgs:   []interface{}{"John%"},
 			expectedIndex:  2,
 			expectError:    false,
@@ -215,16 +532,139 @@ func TestPostgresBuildCondition(t *testing.T) {
 			name:           "in condition",
 			condition:      types.Condition{Column: "status", Type: types.ConditionTypeIn, Value: []string{"active", "pending"}},
 			startIndex:     1,
-			expectedClause: "status = ANY($1)",
-			expectedArgs:   []interface{}{[]string{"active", "pending"}},
+			expectedClause: `"status" = ANY($1)`,
+			expectedArgs:   []interface{}{pq.Array([]interface{}{"active", "pending"})},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:           "in condition with mixed json values",
+			condition:      types.Condition{Column: "code", Type: types.ConditionTypeIn, Value: []interface{}{float64(1), "2", true}},
+			startIndex:     3,
+			expectedClause: `"code" = ANY($3)`,
+			expectedArgs:   []interface{}{pq.Array([]interface{}{float64(1), "2", true})},
+			expectedIndex:  4,
+			expectError:    false,
+		},
+		{
+			name:           "not in condition",
+			condition:      types.Condition{Column: "id", Type: types.ConditionTypeNotIn, Value: []interface{}{float64(1), float64(2)}},
+			startIndex:     1,
+			expectedClause: `"id" <> ALL($1)`,
+			expectedArgs:   []interface{}{pq.Array([]interface{}{float64(1), float64(2)})},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:           "empty in condition",
+			condition:      types.Condition{Column: "id", Type: types.ConditionTypeIn, Value: []interface{}{}},
+			startIndex:     2,
+			expectedClause: "1 = 0",
+			expectedArgs:   []interface{}{},
 			expectedIndex:  2,
 			expectError:    false,
 		},
+		{
+			name:           "empty not in condition",
+			condition:      types.Condition{Column: "id", Type: types.ConditionTypeNotIn, Value: []interface{}{}},
+			startIndex:     2,
+			expectedClause: "1 = 1",
+			expectedArgs:   []interface{}{},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:        "in condition with scalar value",
+			condition:   types.Condition{Column: "id", Type: types.ConditionTypeIn, Value: "1,2"},
+			startIndex:  1,
+			expectError: true,
+		},
+		{
+			name:        "in condition with null element",
+			condition:   types.Condition{Column: "id", Type: types.ConditionTypeIn, Value: []interface{}{float64(1), nil}},
+			startIndex:  1,
+			expectError: true,
+		},
+		{
+			name:           "not like condition",
+			condition:      types.Condition{Column: "email", Type: types.ConditionTypeNotLike, Value: "%@test.com"},
+			startIndex:     1,
+			expectedClause: `"email" NOT LIKE $1`,
+			expectedArgs:   []interface{}{"%@test.com"},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:           "ilike condition",
+			condition:      types.Condition{Column: "name", Type: types.ConditionTypeILike, Value: "al%"},
+			startIndex:     1,
+			expectedClause: `"name" ILIKE $1`,
+			expectedArgs:   []interface{}{"al%"},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:           "starts with condition escapes wildcards",
+			condition:      types.Condition{Column: "code", Type: types.ConditionTypeStartsWith, Value: "50%_off"},
+			startIndex:     1,
+			expectedClause: `"code" LIKE $1 ESCAPE '\'`,
+			expectedArgs:   []interface{}{`50\%\_off%`},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:           "ends with condition",
+			condition:      types.Condition{Column: "email", Type: types.ConditionTypeEndsWith, Value: "@test.com"},
+			startIndex:     1,
+			expectedClause: `"email" LIKE $1 ESCAPE '\'`,
+			expectedArgs:   []interface{}{"%@test.com"},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:           "contains condition",
+			condition:      types.Condition{Column: "path", Type: types.ConditionTypeContains, Value: `a\b`},
+			startIndex:     1,
+			expectedClause: `"path" LIKE $1 ESCAPE '\'`,
+			expectedArgs:   []interface{}{`%a\\b%`},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:           "regex condition",
+			condition:      types.Condition{Column: "sku", Type: types.ConditionTypeRegex, Value: "^[A-Z]{3}-"},
+			startIndex:     1,
+			expectedClause: `"sku" ~ $1`,
+			expectedArgs:   []interface{}{"^[A-Z]{3}-"},
+			expectedIndex:  2,
+			expectError:    false,
+		},
+		{
+			name:           "between condition",
+			condition:      types.Condition{Column: "total", Type: types.ConditionTypeBetween, Value: []interface{}{float64(10), float64(20)}},
+			startIndex:     3,
+			expectedClause: `"total" BETWEEN $3 AND $4`,
+			expectedArgs:   []interface{}{float64(10), float64(20)},
+			expectedIndex:  5,
+			expectError:    false,
+		},
+		{
+			name:        "between condition with one bound",
+			condition:   types.Condition{Column: "total", Type: types.ConditionTypeBetween, Value: []interface{}{float64(10)}},
+			startIndex:  1,
+			expectError: true,
+		},
+		{
+			name:        "contains condition with number",
+			condition:   types.Condition{Column: "code", Type: types.ConditionTypeContains, Value: float64(5)},
+			startIndex:  1,
+			expectError: true,
+		},
 		{
 			name:           "is null condition",
 			condition:      types.Condition{Column: "deleted_at", Type: types.ConditionTypeIsNull},
 			startIndex:     1,
-			expectedClause: "deleted_at IS NULL",
+			expectedClause: `"deleted_at" IS NULL`,
 			expectedArgs:   []interface{}{},
 			expectedIndex:  1,
 			expectError:    false,
@@ -233,7 +673,7 @@ func TestPostgresBuildCondition(t *testing.T) {
 			name:           "is not null condition",
 			condition:      types.Condition{Column: "email", Type: types.ConditionTypeIsNotNull},
 			startIndex:     5,
-			expectedClause: "email IS NOT NULL",
+			expectedClause: `"email" IS NOT NULL`,
 			expectedArgs:   []interface{}{},
 			expectedIndex:  5,
 			expectError:    false,
@@ -334,6 +774,47 @@ func TestPostgresNewEngine(t *testing.T) {
 	_ = err
 }
 
+func TestPostgresClassifyError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode types.ErrorCode
+		nativeCode   string
+	}{
+		{"unknown column", &pq.Error{Code: "42703", Message: `column "nme" does not exist`}, types.ErrorUnknownColumn, "42703"},
+		{"unknown table", &pq.Error{Code: "42P01"}, types.ErrorUnknownTable, "42P01"},
+		{"permission denied", &pq.Error{Code: "42501"}, types.ErrorPermissionDenied, "42501"},
+		{"syntax", &pq.Error{Code: "42601"}, types.ErrorSyntax, "42601"},
+		{"undefined function", &pq.Error{Code: "42883"}, types.ErrorValidation, "42883"},
+		{"read-only transaction", &pq.Error{Code: "25006"}, types.ErrorValidation, "25006"},
+		{"connection failure", &pq.Error{Code: "08006"}, types.ErrorConnection, "08006"},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, types.ErrorConnection, "57P01"},
+		{"statement timeout", &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"}, types.ErrorTimeout, "57014"},
+		{"cancelled", &pq.Error{Code: "57014", Message: "canceling statement due to user request"}, types.ErrorCancelled, "57014"},
+		{"deadline", fmt.Errorf("%w: %w", types.ErrTimeout, &pq.Error{Code: "57014", Message: "canceling statement due to user request"}), types.ErrorTimeout, "57014"},
+		{"internal", &pq.Error{Code: "XX000"}, types.ErrorInternal, "XX000"},
+		{"wrapped", fmt.Errorf("failed to execute query: %w", &pq.Error{Code: "42P01"}), types.ErrorUnknownTable, "42P01"},
+		{"validation", types.ValidationError(errors.New("invalid column")), types.ErrorValidation, ""},
+		{"context cancelled", context.Canceled, types.ErrorCancelled, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryErr := ClassifyError(tt.err)
+			if queryErr == nil {
+				t.Fatal("expected the error to be classified")
+			}
+			if queryErr.Code != tt.expectedCode || queryErr.NativeCode != tt.nativeCode {
+				t.Errorf("expected %v %q, got %v %q", tt.expectedCode, tt.nativeCode, queryErr.Code, queryErr.NativeCode)
+			}
+		})
+	}
+
+	if queryErr := ClassifyError(errors.New("unexpected")); queryErr != nil {
+		t.Errorf("unknown errors should not be classified, got %v", queryErr.Code)
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -1,8 +1,8 @@
 package loops
 
 import (
-	"log/slog"
 	"context"
+	"log/slog"
 	"time"
 
 	"starless/kadath/internal/agent"
@@ -28,6 +28,4 @@ func NewHeartBeatLoop(ctx context.Context, client *agent.Agent) error {
 			}
 		}
 	}
-
-	return nil
 }
@@ -2,56 +2,208 @@ package loops
 
 import (
 	"context"
-	"time"
+	"errors"
+	"fmt"
 	"log/slog"
+	"time"
 
+	pb "starless/kadath/gen/proto"
 	"starless/kadath/internal/agent"
+	"starless/kadath/internal/outbox"
+	"starless/kadath/internal/types"
+	"starless/kadath/internal/utils"
 )
 
+// errShuttingDown stops the jobs still running at the end of the shutdown
+// grace period
+var errShuttingDown = errors.New("agent shutting down")
 
+// reportTimeout bounds the report of a result, which must still be made
+// while the agent shuts down
+const reportTimeout = 10 * time.Second
 
 type JobHandler func(ctx context.Context, client *agent.Agent, job *agent.JobResponse) agent.JobResult
 
-func pollAndProcessJob(ctx context.Context, client *agent.Agent, handler JobHandler) error {
+// processJob runs a job and reports its result, saving it to the outbox
+// when the server cannot be reached
+func processJob(ctx context.Context, client *agent.Agent, box *outbox.Outbox, handler JobHandler, resp *agent.JobResponse) {
 	logger := slog.Default()
-	resp, err := client.GetJob(ctx)
-	if err != nil {
-		if _, ok := err.(*agent.NoJobs); ok {
-			// Ignore it. This is normal case
-			return nil
+	logger.Info("Processing job", "job_id", resp.Id, "kind", resp.Kind)
+
+	jobCtx, done := client.StartJob(ctx, resp.Id, resp.Timeout)
+	result := handler(jobCtx, client, resp)
+	done()
+
+	// A cancelled, stopped or timed out job is reported as such whatever the
+	// handler made of its interrupted query. A job that succeeded, or whose
+	// result upload completed, finished before any interruption.
+	switch {
+	case result.Success, result.Streamed:
+	case errors.Is(context.Cause(jobCtx), errShuttingDown):
+		logger.Warn("Job stopped by shutdown", "job_id", resp.Id)
+		result = shutdownResult()
+	case agent.JobCancelled(jobCtx):
+		logger.Info("Job cancelled", "job_id", resp.Id)
+		result = agent.JobResult{
+			Success:      false,
+			ResultJSON:   "{}",
+			ErrorMessage: agent.ErrJobCancelled.Error(),
+			ErrorCode:    types.ErrorCancelled,
+			Cancelled:    true,
+		}
+	case agent.JobTimedOut(jobCtx):
+		logger.Info("Job timed out", "job_id", resp.Id, "timeout", resp.Timeout)
+		result = agent.JobResult{
+			Success:      false,
+			ResultJSON:   "{}",
+			ErrorMessage: fmt.Sprintf("Job timed out after %s", resp.Timeout),
+			ErrorCode:    types.ErrorTimeout,
+			TimedOut:     true,
 		}
-		logger.Error("GetJob failed", "error", err)
-		return err
 	}
 
-	logger.Info("Processing job", "job_id", resp.Id, "kind", resp.Kind)
+	if result.Streamed {
+		logger.Info("Job completed", "job_id", resp.Id, "success", result.Success, "streamed", true)
+		return
+	}
 
-	result := handler(ctx, client, resp)
+	if reportResult(ctx, client, box, resp.Id, result) {
+		logger.Info("Job completed", "job_id", resp.Id, "success", result.Success)
+	}
+}
 
-	err = client.UpdateJob(ctx, resp.Id, result)
+// shutdownResult is the result of a job stopped, or never started, because
+// the agent shuts down
+func shutdownResult() agent.JobResult {
+	return agent.JobResult{
+		Success:      false,
+		ResultJSON:   "{}",
+		ErrorMessage: errShuttingDown.Error(),
+		ErrorCode:    types.ErrorCancelled,
+	}
+}
 
+// reportResult reports the result of a job, saving it to the outbox when
+// the server cannot be reached. It reports whether the server got it.
+func reportResult(ctx context.Context, client *agent.Agent, box *outbox.Outbox, jobID string, result agent.JobResult) bool {
+	logger := slog.Default()
+
+	req, err := client.ResultRequest(jobID, result)
 	if err != nil {
-		logger.Error("UpdateJob failed", "job_id", resp.Id, "error", err)
-		return err
+		logger.Error("Failed to encode job result, result lost", "job_id", jobID, "error", err)
+		return false
 	}
 
-	logger.Info("Job completed", "job_id", resp.Id, "success", result.Success)
+	reportCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reportTimeout)
+	defer cancel()
+	err = client.SendResult(reportCtx, req)
+
+	if err != nil {
+		logger.Error("UpdateJob failed", "job_id", jobID, "error", err)
 
+		// The outbox loop delivers the result once the server is back
+		if putErr := box.Put(req); putErr != nil {
+			logger.Error("Failed to save job result to outbox, result lost", "job_id", jobID, "error", putErr)
+		} else {
+			logger.Info("Job result saved to outbox", "job_id", jobID)
+		}
+		return false
+	}
+
+	return true
+}
+
+// pollInterval is the wait between GetJob polls when there is no job
+const pollInterval = 5 * time.Second
+
+// Delays before retrying after a failure to get a job or report its result,
+// such as while the server is unreachable
+const (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = time.Minute
+)
+
+// NewJobProcessLoop runs jobs on the workers of pool. A job is only asked
+// for once a worker is free, and only of the kinds under their limit. Once
+// ctx is done it stops asking for jobs and waits up to grace for the
+// running ones, then stops those still running and reports them as failed.
+func NewJobProcessLoop(ctx context.Context, client *agent.Agent, box *outbox.Outbox, pool *WorkerPool, grace time.Duration, handler JobHandler) error {
+	// Jobs outlive ctx until the end of the grace period
+	jobsCtx, stopJobs := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer stopJobs(nil)
+
+	dispatchJobs(ctx, jobsCtx, client, box, pool, handler)
+	drainJobs(pool, grace, stopJobs)
 	return nil
 }
 
-func NewJobProcessLoop(ctx context.Context, client *agent.Agent, handler JobHandler) error {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+// dispatchJobs gets jobs and starts them with jobsCtx until ctx is done
+func dispatchJobs(ctx, jobsCtx context.Context, client *agent.Agent, box *outbox.Outbox, pool *WorkerPool, handler JobHandler) {
+	logger := slog.Default()
+	retry := utils.Backoff{Base: retryBaseDelay, Max: retryMaxDelay}
+
+	for ctx.Err() == nil {
+		kinds := pool.WaitAvailable(ctx)
+		if kinds == nil {
+			return
+		}
+
+		job, err := client.NextJob(ctx, kinds)
+		if err != nil {
+			wait := pollInterval
+			if _, ok := err.(*agent.NoJobs); ok {
+				// Ignore it. This is normal case
+				retry.Reset()
+				if client.Streaming() {
+					continue
+				}
+			} else {
+				logger.Error("GetJob failed", "error", err)
+				wait = retry.Next()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+			continue
+		}
+		retry.Reset()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-ticker.C:
-			pollAndProcessJob(ctx, client, handler)
+		if !pool.Go(ctx, pb.JobKind(job.Kind), func() {
+			processJob(jobsCtx, client, box, handler, job)
+		}) {
+			// The server handed the job over, so it must hear that it
+			// will not run
+			logger.Warn("Agent stopping, job not started", "job_id", job.Id)
+			reportResult(jobsCtx, client, box, job.Id, shutdownResult())
+			return
 		}
 	}
+}
 
-	return nil
+// drainJobs waits for the running jobs, stopping them once grace has passed
+func drainJobs(pool *WorkerPool, grace time.Duration, stopJobs context.CancelCauseFunc) {
+	logger := slog.Default()
+
+	drained := make(chan struct{})
+	go func() {
+		pool.Wait()
+		close(drained)
+	}()
+
+	if running := pool.Running(); running > 0 {
+		logger.Info("Waiting for running jobs to finish", "running", running, "grace_period", grace)
+	}
+
+	select {
+	case <-drained:
+		return
+	case <-time.After(grace):
+	}
+
+	logger.Warn("Shutdown grace period over, stopping running jobs", "running", pool.Running())
+	stopJobs(errShuttingDown)
+	<-drained
 }
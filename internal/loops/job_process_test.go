@@ -0,0 +1,170 @@
+package loops
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"starless/kadath/configs"
+	pb "starless/kadath/gen/proto"
+	"starless/kadath/internal/agent"
+	"starless/kadath/internal/outbox"
+)
+
+// shutdownServer hands out a single schema refresh job and records the
+// results reported for it
+type shutdownServer struct {
+	pb.UnimplementedSqlRunnerServer
+	served  func()
+	results chan *pb.UpdateJobRequest
+}
+
+func (s *shutdownServer) GetJob(context.Context, *pb.GetJobRequest) (*pb.GetJobResponse, error) {
+	s.served()
+	return &pb.GetJobResponse{HasJob: true, Job: &pb.Job{Id: "job-1", Kind: pb.JobKind_JOB_KIND_SCHEMA_REFRESH}}, nil
+}
+
+func (s *shutdownServer) UpdateJob(_ context.Context, req *pb.UpdateJobRequest) (*pb.UpdateJobResponse, error) {
+	s.results <- req
+	return &pb.UpdateJobResponse{}, nil
+}
+
+// newTestClient returns an agent connected to srv and an empty outbox
+func newTestClient(t *testing.T, srv pb.SqlRunnerServer) (*agent.Agent, *outbox.Outbox) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterSqlRunnerServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	cfg := &configs.Config{ServerAddrs: []string{lis.Addr().String()}, ServerInsecure: true}
+	client, err := agent.NewAgent(context.Background(), cfg, testKinds, slog.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	box, err := outbox.Open(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client, box
+}
+
+func TestProcessJobCancelledAfterSuccess(t *testing.T) {
+	srv := &shutdownServer{results: make(chan *pb.UpdateJobRequest, 1)}
+	client, box := newTestClient(t, srv)
+
+	// The server cancels the job once the handler is done with it
+	handler := func(_ context.Context, client *agent.Agent, job *agent.JobResponse) agent.JobResult {
+		client.CancelJob(job.Id)
+		return agent.JobResult{Success: true, ResultJSON: `{"row_count":0}`}
+	}
+	processJob(context.Background(), client, box, handler, &agent.JobResponse{Id: "job-1", Kind: int32(pb.JobKind_JOB_KIND_QUERY)})
+
+	result := <-srv.results
+	if !result.Success || result.ErrorCode != pb.ErrorCode_ERROR_CODE_UNSPECIFIED {
+		t.Errorf("expected the finished job to be reported as a success, got %v", result)
+	}
+
+	// A failure caused by the cancellation is reported as one
+	handler = func(ctx context.Context, client *agent.Agent, job *agent.JobResponse) agent.JobResult {
+		client.CancelJob(job.Id)
+		return agent.JobResult{Success: false, ResultJSON: "{}", ErrorMessage: ctx.Err().Error()}
+	}
+	processJob(context.Background(), client, box, handler, &agent.JobResponse{Id: "job-2", Kind: int32(pb.JobKind_JOB_KIND_QUERY)})
+
+	result = <-srv.results
+	if result.Success || result.ErrorCode != pb.ErrorCode_ERROR_CODE_CANCELLED {
+		t.Errorf("expected the job to be reported as cancelled, got %v", result)
+	}
+}
+
+func TestDrainJobs(t *testing.T) {
+	pool := NewWorkerPool(testKinds, 2, nil)
+	jobsCtx, stopJobs := context.WithCancelCause(context.Background())
+	defer stopJobs(nil)
+
+	// One job finishes within the grace period, the other one only stops
+	// when told to
+	quick := make(chan struct{})
+	pool.Go(jobsCtx, pb.JobKind_JOB_KIND_PING, func() {
+		time.Sleep(10 * time.Millisecond)
+		close(quick)
+	})
+	var cause error
+	pool.Go(jobsCtx, pb.JobKind_JOB_KIND_QUERY, func() {
+		<-jobsCtx.Done()
+		cause = context.Cause(jobsCtx)
+	})
+
+	drainJobs(pool, 100*time.Millisecond, stopJobs)
+
+	select {
+	case <-quick:
+	default:
+		t.Error("expected the quick job to finish")
+	}
+	if !errors.Is(cause, errShuttingDown) {
+		t.Errorf("expected the running job to be stopped by shutdown, got %v", cause)
+	}
+	if pool.Running() != 0 {
+		t.Errorf("expected no running job, got %d", pool.Running())
+	}
+}
+
+func TestDrainJobsWithinGracePeriod(t *testing.T) {
+	pool := NewWorkerPool(testKinds, 1, nil)
+	jobsCtx, stopJobs := context.WithCancelCause(context.Background())
+	defer stopJobs(nil)
+
+	pool.Go(jobsCtx, pb.JobKind_JOB_KIND_QUERY, func() { time.Sleep(10 * time.Millisecond) })
+	drainJobs(pool, time.Minute, stopJobs)
+
+	if jobsCtx.Err() != nil {
+		t.Error("jobs finishing in time should not be stopped")
+	}
+}
+
+func TestDispatchJobsReportsJobNotStarted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The agent stops while the job waits for a worker
+	srv := &shutdownServer{
+		served:  func() { time.AfterFunc(50*time.Millisecond, cancel) },
+		results: make(chan *pb.UpdateJobRequest, 1),
+	}
+	client, box := newTestClient(t, srv)
+
+	// The server sends a schema refresh although its only slot is taken
+	pool := NewWorkerPool(testKinds, 2, map[pb.JobKind]int{pb.JobKind_JOB_KIND_SCHEMA_REFRESH: 1})
+	release := make(chan struct{})
+	pool.Go(ctx, pb.JobKind_JOB_KIND_SCHEMA_REFRESH, func() { <-release })
+	defer pool.Wait()
+	defer close(release)
+
+	handler := func(context.Context, *agent.Agent, *agent.JobResponse) agent.JobResult {
+		t.Error("job should not run")
+		return agent.JobResult{}
+	}
+	dispatchJobs(ctx, context.Background(), client, box, pool, handler)
+
+	select {
+	case result := <-srv.results:
+		if result.JobId != "job-1" || result.Success || result.ErrorMessage != errShuttingDown.Error() ||
+			result.ErrorCode != pb.ErrorCode_ERROR_CODE_CANCELLED {
+			t.Errorf("unexpected result: %v", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the job to be reported as failed")
+	}
+}
@@ -0,0 +1,75 @@
+package loops
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"starless/kadath/internal/agent"
+	"starless/kadath/internal/outbox"
+	"starless/kadath/internal/utils"
+)
+
+// outboxInterval is the wait between checks of the outbox once it was
+// emptied
+const outboxInterval = 10 * time.Second
+
+// deliverOutbox reports the results in the outbox, oldest first, stopping at
+// the first one the server could not be reached for
+func deliverOutbox(ctx context.Context, client *agent.Agent, box *outbox.Outbox) error {
+	logger := slog.Default()
+
+	entries, err := box.Pending()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err := client.SendResult(ctx, e.Request)
+		switch status.Code(err) {
+		case codes.OK:
+			logger.Info("Delivered job result from outbox", "job_id", e.JobID, "success", e.Request.Success)
+		case codes.NotFound, codes.InvalidArgument, codes.FailedPrecondition:
+			// The server will never accept it, such as for a job it gave
+			// up on
+			logger.Warn("Job result rejected, dropping it from outbox", "job_id", e.JobID, "error", err)
+		default:
+			return err
+		}
+
+		if err := box.Remove(e.JobID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewOutboxLoop delivers the results saved in the outbox, starting with the
+// ones left over from a previous run
+func NewOutboxLoop(ctx context.Context, client *agent.Agent, box *outbox.Outbox) error {
+	logger := slog.Default()
+	retry := utils.Backoff{Base: retryBaseDelay, Max: retryMaxDelay}
+
+	for {
+		wait := outboxInterval
+		if err := deliverOutbox(ctx, client, box); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			wait = retry.Next()
+			logger.Warn("Outbox delivery failed, retrying", "error", err, "retry_in", wait)
+		} else {
+			retry.Reset()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package loops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	pb "starless/kadath/gen/proto"
+)
+
+// WorkerPool bounds the number of jobs run at once, overall and per kind.
+// Kinds without a limit only share the overall one.
+type WorkerPool struct {
+	kinds  []pb.JobKind
+	max    int
+	limits map[pb.JobKind]int
+
+	mu      sync.Mutex
+	running int
+	byKind  map[pb.JobKind]int
+
+	// freed is signalled when a job finishes
+	freed chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewWorkerPool creates a pool running up to max jobs of kinds at once
+func NewWorkerPool(kinds []pb.JobKind, max int, limits map[pb.JobKind]int) *WorkerPool {
+	if max < 1 {
+		max = 1
+	}
+	return &WorkerPool{
+		kinds:  kinds,
+		max:    max,
+		limits: limits,
+		byKind: make(map[pb.JobKind]int),
+		freed:  make(chan struct{}, 1),
+	}
+}
+
+// ParseKindLimits converts per-kind limits keyed by job kind name, with or
+// without the JOB_KIND_ prefix
+func ParseKindLimits(limits map[string]int) (map[pb.JobKind]int, error) {
+	parsed := make(map[pb.JobKind]int, len(limits))
+	for name, limit := range limits {
+		name = strings.ToUpper(name)
+		if !strings.HasPrefix(name, "JOB_KIND_") {
+			name = "JOB_KIND_" + name
+		}
+		kind, ok := pb.JobKind_value[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown job kind: %s", name)
+		}
+		if limit < 1 {
+			return nil, fmt.Errorf("invalid limit %d for %s", limit, name)
+		}
+		parsed[pb.JobKind(kind)] = limit
+	}
+	return parsed, nil
+}
+
+// Available returns the kinds a job can be started for right now, none when
+// every worker is busy
+func (p *WorkerPool) Available() []pb.JobKind {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running >= p.max {
+		return nil
+	}
+
+	var kinds []pb.JobKind
+	for _, kind := range p.kinds {
+		if limit, ok := p.limits[kind]; ok && p.byKind[kind] >= limit {
+			continue
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// WaitAvailable waits for a job to be startable and returns the kinds it
+// may be of. It returns nil once ctx is done.
+func (p *WorkerPool) WaitAvailable(ctx context.Context) []pb.JobKind {
+	for {
+		if kinds := p.Available(); len(kinds) > 0 {
+			return kinds
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-p.freed:
+		}
+	}
+}
+
+// Go runs fn for a job of kind on a new worker. It waits for a free slot,
+// which is only needed when the server sends a job that was not asked for,
+// and reports false when ctx is done first.
+func (p *WorkerPool) Go(ctx context.Context, kind pb.JobKind, fn func()) bool {
+	for !p.acquire(kind) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-p.freed:
+		}
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer p.release(kind)
+		fn()
+	}()
+	return true
+}
+
+// Running returns the number of running jobs
+func (p *WorkerPool) Running() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running
+}
+
+// Wait waits for the running jobs to finish
+func (p *WorkerPool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *WorkerPool) acquire(kind pb.JobKind) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running >= p.max {
+		return false
+	}
+	if limit, ok := p.limits[kind]; ok && p.byKind[kind] >= limit {
+		return false
+	}
+	p.running++
+	p.byKind[kind]++
+	return true
+}
+
+func (p *WorkerPool) release(kind pb.JobKind) {
+	p.mu.Lock()
+	p.running--
+	p.byKind[kind]--
+	p.mu.Unlock()
+
+	select {
+	case p.freed <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,92 @@
+package loops
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	pb "starless/kadath/gen/proto"
+)
+
+var testKinds = []pb.JobKind{
+	pb.JobKind_JOB_KIND_PING,
+	pb.JobKind_JOB_KIND_QUERY,
+	pb.JobKind_JOB_KIND_SCHEMA_REFRESH,
+}
+
+func TestWorkerPoolLimits(t *testing.T) {
+	pool := NewWorkerPool(testKinds, 2, map[pb.JobKind]int{pb.JobKind_JOB_KIND_SCHEMA_REFRESH: 1})
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	defer close(release)
+	block := func() { <-release }
+
+	if !slices.Equal(pool.Available(), testKinds) {
+		t.Fatalf("unexpected kinds: %v", pool.Available())
+	}
+
+	pool.Go(ctx, pb.JobKind_JOB_KIND_SCHEMA_REFRESH, block)
+	kinds := pool.Available()
+	if !slices.Equal(kinds, []pb.JobKind{pb.JobKind_JOB_KIND_PING, pb.JobKind_JOB_KIND_QUERY}) {
+		t.Errorf("expected schema refreshes to be at their limit, got %v", kinds)
+	}
+
+	pool.Go(ctx, pb.JobKind_JOB_KIND_QUERY, block)
+	if kinds := pool.Available(); kinds != nil {
+		t.Errorf("expected no free worker, got %v", kinds)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if kinds := pool.WaitAvailable(waitCtx); kinds != nil {
+		t.Errorf("expected no free worker, got %v", kinds)
+	}
+	if pool.Go(waitCtx, pb.JobKind_JOB_KIND_PING, block) {
+		t.Error("expected the job not to start without a free worker")
+	}
+}
+
+func TestWorkerPoolWaitAvailable(t *testing.T) {
+	pool := NewWorkerPool(testKinds, 1, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	release := make(chan struct{})
+	pool.Go(ctx, pb.JobKind_JOB_KIND_QUERY, func() { <-release })
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+
+	if kinds := pool.WaitAvailable(ctx); len(kinds) != len(testKinds) {
+		t.Errorf("expected a free worker once the job finished, got %v", kinds)
+	}
+
+	done := false
+	pool.Go(ctx, pb.JobKind_JOB_KIND_PING, func() { done = true })
+	pool.Wait()
+	if !done {
+		t.Error("expected Wait to wait for the running job")
+	}
+}
+
+func TestParseKindLimits(t *testing.T) {
+	limits, err := ParseKindLimits(map[string]int{"schema_refresh": 1, "JOB_KIND_QUERY": 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limits[pb.JobKind_JOB_KIND_SCHEMA_REFRESH] != 1 || limits[pb.JobKind_JOB_KIND_QUERY] != 3 {
+		t.Errorf("unexpected limits: %v", limits)
+	}
+
+	if _, err := ParseKindLimits(map[string]int{"VACUUM": 1}); err == nil {
+		t.Error("expected error for an unknown kind")
+	}
+	if _, err := ParseKindLimits(map[string]int{"PING": 0}); err == nil {
+		t.Error("expected error for a zero limit")
+	}
+}
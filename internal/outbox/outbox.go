@@ -0,0 +1,238 @@
+// Package outbox keeps the results of jobs that could not be reported to the
+// server on disk until they are delivered
+package outbox
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "starless/kadath/gen/proto"
+)
+
+const (
+	entrySuffix = ".json"
+	tempPattern = ".tmp-*"
+)
+
+// Entry is a job result waiting to be delivered
+type Entry struct {
+	JobID     string
+	Request   *pb.UpdateJobRequest
+	CreatedAt time.Time
+
+	size int64
+}
+
+// entryFile is the content of the file of an entry. The request is kept in
+// its protobuf encoding, which JSON cannot hold without losing typed values.
+type entryFile struct {
+	JobID     string    `json:"job_id"`
+	Request   []byte    `json:"request"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Outbox stores one file per job in a directory, so a result saved again for
+// the same job replaces the previous one. Entries older than MaxAge are
+// dropped, as are the oldest entries once the files exceed MaxBytes. A zero
+// limit disables it.
+type Outbox struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+
+	mu sync.Mutex
+}
+
+// Open opens the outbox in dir, creating the directory if needed
+func Open(dir string, maxBytes int64, maxAge time.Duration) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	// Leftovers of writes interrupted by a crash
+	temps, err := filepath.Glob(filepath.Join(dir, tempPattern))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox: %w", err)
+	}
+	for _, name := range temps {
+		os.Remove(name)
+	}
+
+	return &Outbox{dir: dir, maxBytes: maxBytes, maxAge: maxAge}, nil
+}
+
+// Put saves the result of a job, replacing any result saved for it before
+func (o *Outbox) Put(req *pb.UpdateJobRequest) error {
+	request, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode job result: %w", err)
+	}
+	data, err := json.Marshal(entryFile{JobID: req.JobId, Request: request, CreatedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox entry: %w", err)
+	}
+	if o.maxBytes > 0 && int64(len(data)) > o.maxBytes {
+		return fmt.Errorf("result of %d bytes exceeds the outbox limit of %d bytes", len(data), o.maxBytes)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.write(o.path(req.JobId), data); err != nil {
+		return err
+	}
+	_, err = o.prune()
+	return err
+}
+
+// write replaces the file at path with data through a rename, so a crash
+// never leaves a partial entry behind
+func (o *Outbox) write(path string, data []byte) error {
+	f, err := os.CreateTemp(o.dir, tempPattern)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox entry: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write outbox entry: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write outbox entry: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write outbox entry: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to save outbox entry: %w", err)
+	}
+	return nil
+}
+
+// Pending returns the entries waiting for delivery, oldest first, after
+// dropping the ones past the limits
+func (o *Outbox) Pending() ([]Entry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.prune()
+}
+
+// Remove deletes the entry of a delivered job
+func (o *Outbox) Remove(jobID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := os.Remove(o.path(jobID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove outbox entry: %w", err)
+	}
+	return nil
+}
+
+// prune drops the entries past the limits and returns the remaining ones,
+// oldest first
+func (o *Outbox) prune() ([]Entry, error) {
+	entries, err := o.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+
+	kept := entries[:0]
+	for _, e := range entries {
+		switch {
+		case o.maxAge > 0 && time.Since(e.CreatedAt) > o.maxAge:
+			slog.Default().Warn("Dropping expired job result from outbox", "job_id", e.JobID, "created_at", e.CreatedAt)
+		case o.maxBytes > 0 && total > o.maxBytes:
+			slog.Default().Warn("Dropping job result from full outbox", "job_id", e.JobID, "created_at", e.CreatedAt)
+		default:
+			kept = append(kept, e)
+			continue
+		}
+
+		if err := os.Remove(o.path(e.JobID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove outbox entry: %w", err)
+		}
+		total -= e.size
+	}
+
+	return kept, nil
+}
+
+// load reads all entries, oldest first. Unreadable entries are removed.
+func (o *Outbox) load() ([]Entry, error) {
+	files, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox: %w", err)
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), entrySuffix) {
+			continue
+		}
+
+		path := filepath.Join(o.dir, file.Name())
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read outbox entry: %w", err)
+		}
+
+		e, err := decodeEntry(data)
+		if err != nil {
+			slog.Default().Warn("Removing invalid outbox entry", "file", file.Name(), "error", err)
+			os.Remove(path)
+			continue
+		}
+		e.size = int64(len(data))
+		entries = append(entries, e)
+	}
+
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return entries, nil
+}
+
+func decodeEntry(data []byte) (Entry, error) {
+	var f entryFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Entry{}, err
+	}
+	if f.JobID == "" {
+		return Entry{}, fmt.Errorf("entry has no job ID")
+	}
+
+	req := &pb.UpdateJobRequest{}
+	if err := proto.Unmarshal(f.Request, req); err != nil {
+		return Entry{}, fmt.Errorf("failed to decode job result: %w", err)
+	}
+	return Entry{JobID: f.JobID, Request: req, CreatedAt: f.CreatedAt}, nil
+}
+
+// path returns the file of the entry of a job. Job IDs are encoded so that
+// any ID makes a valid file name.
+func (o *Outbox) path(jobID string) string {
+	return filepath.Join(o.dir, base64.RawURLEncoding.EncodeToString([]byte(jobID))+entrySuffix)
+}
@@ -0,0 +1,216 @@
+package outbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "starless/kadath/gen/proto"
+)
+
+func pendingIDs(t *testing.T, o *Outbox) []string {
+	t.Helper()
+
+	entries, err := o.Pending()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.JobID
+	}
+	return ids
+}
+
+func TestOutboxPersistsAcrossOpen(t *testing.T) {
+	dir := t.TempDir()
+
+	o, err := Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := &pb.UpdateJobRequest{JobId: "job/1", Success: true, ResultJson: `{"rows":[]}`}
+	if err := o.Put(result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := o.Put(&pb.UpdateJobRequest{JobId: "job-2", ErrorMessage: "failed", TimedOut: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A write interrupted by a crash
+	if err := os.WriteFile(filepath.Join(dir, ".tmp-123"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err = Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err := o.Pending()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].JobID != "job/1" || !proto.Equal(entries[0].Request, result) {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if !entries[1].Request.TimedOut {
+		t.Errorf("unexpected entry: %+v", entries[1])
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".tmp-123")); !os.IsNotExist(err) {
+		t.Error("expected temporary file to be removed")
+	}
+
+	if err := o.Remove("job/1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := o.Remove("job/1"); err != nil {
+		t.Fatalf("removing a missing entry should not fail: %v", err)
+	}
+	if ids := pendingIDs(t, o); len(ids) != 1 || ids[0] != "job-2" {
+		t.Errorf("unexpected entries: %v", ids)
+	}
+}
+
+func TestOutboxKeepsTypedResults(t *testing.T) {
+	dir := t.TempDir()
+	o, err := Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Beyond the integers a float64 holds exactly
+	const large = int64(1)<<53 + 1
+	row, err := structpb.NewList([]interface{}{"9007199254740993", "widget"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &pb.UpdateJobRequest{
+		JobId:   "job-1",
+		Success: true,
+		Result: &pb.UpdateJobRequest_ColumnarResult{ColumnarResult: &pb.ColumnarResult{
+			Columns: []*pb.ResultColumn{
+				{Name: "id", NativeType: "INT8", LogicalType: "int"},
+				{Name: "name", NativeType: "TEXT", LogicalType: "string"},
+			},
+			Rows:     []*structpb.ListValue{row},
+			RowCount: large,
+		}},
+	}
+	if err := o.Put(result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	o, err = Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err := o.Pending()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if !proto.Equal(entries[0].Request, result) {
+		t.Errorf("result changed through the outbox:\nexpected: %v\ngot:      %v", result, entries[0].Request)
+	}
+	if got := entries[0].Request.GetColumnarResult().GetRowCount(); got != large {
+		t.Errorf("expected row count %d, got %d", large, got)
+	}
+}
+
+func TestOutboxDeduplicatesByJobID(t *testing.T) {
+	o, err := Open(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	o.Put(&pb.UpdateJobRequest{JobId: "job-1", ErrorMessage: "first"})
+	o.Put(&pb.UpdateJobRequest{JobId: "job-1", ErrorMessage: "second"})
+
+	entries, err := o.Pending()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Request.ErrorMessage != "second" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestOutboxMaxBytes(t *testing.T) {
+	result := func(id string) *pb.UpdateJobRequest {
+		return &pb.UpdateJobRequest{JobId: id, Success: true, ResultJson: `{"value":"0123456789"}`}
+	}
+
+	// Room for two entries
+	probe, err := Open(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	probe.Put(result("job-1"))
+	entries, _ := probe.Pending()
+	size := entries[0].size
+
+	o, err := Open(t.TempDir(), 2*size+size/2, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		if err := o.Put(result(id)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ids := pendingIDs(t, o)
+	if len(ids) != 2 || ids[0] != "job-2" || ids[1] != "job-3" {
+		t.Errorf("expected the oldest entry to be dropped, got %v", ids)
+	}
+
+	large := &pb.UpdateJobRequest{JobId: "job-4", Success: true, ResultJson: string(make([]byte, 4*size))}
+	if err := o.Put(large); err == nil {
+		t.Error("expected error for a result larger than the outbox")
+	}
+}
+
+func TestOutboxMaxAge(t *testing.T) {
+	o, err := Open(t.TempDir(), 0, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	o.Put(&pb.UpdateJobRequest{JobId: "job-1"})
+	time.Sleep(100 * time.Millisecond)
+	o.Put(&pb.UpdateJobRequest{JobId: "job-2"})
+
+	if ids := pendingIDs(t, o); len(ids) != 1 || ids[0] != "job-2" {
+		t.Errorf("expected the expired entry to be dropped, got %v", ids)
+	}
+}
+
+func TestOutboxRemovesInvalidEntries(t *testing.T) {
+	dir := t.TempDir()
+	o, err := Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	o.Put(&pb.UpdateJobRequest{JobId: "job-1"})
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := pendingIDs(t, o); len(ids) != 1 || ids[0] != "job-1" {
+		t.Errorf("unexpected entries: %v", ids)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "broken.json")); !os.IsNotExist(err) {
+		t.Error("expected invalid entry to be removed")
+	}
+}
@@ -0,0 +1,249 @@
+// Package sqlguard classifies hand-written SQL so the agent can refuse
+// anything other than a single read-only statement before it reaches the
+// database.
+package sqlguard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StatementKind defines the broad category of a SQL statement
+type StatementKind string
+
+const (
+	StatementKindRead  StatementKind = "read"
+	StatementKindDML   StatementKind = "dml"
+	StatementKindDDL   StatementKind = "ddl"
+	StatementKindOther StatementKind = "other"
+)
+
+var readKeywords = map[string]bool{
+	"SELECT": true,
+	"WITH":   true,
+	"VALUES": true,
+	"TABLE":  true,
+}
+
+var dmlKeywords = map[string]bool{
+	"INSERT":  true,
+	"UPDATE":  true,
+	"DELETE":  true,
+	"MERGE":   true,
+	"REPLACE": true,
+	"UPSERT":  true,
+	"COPY":    true,
+	"LOAD":    true,
+	"CALL":    true,
+}
+
+var ddlKeywords = map[string]bool{
+	"CREATE":   true,
+	"ALTER":    true,
+	"DROP":     true,
+	"TRUNCATE": true,
+	"RENAME":   true,
+	"COMMENT":  true,
+	"GRANT":    true,
+	"REVOKE":   true,
+}
+
+// writeKeywords may appear inside an otherwise read-only statement, such as
+// a data-modifying CTE in Postgres
+var writeKeywords = map[string]bool{
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"MERGE":  true,
+}
+
+// Statement is the result of classifying a single SQL statement
+type Statement struct {
+	Kind StatementKind
+	// Keyword is the leading keyword of the statement, upper cased
+	Keyword string
+	// SelectInto is set when the statement writes its result with INTO
+	SelectInto bool
+	// Locking is set when the statement takes row locks (FOR UPDATE and friends)
+	Locking bool
+	// ParamCount is the number of positional parameters the statement expects
+	ParamCount int
+}
+
+// Classify tokenizes sql and classifies it. Exactly one statement is
+// accepted; a single trailing semicolon is allowed.
+func Classify(sql string, dialect Dialect) (*Statement, error) {
+	tokens, err := Tokenize(sql, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(tokens) > 0 && tokens[len(tokens)-1].IsPunct(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty statement")
+	}
+
+	stmt := &Statement{Kind: StatementKindOther}
+	maxParam := 0
+
+	for i, tok := range tokens {
+		switch tok.Kind {
+		case TokenPunct:
+			if tok.Text == ";" {
+				return nil, fmt.Errorf("multiple statements are not allowed")
+			}
+			continue
+		case TokenParam:
+			if dialect == DialectMySQL {
+				stmt.ParamCount++
+			} else if n, err := strconv.Atoi(tok.Text[1:]); err == nil && n > maxParam {
+				maxParam = n
+			}
+			continue
+		case TokenWord:
+		default:
+			continue
+		}
+
+		word := strings.ToUpper(tok.Text)
+		if stmt.Keyword == "" {
+			stmt.Keyword = word
+			switch {
+			case readKeywords[word]:
+				stmt.Kind = StatementKindRead
+			case dmlKeywords[word]:
+				stmt.Kind = StatementKindDML
+			case ddlKeywords[word]:
+				stmt.Kind = StatementKindDDL
+			}
+			continue
+		}
+
+		if !isKeywordPosition(tokens, i) {
+			continue
+		}
+
+		switch {
+		case word == "INTO":
+			stmt.SelectInto = true
+		case word == "FOR" && i+1 < len(tokens) && isLockStrength(tokens[i+1]):
+			stmt.Locking = true
+		case word == "LOCK" && i+1 < len(tokens) && tokens[i+1].IsWord("IN"):
+			stmt.Locking = true
+		case writeKeywords[word] && !tokens[i-1].IsWord("FOR") && !tokens[i-1].IsWord("KEY"):
+			if stmt.Kind == StatementKindRead {
+				stmt.Kind = StatementKindDML
+			}
+		}
+	}
+
+	if dialect == DialectPostgres {
+		stmt.ParamCount = maxParam
+	}
+
+	if stmt.Keyword == "" {
+		return nil, fmt.Errorf("statement has no leading keyword")
+	}
+
+	return stmt, nil
+}
+
+// isKeywordPosition filters out words used as identifiers: qualified names
+// (t.update), aliases (AS delete) and function calls (replace(...)).
+func isKeywordPosition(tokens []Token, i int) bool {
+	if i > 0 && (tokens[i-1].IsPunct(".") || tokens[i-1].IsWord("AS")) {
+		return false
+	}
+	if i+1 < len(tokens) && (tokens[i+1].IsPunct(".") || tokens[i+1].IsPunct("(")) {
+		return false
+	}
+	return true
+}
+
+func isLockStrength(tok Token) bool {
+	return tok.IsWord("UPDATE") || tok.IsWord("SHARE") || tok.IsWord("NO") || tok.IsWord("KEY")
+}
+
+// ValidateReadOnly returns an error unless sql is a single read statement
+// without INTO or locking clauses. It returns the classified statement so
+// callers can check the parameter count.
+func ValidateReadOnly(sql string, dialect Dialect) (*Statement, error) {
+	stmt, err := Classify(sql, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	switch stmt.Kind {
+	case StatementKindRead:
+	case StatementKindDML:
+		return nil, fmt.Errorf("data modifying statements are not allowed")
+	case StatementKindDDL:
+		return nil, fmt.Errorf("DDL statements are not allowed")
+	default:
+		return nil, fmt.Errorf("only read statements are allowed, got %s", stmt.Keyword)
+	}
+
+	if stmt.SelectInto {
+		return nil, fmt.Errorf("SELECT ... INTO is not allowed")
+	}
+	if stmt.Locking {
+		return nil, fmt.Errorf("locking clauses are not allowed")
+	}
+
+	return stmt, nil
+}
+
+// ValidateSelectList returns an error unless list can be pasted into a
+// query as its select list: read-only expressions without comments,
+// semicolons, parameters or unbalanced parentheses. Unterminated quotes
+// are rejected by Tokenize.
+func ValidateSelectList(list string, dialect Dialect) error {
+	tokens, err := Tokenize(list, dialect)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("empty select list")
+	}
+
+	depth := 0
+	end := 0
+	for _, tok := range tokens {
+		// Comments are the only thing skipped between tokens besides
+		// whitespace and string prefixes
+		if isComment(list[end:tok.Pos]) {
+			return fmt.Errorf("comments are not allowed")
+		}
+		end = tok.Pos + len(tok.Text)
+
+		switch {
+		case tok.IsPunct(";"):
+			return fmt.Errorf("semicolons are not allowed")
+		case tok.Kind == TokenParam:
+			return fmt.Errorf("parameters are not allowed")
+		case tok.IsPunct("("):
+			depth++
+		case tok.IsPunct(")"):
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced parenthesis at position %d", tok.Pos)
+			}
+		}
+	}
+	if isComment(list[end:]) {
+		return fmt.Errorf("comments are not allowed")
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses")
+	}
+
+	_, err = ValidateReadOnly("SELECT "+list, dialect)
+	return err
+}
+
+func isComment(gap string) bool {
+	return strings.Contains(gap, "--") || strings.Contains(gap, "/*") || strings.Contains(gap, "#")
+}
@@ -0,0 +1,132 @@
+package sqlguard
+
+import "testing"
+
+func TestValidateReadOnly(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		dialect     Dialect
+		expectError bool
+		paramCount  int
+	}{
+		{name: "simple select", sql: "SELECT * FROM users", dialect: DialectPostgres},
+		{name: "trailing semicolon", sql: "SELECT 1;", dialect: DialectMySQL},
+		{name: "parenthesized select", sql: "(SELECT 1) UNION (SELECT 2)", dialect: DialectPostgres},
+		{name: "cte", sql: "WITH t AS (SELECT 1 AS x) SELECT x FROM t", dialect: DialectPostgres},
+		{name: "values", sql: "VALUES (1), (2)", dialect: DialectPostgres},
+		{name: "postgres params", sql: "SELECT * FROM t WHERE a = $2 AND b = $1", dialect: DialectPostgres, paramCount: 2},
+		{name: "mysql params", sql: "SELECT * FROM t WHERE a = ? AND b = ?", dialect: DialectMySQL, paramCount: 2},
+		{name: "keyword in string", sql: "SELECT 'DELETE FROM t; DROP TABLE t' AS msg", dialect: DialectPostgres},
+		{name: "keyword in comment", sql: "SELECT 1 -- ; DELETE FROM t", dialect: DialectPostgres},
+		{name: "keyword as quoted identifier", sql: `SELECT "update", "into" FROM t`, dialect: DialectPostgres},
+		{name: "keyword as qualified column", sql: "SELECT t.update FROM t", dialect: DialectPostgres},
+		{name: "replace function", sql: "SELECT REPLACE(name, 'a', 'b') FROM t", dialect: DialectMySQL},
+		{name: "substring for", sql: "SELECT substring(name FROM 1 FOR 3) FROM t", dialect: DialectPostgres},
+
+		{name: "empty", sql: "  -- nothing\n", dialect: DialectPostgres, expectError: true},
+		{name: "insert", sql: "INSERT INTO t VALUES (1)", dialect: DialectPostgres, expectError: true},
+		{name: "update", sql: "update t set a = 1", dialect: DialectMySQL, expectError: true},
+		{name: "drop", sql: "DROP TABLE users", dialect: DialectPostgres, expectError: true},
+		{name: "create as", sql: "CREATE TABLE x AS SELECT 1", dialect: DialectMySQL, expectError: true},
+		{name: "set", sql: "SET search_path = evil", dialect: DialectPostgres, expectError: true},
+		{name: "multiple statements", sql: "SELECT 1; SELECT 2", dialect: DialectPostgres, expectError: true},
+		{name: "stacked drop", sql: "SELECT 1; DROP TABLE users;", dialect: DialectMySQL, expectError: true},
+		{name: "select into", sql: "SELECT * INTO backup FROM users", dialect: DialectPostgres, expectError: true},
+		{name: "into outfile", sql: "SELECT * FROM users INTO OUTFILE '/tmp/x'", dialect: DialectMySQL, expectError: true},
+		{name: "for update", sql: "SELECT * FROM users FOR UPDATE", dialect: DialectPostgres, expectError: true},
+		{name: "for no key update", sql: "SELECT * FROM users FOR NO KEY UPDATE", dialect: DialectPostgres, expectError: true},
+		{name: "for share", sql: "SELECT * FROM users FOR SHARE", dialect: DialectMySQL, expectError: true},
+		{name: "lock in share mode", sql: "SELECT * FROM users LOCK IN SHARE MODE", dialect: DialectMySQL, expectError: true},
+		{name: "data modifying cte", sql: "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", dialect: DialectPostgres, expectError: true},
+		{name: "statement hidden after dollar quote", sql: "SELECT $$a$$; DELETE FROM t", dialect: DialectPostgres, expectError: true},
+		{name: "mysql hash comment does not hide statement", sql: "SELECT 1 #\n; DELETE FROM t", dialect: DialectMySQL, expectError: true},
+		{name: "mysql executable comment", sql: "SELECT 1 /*!50000 INTO OUTFILE '/tmp/x' */", dialect: DialectMySQL, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := ValidateReadOnly(tt.sql, tt.dialect)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got statement %+v", stmt)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if stmt.ParamCount != tt.paramCount {
+				t.Errorf("param count mismatch: expected %d, got %d", tt.paramCount, stmt.ParamCount)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		sql     string
+		kind    StatementKind
+		keyword string
+	}{
+		{sql: "select 1", kind: StatementKindRead, keyword: "SELECT"},
+		{sql: "DELETE FROM t", kind: StatementKindDML, keyword: "DELETE"},
+		{sql: "WITH x AS (UPDATE t SET a = 1 RETURNING a) SELECT * FROM x", kind: StatementKindDML, keyword: "WITH"},
+		{sql: "ALTER TABLE t ADD COLUMN c int", kind: StatementKindDDL, keyword: "ALTER"},
+		{sql: "VACUUM t", kind: StatementKindOther, keyword: "VACUUM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			stmt, err := Classify(tt.sql, DialectPostgres)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stmt.Kind != tt.kind || stmt.Keyword != tt.keyword {
+				t.Errorf("expected %s/%s, got %s/%s", tt.kind, tt.keyword, stmt.Kind, stmt.Keyword)
+			}
+		})
+	}
+}
+
+func TestValidateSelectList(t *testing.T) {
+	tests := []struct {
+		name        string
+		list        string
+		dialect     Dialect
+		expectError bool
+	}{
+		{name: "columns", list: "id, name", dialect: DialectPostgres},
+		{name: "function with commas", list: "COALESCE(nickname, name) AS display", dialect: DialectMySQL},
+		{name: "subquery", list: "(SELECT count(*) FROM t) AS n", dialect: DialectPostgres},
+		{name: "comment marker in string", list: "'--' AS dashes, E'/*' AS star", dialect: DialectPostgres},
+		{name: "mysql double minus", list: "a--1", dialect: DialectMySQL},
+
+		{name: "empty", list: " ", dialect: DialectPostgres, expectError: true},
+		{name: "line comment", list: "pg_read_file('x') --", dialect: DialectPostgres, expectError: true},
+		{name: "block comment", list: "id /* FROM users */", dialect: DialectPostgres, expectError: true},
+		{name: "mysql hash comment", list: "id #", dialect: DialectMySQL, expectError: true},
+		{name: "semicolon", list: "1; DELETE FROM users", dialect: DialectMySQL, expectError: true},
+		{name: "closing parenthesis", list: "id) FROM users WHERE (true", dialect: DialectPostgres, expectError: true},
+		{name: "opening parenthesis", list: "COALESCE(nickname, name", dialect: DialectPostgres, expectError: true},
+		{name: "unterminated quote", list: "'abc", dialect: DialectMySQL, expectError: true},
+		{name: "parameter", list: "id = $1", dialect: DialectPostgres, expectError: true},
+		{name: "into", list: "* INTO copy", dialect: DialectPostgres, expectError: true},
+		{name: "locking", list: "* FROM users FOR UPDATE", dialect: DialectPostgres, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSelectList(tt.list, tt.dialect)
+			if tt.expectError && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
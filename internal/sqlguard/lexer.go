@@ -0,0 +1,306 @@
+package sqlguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect selects the lexical rules used when tokenizing SQL
+type Dialect int
+
+const (
+	DialectPostgres Dialect = iota
+	DialectMySQL
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case DialectPostgres:
+		return "postgres"
+	case DialectMySQL:
+		return "mysql"
+	default:
+		return fmt.Sprintf("dialect(%d)", int(d))
+	}
+}
+
+// TokenKind defines the lexical class of a token
+type TokenKind int
+
+const (
+	// TokenWord is an unquoted keyword or identifier
+	TokenWord TokenKind = iota
+	// TokenQuotedIdent is a double quoted (Postgres) or backtick quoted (MySQL) identifier
+	TokenQuotedIdent
+	// TokenString is a string literal, including Postgres dollar quoted strings
+	TokenString
+	// TokenNumber is a numeric literal
+	TokenNumber
+	// TokenParam is a positional parameter placeholder ($n or ?)
+	TokenParam
+	// TokenPunct is a single punctuation or operator character
+	TokenPunct
+)
+
+// Token is a single lexical element of a SQL statement. Comments and
+// whitespace are not emitted as tokens.
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  int
+}
+
+// IsWord reports whether the token is the given unquoted keyword, compared
+// case-insensitively
+func (t Token) IsWord(word string) bool {
+	return t.Kind == TokenWord && strings.EqualFold(t.Text, word)
+}
+
+// IsPunct reports whether the token is the given punctuation character
+func (t Token) IsPunct(p string) bool {
+	return t.Kind == TokenPunct && t.Text == p
+}
+
+type lexer struct {
+	src     string
+	pos     int
+	dialect Dialect
+	tokens  []Token
+}
+
+// Tokenize splits a SQL string into tokens following the quoting and comment
+// rules of the given dialect. It fails on unterminated literals or comments
+// rather than guessing where they end.
+func Tokenize(sql string, dialect Dialect) ([]Token, error) {
+	l := &lexer{src: sql, dialect: dialect}
+	for l.pos < len(l.src) {
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+	return l.tokens, nil
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) emit(kind TokenKind, start int) {
+	l.tokens = append(l.tokens, Token{Kind: kind, Text: l.src[start:l.pos], Pos: start})
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || isDigit(c) || c == '$'
+}
+
+func (l *lexer) next() error {
+	c := l.peek(0)
+	start := l.pos
+
+	switch {
+	case isSpace(c):
+		l.pos++
+		return nil
+
+	case c == '-' && l.peek(1) == '-':
+		// MySQL only treats "--" as a comment when followed by whitespace
+		if l.dialect == DialectMySQL && l.peek(2) != 0 && !isSpace(l.peek(2)) {
+			l.pos++
+			l.emit(TokenPunct, start)
+			return nil
+		}
+		l.skipLine()
+		return nil
+
+	case c == '#' && l.dialect == DialectMySQL:
+		l.skipLine()
+		return nil
+
+	case c == '/' && l.peek(1) == '*':
+		return l.skipBlockComment()
+
+	case c == '\'':
+		return l.quoted('\'', TokenString, l.dialect == DialectMySQL)
+
+	case c == '"':
+		if l.dialect == DialectMySQL {
+			return l.quoted('"', TokenString, true)
+		}
+		return l.quoted('"', TokenQuotedIdent, false)
+
+	case c == '`' && l.dialect == DialectMySQL:
+		return l.quoted('`', TokenQuotedIdent, false)
+
+	case c == '$' && l.dialect == DialectPostgres:
+		if isDigit(l.peek(1)) {
+			l.pos++
+			for isDigit(l.peek(0)) {
+				l.pos++
+			}
+			l.emit(TokenParam, start)
+			return nil
+		}
+		return l.dollarQuoted()
+
+	case c == '?' && l.dialect == DialectMySQL:
+		l.pos++
+		l.emit(TokenParam, start)
+		return nil
+
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		l.number()
+		return nil
+
+	case isWordStart(c):
+		return l.word()
+
+	default:
+		l.pos++
+		l.emit(TokenPunct, start)
+		return nil
+	}
+}
+
+func (l *lexer) skipLine() {
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+		l.pos++
+	}
+}
+
+func (l *lexer) skipBlockComment() error {
+	start := l.pos
+	if l.dialect == DialectMySQL && l.peek(2) == '!' {
+		// /*! ... */ is executed by MySQL rather than ignored
+		return fmt.Errorf("executable comment at position %d is not allowed", start)
+	}
+
+	l.pos += 2
+	depth := 1
+	for l.pos < len(l.src) {
+		switch {
+		case l.peek(0) == '*' && l.peek(1) == '/':
+			l.pos += 2
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		case l.peek(0) == '/' && l.peek(1) == '*' && l.dialect == DialectPostgres:
+			// Postgres block comments nest, MySQL ones do not
+			l.pos += 2
+			depth++
+		default:
+			l.pos++
+		}
+	}
+	return fmt.Errorf("unterminated comment at position %d", start)
+}
+
+// quoted consumes a literal delimited by quote. A doubled quote is always an
+// escaped quote; backslash escapes are honoured only when requested.
+func (l *lexer) quoted(quote byte, kind TokenKind, backslashEscapes bool) error {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case backslashEscapes && c == '\\':
+			l.pos += 2
+		case c == quote && l.peek(1) == quote:
+			l.pos += 2
+		case c == quote:
+			l.pos++
+			l.emit(kind, start)
+			return nil
+		default:
+			l.pos++
+		}
+	}
+	return fmt.Errorf("unterminated quoted literal at position %d", start)
+}
+
+func (l *lexer) dollarQuoted() error {
+	start := l.pos
+	end := l.pos + 1
+	for end < len(l.src) && l.src[end] != '$' {
+		if !isWordChar(l.src[end]) {
+			// A lone $ is not a valid token start in Postgres
+			l.pos++
+			l.emit(TokenPunct, start)
+			return nil
+		}
+		end++
+	}
+	if end >= len(l.src) {
+		l.pos++
+		l.emit(TokenPunct, start)
+		return nil
+	}
+
+	delimiter := l.src[start : end+1]
+	closing := strings.Index(l.src[end+1:], delimiter)
+	if closing < 0 {
+		return fmt.Errorf("unterminated dollar quoted string at position %d", start)
+	}
+	l.pos = end + 1 + closing + len(delimiter)
+	l.emit(TokenString, start)
+	return nil
+}
+
+func (l *lexer) number() {
+	start := l.pos
+	for isDigit(l.peek(0)) || l.peek(0) == '.' {
+		l.pos++
+	}
+	if c := l.peek(0); c == 'e' || c == 'E' {
+		next := l.peek(1)
+		if isDigit(next) || ((next == '+' || next == '-') && isDigit(l.peek(2))) {
+			l.pos += 2
+			for isDigit(l.peek(0)) {
+				l.pos++
+			}
+		}
+	}
+	l.emit(TokenNumber, start)
+}
+
+func (l *lexer) word() error {
+	start := l.pos
+	for isWordChar(l.peek(0)) {
+		l.pos++
+	}
+	text := l.src[start:l.pos]
+
+	// String and identifier prefixes: E'..', B'..', X'..', N'..', U&'..', U&".."
+	if l.peek(0) == '\'' && len(text) == 1 && strings.ContainsAny(text, "eEbBxXnN") {
+		backslash := l.dialect == DialectMySQL || text == "e" || text == "E"
+		return l.quoted('\'', TokenString, backslash)
+	}
+	if l.dialect == DialectPostgres && (text == "u" || text == "U") && l.peek(0) == '&' {
+		switch l.peek(1) {
+		case '\'':
+			l.pos++
+			return l.quoted('\'', TokenString, false)
+		case '"':
+			l.pos++
+			return l.quoted('"', TokenQuotedIdent, false)
+		}
+	}
+
+	l.emit(TokenWord, start)
+	return nil
+}
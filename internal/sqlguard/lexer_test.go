@@ -0,0 +1,131 @@
+package sqlguard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tokenTexts(tokens []Token) []string {
+	texts := make([]string, len(tokens))
+	for i, tok := range tokens {
+		texts[i] = tok.Text
+	}
+	return texts
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		dialect  Dialect
+		expected []string
+	}{
+		{
+			name:     "words and punctuation",
+			sql:      "SELECT id, name FROM users;",
+			dialect:  DialectPostgres,
+			expected: []string{"SELECT", "id", ",", "name", "FROM", "users", ";"},
+		},
+		{
+			name:     "postgres comments are dropped",
+			sql:      "SELECT 1 -- trailing ; comment\n/* outer /* nested; */ still comment */ FROM t",
+			dialect:  DialectPostgres,
+			expected: []string{"SELECT", "1", "FROM", "t"},
+		},
+		{
+			name:     "postgres string keeps semicolon",
+			sql:      "SELECT 'a;''b' FROM t",
+			dialect:  DialectPostgres,
+			expected: []string{"SELECT", "'a;''b'", "FROM", "t"},
+		},
+		{
+			name:     "postgres backslash does not escape in standard strings",
+			sql:      `SELECT 'a\' , 'b'`,
+			dialect:  DialectPostgres,
+			expected: []string{"SELECT", `'a\'`, ",", "'b'"},
+		},
+		{
+			name:     "postgres escape string",
+			sql:      `SELECT E'it\'s; fine'`,
+			dialect:  DialectPostgres,
+			expected: []string{"SELECT", `'it\'s; fine'`},
+		},
+		{
+			name:     "postgres dollar quoting and params",
+			sql:      "SELECT $tag$ ; DROP $$ $tag$, $$x$$ WHERE id = $1",
+			dialect:  DialectPostgres,
+			expected: []string{"SELECT", "$tag$ ; DROP $$ $tag$", ",", "$$x$$", "WHERE", "id", "=", "$1"},
+		},
+		{
+			name:     "postgres quoted identifier",
+			sql:      `SELECT "weird "" ; name" FROM t`,
+			dialect:  DialectPostgres,
+			expected: []string{"SELECT", `"weird "" ; name"`, "FROM", "t"},
+		},
+		{
+			name:     "mysql comments",
+			sql:      "SELECT 1 # hash comment ;\nFROM t -- dash comment\n/* block */",
+			dialect:  DialectMySQL,
+			expected: []string{"SELECT", "1", "FROM", "t"},
+		},
+		{
+			name:     "mysql double dash without space is not a comment",
+			sql:      "SELECT 1--1",
+			dialect:  DialectMySQL,
+			expected: []string{"SELECT", "1", "-", "-", "1"},
+		},
+		{
+			name:     "mysql backslash escapes and backticks",
+			sql:      "SELECT 'a\\';', `col``;` FROM t WHERE x = ?",
+			dialect:  DialectMySQL,
+			expected: []string{"SELECT", "'a\\';'", ",", "`col``;`", "FROM", "t", "WHERE", "x", "=", "?"},
+		},
+		{
+			name:     "mysql double quoted string",
+			sql:      `SELECT "a;b"`,
+			dialect:  DialectMySQL,
+			expected: []string{"SELECT", `"a;b"`},
+		},
+		{
+			name:     "numbers",
+			sql:      "SELECT 1.5e-3, .5",
+			dialect:  DialectPostgres,
+			expected: []string{"SELECT", "1.5e-3", ",", ".5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.sql, tt.dialect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := tokenTexts(tokens); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("token mismatch:\nexpected: %q\ngot:      %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		dialect Dialect
+	}{
+		{name: "unterminated string", sql: "SELECT 'abc", dialect: DialectPostgres},
+		{name: "unterminated identifier", sql: `SELECT "abc`, dialect: DialectPostgres},
+		{name: "unterminated nested comment", sql: "SELECT /* /* */ 1", dialect: DialectPostgres},
+		{name: "unterminated dollar quote", sql: "SELECT $a$ abc", dialect: DialectPostgres},
+		{name: "mysql escaped quote runs to end", sql: `SELECT 'abc\'`, dialect: DialectMySQL},
+		{name: "mysql executable comment", sql: "SELECT /*! 1; DROP TABLE t */ 1", dialect: DialectMySQL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Tokenize(tt.sql, tt.dialect); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
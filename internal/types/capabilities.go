@@ -0,0 +1,44 @@
+package types
+
+// ConditionTypes lists every condition operator of the DSL
+var ConditionTypes = []ConditionType{
+	ConditionTypeEqual,
+	ConditionTypeNotEqual,
+	ConditionTypeGreaterThan,
+	ConditionTypeGreaterThanOrEqual,
+	ConditionTypeLessThan,
+	ConditionTypeLessThanOrEqual,
+	ConditionTypeLike,
+	ConditionTypeIn,
+	ConditionTypeNotIn,
+	ConditionTypeIsNull,
+	ConditionTypeIsNotNull,
+	ConditionTypeBetween,
+	ConditionTypeNotLike,
+	ConditionTypeILike,
+	ConditionTypeStartsWith,
+	ConditionTypeEndsWith,
+	ConditionTypeContains,
+	ConditionTypeRegex,
+}
+
+// DSLFeatures lists the optional parts of a DSL query the engines support
+var DSLFeatures = []string{
+	"projections",
+	"aggregates",
+	"distinct",
+	"group_by",
+	"having",
+	"condition_groups",
+	"order_by",
+	"offset",
+	"cursor",
+}
+
+// EngineInfo describes the database behind an engine
+type EngineInfo struct {
+	// Type is the engine name, postgres or mysql
+	Type string `json:"type"`
+	// Version is the version reported by the database server
+	Version string `json:"version"`
+}
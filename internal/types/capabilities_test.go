@@ -0,0 +1,15 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConditionTypesAreSupported(t *testing.T) {
+	for _, ct := range ConditionTypes {
+		err := validateConditionValue(Condition{Column: "id", Type: ct})
+		if err != nil && strings.Contains(err.Error(), "unsupported condition type") {
+			t.Errorf("%s is announced but not supported", ct)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Cursor is the decoded form of the opaque continuation token returned in
+// QueryResponse.NextCursor. After holds the order by values of the last row
+// returned, and Offset the number of rows to skip from that position (or
+// from the start when After is empty). Query ties the cursor to the query
+// it was issued for.
+type Cursor struct {
+	Query  string        `json:"q"`
+	After  []interface{} `json:"after,omitempty"`
+	Offset int           `json:"offset,omitempty"`
+}
+
+// EncodeCursor serializes a cursor into an opaque URL safe token
+func EncodeCursor(c *Cursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses a token produced by EncodeCursor. Integers are kept
+// as int64 so large keys survive the round trip.
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var c Cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+	if c.Offset < 0 {
+		return nil, fmt.Errorf("malformed cursor: negative offset")
+	}
+
+	for i, v := range c.After {
+		n, ok := v.(json.Number)
+		if !ok {
+			continue
+		}
+		if integer, err := n.Int64(); err == nil {
+			c.After[i] = integer
+		} else if f, err := n.Float64(); err == nil {
+			c.After[i] = f
+		} else {
+			c.After[i] = n.String()
+		}
+	}
+
+	return &c, nil
+}
+
+// Fingerprint identifies the shape of the query independently of the page
+// being requested, so a cursor cannot be replayed against another query
+func (q *QueryParams) Fingerprint() string {
+	shape := *q
+	shape.Limit = nil
+	shape.Offset = nil
+	shape.Cursor = ""
+
+	data, err := json.Marshal(shape)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PageCursor returns the decoded cursor of the request, or nil when the
+// request starts from the first page
+func (q *QueryParams) PageCursor() (*Cursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	c, err := DecodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if c.Query != q.Fingerprint() {
+		return nil, fmt.Errorf("cursor was issued for a different query")
+	}
+	if len(c.After) > 0 {
+		if _, ok := q.KeysetColumns(); !ok || len(c.After) != len(q.OrderBy) {
+			return nil, fmt.Errorf("cursor does not match order_by")
+		}
+	}
+
+	return c, nil
+}
+
+// KeysetColumns returns the result set names of the order by columns. It
+// reports false when the query cannot be paged by key, because it has no
+// order, orders by aggregates, groups rows or uses the legacy select string.
+func (q *QueryParams) KeysetColumns() ([]string, bool) {
+	if len(q.OrderBy) == 0 || len(q.GroupBy) > 0 || (q.Select != nil && *q.Select != "") {
+		return nil, false
+	}
+
+	names := make([]string, len(q.OrderBy))
+	for i, term := range q.OrderBy {
+		resolved := q.ResolveOrderTerm(term)
+		if resolved.Aggregate != "" {
+			return nil, false
+		}
+		name, ok := q.outputName(resolved.Column)
+		if !ok {
+			return nil, false
+		}
+		names[i] = name
+	}
+
+	return names, true
+}
+
+// outputName returns the name under which column appears in the result set
+func (q *QueryParams) outputName(column string) (string, bool) {
+	parts := strings.Split(column, ".")
+	if len(q.Projections) == 0 {
+		return parts[len(parts)-1], true
+	}
+
+	star := false
+	for _, p := range q.Projections {
+		if p.Aggregate != "" {
+			continue
+		}
+		if p.Column == column {
+			return p.OutputName(), true
+		}
+		if p.Column == "*" {
+			star = true
+		}
+	}
+	if star {
+		return parts[len(parts)-1], true
+	}
+	return "", false
+}
+
+// PageConditions returns the WHERE conditions for the requested page and
+// the number of rows to skip. A keyset cursor adds a condition selecting
+// rows at or after the last row of the previous page; nullsLargest tells
+// whether the database sorts NULLs as larger than any other value.
+func (q *QueryParams) PageConditions(nullsLargest bool) ([]Condition, int, error) {
+	c, err := q.PageCursor()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if c == nil {
+		if q.Offset != nil {
+			return q.Conditions, *q.Offset, nil
+		}
+		return q.Conditions, 0, nil
+	}
+
+	if len(c.After) == 0 {
+		return q.Conditions, c.Offset, nil
+	}
+
+	terms := make([]OrderTerm, len(q.OrderBy))
+	for i, term := range q.OrderBy {
+		terms[i] = q.ResolveOrderTerm(term)
+	}
+
+	conditions := make([]Condition, 0, len(q.Conditions)+1)
+	conditions = append(conditions, q.Conditions...)
+	conditions = append(conditions, keysetCondition(terms, c.After, nullsLargest))
+	return conditions, c.Offset, nil
+}
+
+// keysetCondition selects the rows that sort at or after the key after.
+// Rows equal to the key are included and skipped with the cursor offset,
+// so ties between pages are neither repeated nor lost.
+func keysetCondition(terms []OrderTerm, after []interface{}, nullsLargest bool) Condition {
+	term := terms[0]
+
+	if len(terms) == 1 {
+		return keysetCompare(term, after[0], true, nullsLargest)
+	}
+
+	return Condition{Any: []Condition{
+		keysetCompare(term, after[0], false, nullsLargest),
+		{All: []Condition{
+			{Column: term.Column, Type: ConditionTypeEqual, Value: after[0]},
+			keysetCondition(terms[1:], after[1:], nullsLargest),
+		}},
+	}}
+}
+
+// keysetCompare selects the rows whose term sorts after value, or at value
+// when inclusive is set. NULLs follow every value when they sort last.
+func keysetCompare(term OrderTerm, value interface{}, inclusive, nullsLargest bool) Condition {
+	var op ConditionType
+	switch {
+	case term.Descending() && inclusive:
+		op = ConditionTypeLessThanOrEqual
+	case term.Descending():
+		op = ConditionTypeLessThan
+	case inclusive:
+		op = ConditionTypeGreaterThanOrEqual
+	default:
+		op = ConditionTypeGreaterThan
+	}
+
+	cond := Condition{Column: term.Column, Type: op, Value: value}
+	if !term.NullsSortLast(nullsLargest) {
+		return cond
+	}
+	return Condition{Any: []Condition{
+		cond,
+		{Column: term.Column, Type: ConditionTypeIsNull},
+	}}
+}
+
+// NextCursor returns the token for the page after rows, or an empty string
+// when rows is the last page
+func (q *QueryParams) NextCursor(rows []QueryResult) (string, error) {
+	tracker := q.NewPageTracker(nil)
+	for _, row := range rows {
+		tracker.observe(rowKey(row, tracker.columns))
+	}
+	return tracker.NextCursor()
+}
+
+// PageTracker follows the rows of a page as they are written so the next
+// cursor can be computed without holding the page in memory. It forwards
+// every row to the wrapped writer.
+type PageTracker struct {
+	params  *QueryParams
+	next    RowWriter
+	columns []string
+	indexes []int
+	keyset  bool
+	rows    int
+	last    []interface{}
+	ties    int
+}
+
+// NewPageTracker returns a tracker forwarding rows to next, which may be
+// nil when the rows are only counted
+func (q *QueryParams) NewPageTracker(next RowWriter) *PageTracker {
+	columns, keyset := q.KeysetColumns()
+	return &PageTracker{params: q, next: next, columns: columns, keyset: keyset}
+}
+
+// WriteHeader implements RowWriter. A key column whose name appears more
+// than once in the result is ambiguous, so the cursor then counts rows
+// instead.
+func (t *PageTracker) WriteHeader(columns []ResultColumn) error {
+	if t.keyset {
+		t.indexes, t.keyset = keyIndexes(columns, t.columns)
+	}
+	if t.next == nil {
+		return nil
+	}
+	return t.next.WriteHeader(columns)
+}
+
+// WriteRow implements RowWriter
+func (t *PageTracker) WriteRow(values []interface{}) error {
+	t.observe(valuesKey(values, t.indexes))
+	if t.next == nil {
+		return nil
+	}
+	return t.next.WriteRow(values)
+}
+
+// observe records the key of a row and the length of the trailing run of
+// rows sharing it
+func (t *PageTracker) observe(key []interface{}) {
+	t.rows++
+	if !t.keyset {
+		return
+	}
+
+	if key != nil && sameKey(key, t.last) {
+		t.ties++
+	} else {
+		t.ties = 1
+	}
+	t.last = key
+}
+
+// NextCursor returns the token for the page after the tracked rows, or an
+// empty string when they were the last page. Keyset cursors are used
+// whenever the query allows it and the last row has no NULL keys; otherwise
+// the cursor keeps the previous position and counts the rows to skip from it.
+func (t *PageTracker) NextCursor() (string, error) {
+	q := t.params
+	if q.Limit == nil || *q.Limit <= 0 || t.rows < *q.Limit {
+		return "", nil
+	}
+
+	current, err := q.PageCursor()
+	if err != nil {
+		return "", err
+	}
+
+	var after []interface{}
+	skipped := 0
+	switch {
+	case current != nil:
+		after, skipped = current.After, current.Offset
+	case q.Offset != nil:
+		skipped = *q.Offset
+	}
+
+	next := &Cursor{Query: q.Fingerprint(), After: after, Offset: skipped + t.rows}
+
+	if t.keyset && t.last != nil {
+		// The rows sharing the last key are skipped on the next page,
+		// including those skipped on earlier pages when the whole page is
+		// one run of ties
+		ties := t.ties
+		if ties == t.rows && sameKey(after, t.last) {
+			ties += skipped
+		}
+		next.After, next.Offset = t.last, ties
+	}
+
+	return EncodeCursor(next)
+}
+
+// rowKey extracts the order by values of a row, or nil when any is NULL
+func rowKey(row QueryResult, columns []string) []interface{} {
+	key := make([]interface{}, len(columns))
+	for i, col := range columns {
+		v, ok := row[col]
+		if !ok || v == nil {
+			return nil
+		}
+		key[i] = v
+	}
+	return key
+}
+
+// keyIndexes returns the position of each key column in the result
+// columns, and false when one is missing or ambiguous
+func keyIndexes(columns []ResultColumn, keys []string) ([]int, bool) {
+	indexes := make([]int, len(keys))
+	for i, key := range keys {
+		indexes[i] = -1
+		for j, col := range columns {
+			if col.Name != key {
+				continue
+			}
+			if indexes[i] >= 0 {
+				return nil, false
+			}
+			indexes[i] = j
+		}
+		if indexes[i] < 0 {
+			return nil, false
+		}
+	}
+	return indexes, true
+}
+
+// valuesKey extracts the order by values of a row from its values at
+// indexes, or nil when any is NULL or no header was written
+func valuesKey(values []interface{}, indexes []int) []interface{} {
+	if len(indexes) == 0 {
+		return nil
+	}
+	key := make([]interface{}, len(indexes))
+	for i, idx := range indexes {
+		if idx >= len(values) || values[idx] == nil {
+			return nil
+		}
+		key[i] = values[idx]
+	}
+	return key
+}
+
+// sameKey compares keys by their JSON form, which is how keys read from a
+// row and keys decoded from a cursor are both represented in the token
+func sameKey(a, b []interface{}) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
@@ -0,0 +1,245 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	token, err := EncodeCursor(&Cursor{
+		Query:  "abc",
+		After:  []interface{}{int64(9007199254740993), "x", 1.5},
+		Offset: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []interface{}{int64(9007199254740993), "x", 1.5}
+	if c.Query != "abc" || c.Offset != 3 || !reflect.DeepEqual(c.After, expected) {
+		t.Errorf("unexpected cursor: %+v", c)
+	}
+
+	if _, err := DecodeCursor("not a cursor!"); err == nil {
+		t.Error("expected error for malformed cursor")
+	}
+}
+
+func TestPageCursorRejectsOtherQueries(t *testing.T) {
+	limit := 10
+	first := &QueryParams{Table: "users", Limit: &limit}
+	token, err := EncodeCursor(&Cursor{Query: first.Fingerprint(), Offset: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A different limit is still the same query
+	other := 20
+	if _, err := (&QueryParams{Table: "users", Limit: &other, Cursor: token}).PageCursor(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := (&QueryParams{Table: "orders", Limit: &limit, Cursor: token}).PageCursor(); err == nil {
+		t.Error("expected error for cursor of another query")
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	limit := 3
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("short page ends paging", func(t *testing.T) {
+		q := &QueryParams{Table: "users", Limit: &limit}
+		token, err := q.NextCursor([]QueryResult{{"id": 1}})
+		if err != nil || token != "" {
+			t.Errorf("expected no cursor, got %q (%v)", token, err)
+		}
+	})
+
+	t.Run("offset without order", func(t *testing.T) {
+		offset := 6
+		q := &QueryParams{Table: "users", Limit: &limit, Offset: &offset}
+		c := nextCursor(t, q, []QueryResult{{"id": 1}, {"id": 2}, {"id": 3}})
+		if c.After != nil || c.Offset != 9 {
+			t.Errorf("unexpected cursor: %+v", c)
+		}
+	})
+
+	t.Run("keyset on last row", func(t *testing.T) {
+		q := &QueryParams{
+			Table:   "users",
+			OrderBy: []OrderTerm{{Column: "created_at"}, {Column: "users.id"}},
+			Limit:   &limit,
+		}
+		c := nextCursor(t, q, []QueryResult{
+			{"id": 1, "created_at": created},
+			{"id": 2, "created_at": created},
+			{"id": 3, "created_at": created},
+		})
+		expected := []interface{}{created.Format(time.RFC3339Nano), int64(3)}
+		if !reflect.DeepEqual(c.After, expected) || c.Offset != 1 {
+			t.Errorf("unexpected cursor: %+v", c)
+		}
+	})
+
+	t.Run("ties are counted across pages", func(t *testing.T) {
+		q := &QueryParams{
+			Table:   "users",
+			OrderBy: []OrderTerm{{Column: "status"}},
+			Limit:   &limit,
+		}
+		c := nextCursor(t, q, []QueryResult{{"status": "a"}, {"status": "b"}, {"status": "b"}})
+		if !reflect.DeepEqual(c.After, []interface{}{"b"}) || c.Offset != 2 {
+			t.Fatalf("unexpected cursor: %+v", c)
+		}
+
+		q.Cursor, _ = EncodeCursor(c)
+		c = nextCursor(t, q, []QueryResult{{"status": "b"}, {"status": "b"}, {"status": "b"}})
+		if !reflect.DeepEqual(c.After, []interface{}{"b"}) || c.Offset != 5 {
+			t.Errorf("unexpected cursor: %+v", c)
+		}
+	})
+
+	t.Run("null key falls back to offset", func(t *testing.T) {
+		q := &QueryParams{
+			Table:   "users",
+			OrderBy: []OrderTerm{{Column: "status"}},
+			Limit:   &limit,
+		}
+		q.Cursor, _ = EncodeCursor(&Cursor{Query: q.Fingerprint(), After: []interface{}{"b"}, Offset: 1})
+		c := nextCursor(t, q, []QueryResult{{"status": "c"}, {"status": "d"}, {"status": nil}})
+		if !reflect.DeepEqual(c.After, []interface{}{"b"}) || c.Offset != 4 {
+			t.Errorf("unexpected cursor: %+v", c)
+		}
+	})
+
+	t.Run("aggregate order uses offset", func(t *testing.T) {
+		q := &QueryParams{
+			Table:       "orders",
+			Projections: []Projection{{Column: "status"}, {Aggregate: AggregateCount, Alias: "n"}},
+			GroupBy:     []string{"status"},
+			OrderBy:     []OrderTerm{{Column: "n"}},
+			Limit:       &limit,
+		}
+		c := nextCursor(t, q, []QueryResult{{"status": "a"}, {"status": "b"}, {"status": "c"}})
+		if c.After != nil || c.Offset != 3 {
+			t.Errorf("unexpected cursor: %+v", c)
+		}
+	})
+}
+
+func TestPageTrackerAmbiguousKey(t *testing.T) {
+	limit := 1
+	q := &QueryParams{Table: "users", OrderBy: []OrderTerm{{Column: "id"}}, Limit: &limit}
+
+	// Two result columns named id: the key cannot be told apart, so the
+	// cursor counts rows instead
+	tracker := q.NewPageTracker(nil)
+	if err := tracker.WriteHeader([]ResultColumn{{Name: "id"}, {Name: "id"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tracker.WriteRow([]interface{}{int64(1), int64(9)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	token, err := tracker.NextCursor()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.After != nil || c.Offset != 1 {
+		t.Errorf("unexpected cursor: %+v", c)
+	}
+}
+
+func TestPageTrackerForwardsRows(t *testing.T) {
+	limit := 2
+	q := &QueryParams{Table: "users", OrderBy: []OrderTerm{{Column: "id"}}, Limit: &limit}
+
+	collector := &RowCollector{}
+	tracker := q.NewPageTracker(collector)
+	if err := tracker.WriteHeader([]ResultColumn{{Name: "id"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []int64{4, 5} {
+		if err := tracker.WriteRow([]interface{}{id}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(collector.Columns) != 1 || collector.Response().RowCount != 2 {
+		t.Errorf("rows were not forwarded: %+v", collector)
+	}
+
+	token, err := tracker.NextCursor()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(c.After, []interface{}{int64(5)}) || c.Offset != 1 {
+		t.Errorf("unexpected cursor: %+v", c)
+	}
+}
+
+func TestPageConditions(t *testing.T) {
+	q := &QueryParams{
+		Table:      "users",
+		Conditions: []Condition{{Column: "active", Type: ConditionTypeEqual, Value: true}},
+		OrderBy:    []OrderTerm{{Column: "name", Direction: SortDesc}, {Column: "id"}},
+	}
+	q.Cursor, _ = EncodeCursor(&Cursor{Query: q.Fingerprint(), After: []interface{}{"bob", 7}, Offset: 1})
+
+	conditions, skip, err := q.PageConditions(true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if skip != 1 || len(conditions) != 2 {
+		t.Fatalf("unexpected page: skip=%d conditions=%+v", skip, conditions)
+	}
+
+	// name sorts NULLs first when descending with NULLs largest, id sorts
+	// them last when ascending
+	expected := Condition{Any: []Condition{
+		{Column: "name", Type: ConditionTypeLessThan, Value: "bob"},
+		{All: []Condition{
+			{Column: "name", Type: ConditionTypeEqual, Value: "bob"},
+			{Any: []Condition{
+				{Column: "id", Type: ConditionTypeGreaterThanOrEqual, Value: int64(7)},
+				{Column: "id", Type: ConditionTypeIsNull},
+			}},
+		}},
+	}}
+	if !reflect.DeepEqual(conditions[1], expected) {
+		t.Errorf("unexpected keyset condition:\nexpected: %+v\ngot:      %+v", expected, conditions[1])
+	}
+}
+
+func nextCursor(t *testing.T, q *QueryParams, rows []QueryResult) *Cursor {
+	t.Helper()
+
+	token, err := q.NextCursor(rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token == "" {
+		t.Fatal("expected a cursor")
+	}
+
+	c, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return c
+}
@@ -1,15 +1,61 @@
 package types
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTimeout is returned, wrapping the database error, when a query runs
+// past the deadline of its context
+var ErrTimeout = errors.New("query timed out")
+
+// StatementTimeout returns the time left before the deadline of ctx in
+// whole milliseconds, for engines to set as a statement timeout on the
+// database. It reports false when ctx has no deadline.
+func StatementTimeout(ctx context.Context) (int64, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	// Round up, and never return 0 which disables the timeout
+	ms := (time.Until(deadline) + time.Millisecond - 1).Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return ms, true
+}
 
 // Engine defines the common interface that all database engines must implement
 type Engine interface {
 	// Ping checks if the database is reachable
 	Ping(ctx context.Context) error
 
+	// Info returns the engine type and the database server version
+	Info(ctx context.Context) (*EngineInfo, error)
+
 	// ExecuteQuery executes a DSL query and returns results
 	ExecuteQuery(ctx context.Context, params *QueryParams) (*QueryResponse, error)
 
+	// ExecuteRawQuery executes a single read-only SQL statement inside a
+	// read-only transaction and returns results
+	ExecuteRawQuery(ctx context.Context, params *RawQueryParams) (*QueryResponse, error)
+
+	// StreamQuery executes a DSL query and writes each row to w as it is
+	// read, without holding the result in memory
+	StreamQuery(ctx context.Context, params *QueryParams, w RowWriter) (*StreamSummary, error)
+
+	// StreamRawQuery is the streaming form of ExecuteRawQuery
+	StreamRawQuery(ctx context.Context, params *RawQueryParams, w RowWriter) (*StreamSummary, error)
+
+	// DescribeSchema introspects the catalog and returns every user visible
+	// schema with its tables, views and columns
+	DescribeSchema(ctx context.Context) (*SchemaResponse, error)
+
+	// FetchColumns returns the ordered columns of a single table
+	FetchColumns(ctx context.Context, params *FetchColumnsParams) (*ColumnsResponse, error)
+
 	// Close closes the database connection
 	Close() error
 }
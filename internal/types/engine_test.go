@@ -0,0 +1,25 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStatementTimeout(t *testing.T) {
+	if _, ok := StatementTimeout(context.Background()); ok {
+		t.Error("expected no timeout without a deadline")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if ms, ok := StatementTimeout(ctx); !ok || ms <= 1000 || ms > 2000 {
+		t.Errorf("unexpected timeout: %d %v", ms, ok)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if ms, ok := StatementTimeout(expired); !ok || ms != 1 {
+		t.Errorf("expected the smallest timeout for a past deadline, got %d %v", ms, ok)
+	}
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+)
+
+// ErrorCode classifies why a job failed, the same way for every engine
+type ErrorCode int
+
+const (
+	ErrorUnspecified ErrorCode = iota
+	// The job payload or query is invalid
+	ErrorValidation
+	ErrorUnknownColumn
+	ErrorUnknownTable
+	ErrorPermissionDenied
+	// The database cannot be reached or dropped the connection
+	ErrorConnection
+	ErrorTimeout
+	ErrorCancelled
+	ErrorSyntax
+	ErrorInternal
+)
+
+// QueryError is an error classified with its code. NativeCode and
+// NativeMessage are the code and message of the database error it comes
+// from, such as a SQLSTATE or a MySQL error number, when there is one.
+type QueryError struct {
+	Code          ErrorCode
+	NativeCode    string
+	NativeMessage string
+	Err           error
+}
+
+func (e *QueryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError classifies err as an invalid query
+func ValidationError(err error) error {
+	return &QueryError{Code: ErrorValidation, Err: err}
+}
+
+// ClassifyCommonError classifies the errors that do not come from the
+// database: errors already classified, timeouts, cancellations and lost
+// connections. It returns nil for other errors.
+func ClassifyCommonError(err error) *QueryError {
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr
+	}
+
+	switch {
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return &QueryError{Code: ErrorTimeout, Err: err}
+	case errors.Is(err, context.Canceled):
+		return &QueryError{Code: ErrorCancelled, Err: err}
+	case errors.Is(err, driver.ErrBadConn):
+		return &QueryError{Code: ErrorConnection, Err: err}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return &QueryError{Code: ErrorConnection, Err: err}
+	}
+	return nil
+}
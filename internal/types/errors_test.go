@@ -0,0 +1,38 @@
+package types
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestClassifyCommonError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode ErrorCode
+	}{
+		{"validation", fmt.Errorf("rejected: %w", ValidationError(errors.New("not a select"))), ErrorValidation},
+		{"timeout", fmt.Errorf("%w: %w", ErrTimeout, errors.New("statement stopped")), ErrorTimeout},
+		{"deadline", context.DeadlineExceeded, ErrorTimeout},
+		{"cancelled", fmt.Errorf("failed to scan row: %w", context.Canceled), ErrorCancelled},
+		{"bad connection", driver.ErrBadConn, ErrorConnection},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrorConnection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryErr := ClassifyCommonError(tt.err)
+			if queryErr == nil || queryErr.Code != tt.expectedCode {
+				t.Errorf("expected %v, got %v", tt.expectedCode, queryErr)
+			}
+		})
+	}
+
+	if queryErr := ClassifyCommonError(errors.New("unexpected")); queryErr != nil {
+		t.Errorf("unknown errors should not be classified, got %v", queryErr.Code)
+	}
+}
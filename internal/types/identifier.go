@@ -0,0 +1,198 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxIdentifierLength is the longest identifier part, in characters, that
+// any supported dialect accepts
+const MaxIdentifierLength = 64
+
+// IdentifierRules describes how a SQL dialect quotes identifiers and which
+// names it is able to represent
+type IdentifierRules struct {
+	// QuoteChar is the character used to delimit identifiers
+	QuoteChar byte
+	// MaxBytes limits the encoded length of each part, 0 means no limit
+	MaxBytes int
+	// MaxChars limits the number of characters of each part, 0 means no limit
+	MaxChars int
+	// BMPOnly rejects characters outside the Basic Multilingual Plane
+	BMPOnly bool
+	// NoTrailingSpace rejects parts that end with a space
+	NoTrailingSpace bool
+}
+
+var (
+	// PostgresIdentifiers silently truncates names to NAMEDATALEN-1 bytes,
+	// so longer names are rejected rather than allowed to alias another object
+	PostgresIdentifiers = IdentifierRules{QuoteChar: '"', MaxBytes: 63}
+
+	// MySQLIdentifiers follows the MySQL rules for quoted identifiers
+	MySQLIdentifiers = IdentifierRules{QuoteChar: '`', MaxChars: 64, BMPOnly: true, NoTrailingSpace: true}
+)
+
+// IdentifierRulesFor returns the identifier rules of a database type, or
+// false when the database type is unknown
+func IdentifierRulesFor(databaseType string) (IdentifierRules, bool) {
+	switch strings.ToLower(databaseType) {
+	case "postgres", "postgresql":
+		return PostgresIdentifiers, true
+	case "mysql":
+		return MySQLIdentifiers, true
+	default:
+		return IdentifierRules{}, false
+	}
+}
+
+// SplitIdentifier splits a possibly qualified identifier such as
+// "schema.table" on dots and validates every part. Names containing a
+// literal dot cannot be addressed through the DSL.
+func SplitIdentifier(name string) ([]string, error) {
+	if name == "" {
+		return nil, fmt.Errorf("identifier is empty")
+	}
+
+	parts := strings.Split(name, ".")
+	for _, part := range parts {
+		if err := validateIdentifierPart(part); err != nil {
+			return nil, fmt.Errorf("invalid identifier %q: %w", name, err)
+		}
+	}
+	return parts, nil
+}
+
+// ValidateIdentifier checks that name is an identifier with at most
+// maxParts dot separated parts that every supported dialect can represent
+func ValidateIdentifier(name string, maxParts int) error {
+	parts, err := SplitIdentifier(name)
+	if err != nil {
+		return err
+	}
+	if len(parts) > maxParts {
+		return fmt.Errorf("identifier %q has too many parts", name)
+	}
+	return nil
+}
+
+func validateIdentifierPart(part string) error {
+	if part == "" {
+		return fmt.Errorf("empty name part")
+	}
+	if !utf8.ValidString(part) {
+		return fmt.Errorf("not valid UTF-8")
+	}
+	if utf8.RuneCountInString(part) > MaxIdentifierLength {
+		return fmt.Errorf("longer than %d characters", MaxIdentifierLength)
+	}
+	for _, r := range part {
+		if r == 0 || unicode.IsControl(r) {
+			return fmt.Errorf("contains control characters")
+		}
+	}
+	return nil
+}
+
+// Validate checks that name is representable in this dialect
+func (r IdentifierRules) Validate(name string, maxParts int) error {
+	parts, err := SplitIdentifier(name)
+	if err != nil {
+		return err
+	}
+	if len(parts) > maxParts {
+		return fmt.Errorf("identifier %q has too many parts", name)
+	}
+
+	for _, part := range parts {
+		if r.MaxBytes > 0 && len(part) > r.MaxBytes {
+			return fmt.Errorf("invalid identifier %q: longer than %d bytes", name, r.MaxBytes)
+		}
+		if r.MaxChars > 0 && utf8.RuneCountInString(part) > r.MaxChars {
+			return fmt.Errorf("invalid identifier %q: longer than %d characters", name, r.MaxChars)
+		}
+		if r.NoTrailingSpace && strings.HasSuffix(part, " ") {
+			return fmt.Errorf("invalid identifier %q: ends with a space", name)
+		}
+		if r.BMPOnly {
+			for _, c := range part {
+				if c > 0xFFFF {
+					return fmt.Errorf("invalid identifier %q: contains characters outside the BMP", name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// QuotePart quotes a single identifier part, doubling embedded quotes
+func (r IdentifierRules) QuotePart(part string) string {
+	q := string(r.QuoteChar)
+	return q + strings.ReplaceAll(part, q, q+q) + q
+}
+
+// Quote validates and quotes a possibly qualified identifier with at most
+// maxParts parts
+func (r IdentifierRules) Quote(name string, maxParts int) (string, error) {
+	if err := r.Validate(name, maxParts); err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = r.QuotePart(part)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// QuoteTable quotes a table reference. When schema is set the table must be
+// a single part; otherwise the table may itself be schema qualified.
+func (r IdentifierRules) QuoteTable(schema *string, table string) (string, error) {
+	if schema != nil && *schema != "" {
+		quotedSchema, err := r.Quote(*schema, 1)
+		if err != nil {
+			return "", err
+		}
+		quotedTable, err := r.Quote(table, 1)
+		if err != nil {
+			return "", err
+		}
+		return quotedSchema + "." + quotedTable, nil
+	}
+	return r.Quote(table, 2)
+}
+
+// QuoteColumn quotes a column reference, optionally qualified by table and schema
+func (r IdentifierRules) QuoteColumn(column string) (string, error) {
+	return r.Quote(column, 3)
+}
+
+// QuoteSelectItem quotes an entry of a select list, which besides a column
+// may be "*" or a qualified "table.*"
+func (r IdentifierRules) QuoteSelectItem(item string) (string, error) {
+	if item == "*" {
+		return item, nil
+	}
+	if prefix, ok := strings.CutSuffix(item, ".*"); ok {
+		quoted, err := r.Quote(prefix, 2)
+		if err != nil {
+			return "", err
+		}
+		return quoted + ".*", nil
+	}
+	return r.QuoteColumn(item)
+}
+
+// ParseSelectList splits a comma separated select string into its items
+func ParseSelectList(selectList string) ([]string, error) {
+	items := strings.Split(selectList, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+		if items[i] == "" {
+			return nil, fmt.Errorf("select list has an empty item")
+		}
+	}
+	return items, nil
+}
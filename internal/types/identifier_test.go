@@ -0,0 +1,141 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []string
+		expectError bool
+	}{
+		{name: "simple", input: "users", expected: []string{"users"}},
+		{name: "qualified", input: "public.users", expected: []string{"public", "users"}},
+		{name: "spaces and punctuation", input: "order items; --", expected: []string{"order items; --"}},
+		{name: "unicode", input: "commandes.numéro", expected: []string{"commandes", "numéro"}},
+		{name: "empty", input: "", expectError: true},
+		{name: "empty part", input: "public..users", expectError: true},
+		{name: "trailing dot", input: "users.", expectError: true},
+		{name: "nul byte", input: "users\x00", expectError: true},
+		{name: "newline", input: "users\nname", expectError: true},
+		{name: "invalid utf8", input: "users\xff", expectError: true},
+		{name: "too long", input: strings.Repeat("a", MaxIdentifierLength+1), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := SplitIdentifier(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %v", parts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(parts, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("expected %q, got %q", tt.expected, parts)
+			}
+		})
+	}
+}
+
+func TestIdentifierRulesQuote(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       IdentifierRules
+		input       string
+		maxParts    int
+		expected    string
+		expectError bool
+	}{
+		{name: "postgres simple", rules: PostgresIdentifiers, input: "users", maxParts: 1, expected: `"users"`},
+		{name: "postgres qualified", rules: PostgresIdentifiers, input: "public.users", maxParts: 2, expected: `"public"."users"`},
+		{name: "postgres embedded quote", rules: PostgresIdentifiers, input: `a"b`, maxParts: 1, expected: `"a""b"`},
+		{name: "postgres keeps backticks", rules: PostgresIdentifiers, input: "a`b", maxParts: 1, expected: "\"a`b\""},
+		{name: "mysql simple", rules: MySQLIdentifiers, input: "users", maxParts: 1, expected: "`users`"},
+		{name: "mysql embedded backtick", rules: MySQLIdentifiers, input: "a`b", maxParts: 1, expected: "`a``b`"},
+		{name: "mysql keeps double quotes", rules: MySQLIdentifiers, input: `a"b`, maxParts: 1, expected: "`a\"b`"},
+		{name: "too many parts", rules: PostgresIdentifiers, input: "a.b.c", maxParts: 2, expectError: true},
+		{name: "postgres byte limit", rules: PostgresIdentifiers, input: strings.Repeat("é", 32), maxParts: 1, expectError: true},
+		{name: "mysql trailing space", rules: MySQLIdentifiers, input: "name ", maxParts: 1, expectError: true},
+		{name: "mysql supplementary character", rules: MySQLIdentifiers, input: "emoji_\U0001F600", maxParts: 1, expectError: true},
+		{name: "postgres supplementary character", rules: PostgresIdentifiers, input: "emoji_\U0001F600", maxParts: 1, expected: "\"emoji_\U0001F600\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quoted, err := tt.rules.Quote(tt.input, tt.maxParts)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %s", quoted)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if quoted != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, quoted)
+			}
+		})
+	}
+}
+
+func TestQuoteTable(t *testing.T) {
+	schema := "sales"
+	empty := ""
+
+	quoted, err := PostgresIdentifiers.QuoteTable(&schema, "orders")
+	if err != nil || quoted != `"sales"."orders"` {
+		t.Errorf("unexpected result %s, %v", quoted, err)
+	}
+
+	quoted, err = MySQLIdentifiers.QuoteTable(&empty, "sales.orders")
+	if err != nil || quoted != "`sales`.`orders`" {
+		t.Errorf("unexpected result %s, %v", quoted, err)
+	}
+
+	if _, err := PostgresIdentifiers.QuoteTable(&schema, "other.orders"); err == nil {
+		t.Error("expected error for qualified table with explicit schema")
+	}
+}
+
+func TestQuoteSelectItem(t *testing.T) {
+	tests := map[string]string{
+		"*":             "*",
+		"users.*":       `"users".*`,
+		"public.u.*":    `"public"."u".*`,
+		"users.email":   `"users"."email"`,
+		"COUNT(*) AS n": `"COUNT(*) AS n"`,
+	}
+
+	for input, expected := range tests {
+		quoted, err := PostgresIdentifiers.QuoteSelectItem(input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", input, err)
+			continue
+		}
+		if quoted != expected {
+			t.Errorf("%q: expected %s, got %s", input, expected, quoted)
+		}
+	}
+}
+
+func TestParseSelectList(t *testing.T) {
+	items, err := ParseSelectList(" id,name ,  email ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(items, "|") != "id|name|email" {
+		t.Errorf("unexpected items: %q", items)
+	}
+
+	if _, err := ParseSelectList("id,,name"); err == nil {
+		t.Error("expected error for empty item")
+	}
+}
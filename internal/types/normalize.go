@@ -0,0 +1,469 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ValueKind selects how the values of a result column are normalized.
+// Engines set it from the native type of each column.
+type ValueKind int
+
+const (
+	// ValueText values are returned as strings, or as scanned when the
+	// driver returns another type
+	ValueText ValueKind = iota
+	// ValueInt values are numbers, or decimal strings outside the range
+	// JSON numbers hold exactly (±2^53-1)
+	ValueInt
+	// ValueFloat values are numbers, or NaN, Infinity and -Infinity strings
+	ValueFloat
+	// ValueDecimal values are decimal strings, keeping their precision
+	ValueDecimal
+	ValueBool
+	// ValueTimestamp values are RFC 3339 strings with their zone. Times
+	// without a zone, such as MySQL DATETIME and TIMESTAMP text, are taken
+	// as in the Location of their column, UTC when it is nil.
+	ValueTimestamp
+	// ValueDate values are YYYY-MM-DD strings
+	ValueDate
+	// ValueInterval values are ISO 8601 durations with signed components,
+	// such as P1DT-2H
+	ValueInterval
+	// ValueUUID values are lower case hyphenated strings
+	ValueUUID
+	// ValueJSON values are embedded in the result as JSON
+	ValueJSON
+	// ValueBinary values are byte slices, base64 strings in JSON
+	ValueBinary
+	// ValueArray values are arrays of their element kind
+	ValueArray
+)
+
+// maxExactInt is the largest integer a JSON number holds exactly
+const maxExactInt = 1<<53 - 1
+
+// timestampLayouts are the layouts of timestamps returned as text
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// NormalizeValue converts a value scanned from col to its result form,
+// defined by the Kind of col. Values that cannot be converted, such as
+// MySQL zero dates, are returned as scanned, with byte slices as strings.
+func NormalizeValue(val interface{}, col ResultColumn) interface{} {
+	return normalize(val, col.Kind, col.ElemKind, col.Location)
+}
+
+func normalize(val interface{}, kind, elem ValueKind, loc *time.Location) interface{} {
+	if val == nil {
+		return nil
+	}
+
+	var normalized interface{}
+	var ok bool
+	switch kind {
+	case ValueInt:
+		normalized, ok = normalizeInt(val)
+	case ValueFloat:
+		normalized, ok = normalizeFloat(val)
+	case ValueDecimal:
+		normalized, ok = normalizeDecimal(val)
+	case ValueBool:
+		normalized, ok = normalizeBool(val)
+	case ValueTimestamp:
+		var t time.Time
+		if t, ok = parseTimestamp(val, loc); ok {
+			normalized = t.Format(time.RFC3339Nano)
+		}
+	case ValueDate:
+		var t time.Time
+		if t, ok = parseTimestamp(val, nil); ok {
+			normalized = t.Format(time.DateOnly)
+		}
+	case ValueInterval:
+		normalized, ok = parseInterval(textOf(val))
+	case ValueUUID:
+		normalized, ok = normalizeUUID(val)
+	case ValueJSON:
+		if data := []byte(textOf(val)); json.Valid(data) {
+			normalized, ok = json.RawMessage(data), true
+		}
+	case ValueBinary:
+		switch v := val.(type) {
+		case []byte:
+			normalized, ok = v, true
+		case string:
+			normalized, ok = []byte(v), true
+		}
+	case ValueArray:
+		normalized, ok = normalizeArray(val, elem)
+	}
+
+	if ok {
+		return normalized
+	}
+	if b, isBytes := val.([]byte); isBytes {
+		return string(b)
+	}
+	return val
+}
+
+// textOf returns the text of a value a driver returned as text
+func textOf(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func normalizeInt(val interface{}) (interface{}, bool) {
+	switch v := val.(type) {
+	case int64:
+		if v > maxExactInt || v < -maxExactInt {
+			return strconv.FormatInt(v, 10), true
+		}
+		return v, true
+	case int32:
+		return int64(v), true
+	case int:
+		return normalizeInt(int64(v))
+	case uint64:
+		if v > maxExactInt {
+			return strconv.FormatUint(v, 10), true
+		}
+		return int64(v), true
+	case string, []byte:
+		s := textOf(v)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return normalizeInt(n)
+		}
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return normalizeInt(n)
+		}
+	}
+	return nil, false
+}
+
+func normalizeFloat(val interface{}) (interface{}, bool) {
+	var f float64
+	switch v := val.(type) {
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	case string, []byte:
+		var err error
+		if f, err = strconv.ParseFloat(textOf(v), 64); err != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	// JSON has no NaN or infinities; the strings are the ones Postgres uses
+	switch {
+	case math.IsNaN(f):
+		return "NaN", true
+	case math.IsInf(f, 1):
+		return "Infinity", true
+	case math.IsInf(f, -1):
+		return "-Infinity", true
+	}
+	return f, true
+}
+
+func normalizeDecimal(val interface{}) (interface{}, bool) {
+	switch v := val.(type) {
+	case string, []byte:
+		return textOf(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	}
+	return nil, false
+}
+
+func normalizeBool(val interface{}) (interface{}, bool) {
+	switch v := val.(type) {
+	case bool:
+		return v, true
+	case int64:
+		return v != 0, true
+	case string, []byte:
+		if b, err := strconv.ParseBool(textOf(v)); err == nil {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func parseTimestamp(val interface{}, loc *time.Location) (time.Time, bool) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case string, []byte:
+		s := textOf(v)
+		for _, layout := range timestampLayouts {
+			if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+				return t, true
+			}
+		}
+		if t, err := time.Parse(time.DateOnly, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func normalizeUUID(val interface{}) (interface{}, bool) {
+	// Binary UUIDs, as stored in MySQL BINARY(16) columns
+	if b, ok := val.([]byte); ok && len(b) == 16 {
+		if id, err := uuid.FromBytes(b); err == nil {
+			return id.String(), true
+		}
+	}
+	if id, err := uuid.Parse(textOf(val)); err == nil {
+		return id.String(), true
+	}
+	return nil, false
+}
+
+// parseInterval converts an interval in the default postgres IntervalStyle,
+// such as "1 year 2 mons -3 days 04:05:06.5", to an ISO 8601 duration
+func parseInterval(s string) (string, bool) {
+	var date, clock strings.Builder
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		if strings.Contains(field, ":") {
+			if !writeClock(&clock, field) {
+				return "", false
+			}
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			return "", false
+		}
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return "", false
+		}
+		i++
+		var unit string
+		switch strings.TrimSuffix(fields[i], "s") {
+		case "year":
+			unit = "Y"
+		case "mon":
+			unit = "M"
+		case "day":
+			unit = "D"
+		default:
+			return "", false
+		}
+		if n != 0 {
+			date.WriteString(strconv.FormatInt(n, 10) + unit)
+		}
+	}
+
+	if date.Len() == 0 && clock.Len() == 0 {
+		return "PT0S", true
+	}
+	duration := "P" + date.String()
+	if clock.Len() > 0 {
+		duration += "T" + clock.String()
+	}
+	return duration, true
+}
+
+// writeClock writes the hours, minutes and seconds of a [-]HH:MM:SS[.f]
+// interval field, each with the sign of the field
+func writeClock(b *strings.Builder, field string) bool {
+	sign := ""
+	if strings.HasPrefix(field, "-") {
+		sign = "-"
+	}
+	parts := strings.Split(strings.TrimLeft(field, "+-"), ":")
+	if len(parts) != 3 {
+		return false
+	}
+
+	hours, errH := strconv.ParseInt(parts[0], 10, 64)
+	minutes, errM := strconv.ParseInt(parts[1], 10, 64)
+	seconds, errS := strconv.ParseFloat(parts[2], 64)
+	if errH != nil || errM != nil || errS != nil {
+		return false
+	}
+
+	if hours != 0 {
+		b.WriteString(sign + strconv.FormatInt(hours, 10) + "H")
+	}
+	if minutes != 0 {
+		b.WriteString(sign + strconv.FormatInt(minutes, 10) + "M")
+	}
+	if seconds != 0 {
+		b.WriteString(sign + strconv.FormatFloat(seconds, 'f', -1, 64) + "S")
+	}
+	return true
+}
+
+func normalizeArray(val interface{}, elem ValueKind) (interface{}, bool) {
+	if values, ok := val.([]interface{}); ok {
+		normalized := make([]interface{}, len(values))
+		for i, v := range values {
+			normalized[i] = normalize(v, elem, ValueText, nil)
+		}
+		return normalized, true
+	}
+
+	s := textOf(val)
+	// Arrays with bounds other than 1 are prefixed with them, as [0:1]={1,2}
+	if strings.HasPrefix(s, "[") {
+		i := strings.Index(s, "=")
+		if i < 0 {
+			return nil, false
+		}
+		s = s[i+1:]
+	}
+
+	p := arrayParser{s: s, elem: elem}
+	values, err := p.parse()
+	if err != nil || p.pos != len(p.s) {
+		return nil, false
+	}
+	return values, true
+}
+
+// arrayParser parses a postgres array literal such as {1,NULL,"a b",{2}}
+type arrayParser struct {
+	s    string
+	pos  int
+	elem ValueKind
+}
+
+func (p *arrayParser) parse() ([]interface{}, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+		return nil, fmt.Errorf("expected { at %d", p.pos)
+	}
+	p.pos++
+
+	values := []interface{}{}
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return values, nil
+	}
+
+	for {
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+
+		switch p.s[p.pos] {
+		case '{':
+			nested, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, nested)
+		case '"':
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, normalize(s, p.elem, ValueText, nil))
+		default:
+			end := strings.IndexAny(p.s[p.pos:], ",}")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated array")
+			}
+			s := p.s[p.pos : p.pos+end]
+			p.pos += end
+			if strings.EqualFold(s, "NULL") {
+				values = append(values, nil)
+			} else {
+				values = append(values, normalize(s, p.elem, ValueText, nil))
+			}
+		}
+
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return values, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", p.s[p.pos], p.pos)
+		}
+	}
+}
+
+// quoted reads a double quoted element, unescaping backslashes
+func (p *arrayParser) quoted() (string, error) {
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		switch c := p.s[p.pos]; c {
+		case '\\':
+			p.pos++
+			if p.pos < len(p.s) {
+				b.WriteByte(p.s[p.pos])
+			}
+		case '"':
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated quoted element")
+}
+
+// NormalizeRows returns a RowWriter normalizing the values of each row with
+// NormalizeValue before writing it to next
+func NormalizeRows(next RowWriter) RowWriter {
+	return &normalizingWriter{next: next}
+}
+
+type normalizingWriter struct {
+	next    RowWriter
+	columns []ResultColumn
+}
+
+func (w *normalizingWriter) WriteHeader(columns []ResultColumn) error {
+	w.columns = columns
+	return w.next.WriteHeader(columns)
+}
+
+func (w *normalizingWriter) WriteRow(values []interface{}) error {
+	normalized := make([]interface{}, len(values))
+	for i, val := range values {
+		var col ResultColumn
+		if i < len(w.columns) {
+			col = w.columns[i]
+		}
+		normalized[i] = NormalizeValue(val, col)
+	}
+	return w.next.WriteRow(normalized)
+}
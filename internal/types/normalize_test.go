@@ -0,0 +1,87 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 500000000, time.FixedZone("", 2*3600))
+
+	tests := []struct {
+		name string
+		val  interface{}
+		kind ValueKind
+		elem ValueKind
+		want string
+	}{
+		{"null", nil, ValueInt, ValueText, `null`},
+		{"int", int64(42), ValueInt, ValueText, `42`},
+		{"int text", "42", ValueInt, ValueText, `42`},
+		{"big int", int64(1<<53 + 1), ValueInt, ValueText, `"9007199254740993"`},
+		{"big unsigned", "18446744073709551615", ValueInt, ValueText, `"18446744073709551615"`},
+		{"float", "1.5", ValueFloat, ValueText, `1.5`},
+		{"float nan", "NaN", ValueFloat, ValueText, `"NaN"`},
+		{"decimal", "12.50", ValueDecimal, ValueText, `"12.50"`},
+		{"bool", "t", ValueBool, ValueText, `true`},
+		{"timestamp", at, ValueTimestamp, ValueText, `"2024-05-01T12:30:00.5+02:00"`},
+		{"datetime text", "2024-05-01 12:30:00", ValueTimestamp, ValueText, `"2024-05-01T12:30:00Z"`},
+		{"zero date", "0000-00-00 00:00:00", ValueTimestamp, ValueText, `"0000-00-00 00:00:00"`},
+		{"date", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), ValueDate, ValueText, `"2024-05-01"`},
+		{"interval", "1 year 2 mons -3 days 04:05:06.5", ValueInterval, ValueText, `"P1Y2M-3DT4H5M6.5S"`},
+		{"negative interval", "-00:00:01", ValueInterval, ValueText, `"PT-1S"`},
+		{"zero interval", "00:00:00", ValueInterval, ValueText, `"PT0S"`},
+		{"uuid", "A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11", ValueUUID, ValueText, `"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"`},
+		{"json", []byte(`{"a": [1, 2]}`), ValueJSON, ValueText, `{"a":[1,2]}`},
+		{"binary", "\x00\xff", ValueBinary, ValueText, `"AP8="`},
+		{"text bytes", []byte("héllo"), ValueText, ValueText, `"héllo"`},
+		{"int array", "{1,NULL,3}", ValueArray, ValueInt, `[1,null,3]`},
+		{"text array", `{"a b","c\"d",NULL,"NULL"}`, ValueArray, ValueText, `["a b","c\"d",null,"NULL"]`},
+		{"nested array", "[0:1]={{1,2},{3,4}}", ValueArray, ValueInt, `[[1,2],[3,4]]`},
+		{"invalid array", "{1,2", ValueArray, ValueInt, `"{1,2"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeValue(tt.val, ResultColumn{Kind: tt.kind, ElemKind: tt.elem})
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeValueLocation(t *testing.T) {
+	col := ResultColumn{Kind: ValueTimestamp, Location: time.FixedZone("", -5*3600)}
+
+	if got := NormalizeValue("2024-05-01 12:30:00", col); got != "2024-05-01T12:30:00-05:00" {
+		t.Errorf("expected the column zone, got %v", got)
+	}
+	// A zone in the text wins
+	if got := NormalizeValue("2024-05-01 12:30:00+02", col); got != "2024-05-01T12:30:00+02:00" {
+		t.Errorf("expected the zone of the text, got %v", got)
+	}
+}
+
+func TestNormalizeRows(t *testing.T) {
+	collector := &RowCollector{}
+	w := NormalizeRows(collector)
+
+	columns := []ResultColumn{{Name: "id", Kind: ValueInt}, {Name: "data", Kind: ValueBinary}}
+	if err := w.WriteHeader(columns); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.WriteRow([]interface{}{"7", "\x01"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	row := collector.Rows[0]
+	if row[0] != int64(7) || string(row[1].([]byte)) != "\x01" {
+		t.Errorf("unexpected row: %v", row)
+	}
+}
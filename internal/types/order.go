@@ -0,0 +1,132 @@
+package types
+
+import "fmt"
+
+// SortDirection defines the direction of an ORDER BY term
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// NullsOrder defines where NULL values sort in an ORDER BY term
+type NullsOrder string
+
+const (
+	NullsFirst NullsOrder = "first"
+	NullsLast  NullsOrder = "last"
+)
+
+// OrderTerm is a single ORDER BY entry. Column refers to a table column, or
+// to the alias of a projection when no aggregate is set; Aggregate orders
+// by an aggregate over Column. Direction defaults to ascending and Nulls to
+// the database's own default.
+type OrderTerm struct {
+	Column    string        `json:"column,omitempty"`
+	Aggregate AggregateFunc `json:"aggregate,omitempty"`
+	Direction SortDirection `json:"direction,omitempty"`
+	Nulls     NullsOrder    `json:"nulls,omitempty"`
+}
+
+// Validate checks the direction, null ordering and aggregate of the term
+func (o OrderTerm) Validate() error {
+	switch o.Direction {
+	case "", SortAsc, SortDesc:
+	default:
+		return fmt.Errorf("unsupported direction: %s", o.Direction)
+	}
+
+	switch o.Nulls {
+	case "", NullsFirst, NullsLast:
+	default:
+		return fmt.Errorf("unsupported nulls ordering: %s", o.Nulls)
+	}
+
+	if o.Aggregate == "" && o.Column == "*" {
+		return fmt.Errorf("cannot order by *")
+	}
+	return validateAggregate(o.Aggregate, o.Column)
+}
+
+// Descending reports whether the term sorts in descending order
+func (o OrderTerm) Descending() bool {
+	return o.Direction == SortDesc
+}
+
+// NullsSortLast reports whether NULLs come after every other value for the
+// term. nullsLargest is the database's default: Postgres sorts NULLs as
+// larger than any value, MySQL as smaller.
+func (o OrderTerm) NullsSortLast(nullsLargest bool) bool {
+	switch o.Nulls {
+	case NullsFirst:
+		return false
+	case NullsLast:
+		return true
+	}
+	return nullsLargest != o.Descending()
+}
+
+// ResolveOrderTerm replaces a reference to a projection alias with the
+// projection's own column and aggregate, so engines can render the
+// expression instead of relying on alias resolution in ORDER BY
+func (q *QueryParams) ResolveOrderTerm(term OrderTerm) OrderTerm {
+	if term.Aggregate != "" {
+		return term
+	}
+	for _, p := range q.Projections {
+		if p.Alias != "" && p.Alias == term.Column {
+			term.Column = p.Column
+			term.Aggregate = p.Aggregate
+			return term
+		}
+	}
+	return term
+}
+
+// validateOrderTerm checks a single ORDER BY term. With GROUP BY every term
+// must be a grouped column or an aggregate from the select list.
+func (q *QueryParams) validateOrderTerm(term OrderTerm) error {
+	if err := term.Validate(); err != nil {
+		return err
+	}
+
+	resolved := q.ResolveOrderTerm(term)
+	if resolved.Column != "" && resolved.Column != "*" {
+		if err := q.validateIdentifier(resolved.Column, 3); err != nil {
+			return err
+		}
+	}
+
+	if len(q.GroupBy) == 0 {
+		return nil
+	}
+
+	if resolved.Aggregate == "" {
+		for _, col := range q.GroupBy {
+			if col == resolved.Column {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must appear in group_by", resolved.Column)
+	}
+
+	for _, p := range q.Projections {
+		if p.Aggregate == resolved.Aggregate && sameAggregateColumn(p.Column, resolved.Column) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must appear in projections", AggregateOutputName(resolved.Aggregate, resolved.Column))
+}
+
+// sameAggregateColumn compares aggregate arguments, treating an empty
+// column and * as the same count argument
+func sameAggregateColumn(a, b string) bool {
+	if a == "" {
+		a = "*"
+	}
+	if b == "" {
+		b = "*"
+	}
+	return a == b
+}
@@ -0,0 +1,138 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AggregateFunc defines an aggregate function usable in projections and
+// HAVING conditions
+type AggregateFunc string
+
+const (
+	AggregateCount         AggregateFunc = "count"
+	AggregateCountDistinct AggregateFunc = "count_distinct"
+	AggregateSum           AggregateFunc = "sum"
+	AggregateAvg           AggregateFunc = "avg"
+	AggregateMin           AggregateFunc = "min"
+	AggregateMax           AggregateFunc = "max"
+)
+
+// Projection is a single entry of a structured select list: a plain column
+// or an aggregate over a column, optionally renamed with an alias
+type Projection struct {
+	Column    string        `json:"column,omitempty"`
+	Aggregate AggregateFunc `json:"aggregate,omitempty"`
+	Alias     string        `json:"alias,omitempty"`
+}
+
+// Validate checks that the projection can be rendered in every dialect
+func (p Projection) Validate() error {
+	if err := validateAggregate(p.Aggregate, p.Column); err != nil {
+		return err
+	}
+	if p.Aggregate == "" && p.Column == "*" && p.Alias != "" {
+		return fmt.Errorf("* cannot have an alias")
+	}
+	if p.Alias != "" {
+		if err := ValidateIdentifier(p.Alias, 1); err != nil {
+			return fmt.Errorf("alias: %w", err)
+		}
+	}
+	return nil
+}
+
+// OutputName returns the name the projection has in the result set.
+// Aggregates without an alias are given a predictable name such as
+// "count" or "sum_total", since each database names them differently.
+func (p Projection) OutputName() string {
+	if p.Alias != "" {
+		return p.Alias
+	}
+	if p.Aggregate == "" {
+		parts := strings.Split(p.Column, ".")
+		return parts[len(parts)-1]
+	}
+	return AggregateOutputName(p.Aggregate, p.Column)
+}
+
+// AggregateOutputName returns the default result name of an aggregate
+func AggregateOutputName(aggregate AggregateFunc, column string) string {
+	if column == "" || column == "*" {
+		return string(aggregate)
+	}
+	parts := strings.Split(column, ".")
+	return string(aggregate) + "_" + parts[len(parts)-1]
+}
+
+// validateAggregate checks that column is a valid argument for aggregate.
+// An empty aggregate means column is referenced directly.
+func validateAggregate(aggregate AggregateFunc, column string) error {
+	switch aggregate {
+	case "":
+		if column == "" {
+			return fmt.Errorf("column is required")
+		}
+		if column == "*" {
+			return nil
+		}
+	case AggregateCount:
+		if column == "" || column == "*" {
+			return nil
+		}
+	case AggregateCountDistinct, AggregateSum, AggregateAvg, AggregateMin, AggregateMax:
+		if column == "" || column == "*" {
+			return fmt.Errorf("aggregate %s requires a column", aggregate)
+		}
+	default:
+		return fmt.Errorf("unsupported aggregate: %s", aggregate)
+	}
+	return ValidateIdentifier(column, 3)
+}
+
+// QuoteAggregate renders an aggregate over a column. Aggregate syntax is
+// the same in every supported dialect; only identifier quoting differs.
+func (r IdentifierRules) QuoteAggregate(aggregate AggregateFunc, column string) (string, error) {
+	if err := validateAggregate(aggregate, column); err != nil {
+		return "", err
+	}
+
+	if aggregate == "" {
+		return r.QuoteSelectItem(column)
+	}
+	if aggregate == AggregateCount && (column == "" || column == "*") {
+		return "COUNT(*)", nil
+	}
+
+	quoted, err := r.QuoteColumn(column)
+	if err != nil {
+		return "", err
+	}
+
+	switch aggregate {
+	case AggregateCountDistinct:
+		return fmt.Sprintf("COUNT(DISTINCT %s)", quoted), nil
+	default:
+		return fmt.Sprintf("%s(%s)", strings.ToUpper(string(aggregate)), quoted), nil
+	}
+}
+
+// QuoteProjection renders a projection with its alias
+func (r IdentifierRules) QuoteProjection(p Projection) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	expr, err := r.QuoteAggregate(p.Aggregate, p.Column)
+	if err != nil {
+		return "", err
+	}
+
+	if p.Alias == "" && p.Aggregate == "" {
+		return expr, nil
+	}
+	if err := r.Validate(p.OutputName(), 1); err != nil {
+		return "", err
+	}
+	return expr + " AS " + r.QuotePart(p.OutputName()), nil
+}
@@ -0,0 +1,65 @@
+package types
+
+import "testing"
+
+func TestProjectionOutputName(t *testing.T) {
+	tests := []struct {
+		projection Projection
+		expected   string
+	}{
+		{projection: Projection{Column: "status"}, expected: "status"},
+		{projection: Projection{Column: "orders.status"}, expected: "status"},
+		{projection: Projection{Column: "status", Alias: "state"}, expected: "state"},
+		{projection: Projection{Aggregate: AggregateCount}, expected: "count"},
+		{projection: Projection{Column: "*", Aggregate: AggregateCount}, expected: "count"},
+		{projection: Projection{Column: "orders.total", Aggregate: AggregateSum}, expected: "sum_total"},
+		{projection: Projection{Column: "id", Aggregate: AggregateCountDistinct}, expected: "count_distinct_id"},
+		{projection: Projection{Column: "total", Aggregate: AggregateMax, Alias: "top"}, expected: "top"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.projection.OutputName(); got != tt.expected {
+			t.Errorf("%+v: expected %s, got %s", tt.projection, tt.expected, got)
+		}
+	}
+}
+
+func TestQuoteProjection(t *testing.T) {
+	tests := []struct {
+		name        string
+		projection  Projection
+		expected    string
+		expectError bool
+	}{
+		{name: "column", projection: Projection{Column: "status"}, expected: "`status`"},
+		{name: "star", projection: Projection{Column: "*"}, expected: "*"},
+		{name: "column alias", projection: Projection{Column: "status", Alias: "s"}, expected: "`status` AS `s`"},
+		{name: "count star", projection: Projection{Aggregate: AggregateCount}, expected: "COUNT(*) AS `count`"},
+		{name: "count column", projection: Projection{Column: "id", Aggregate: AggregateCount}, expected: "COUNT(`id`) AS `count_id`"},
+		{name: "count distinct", projection: Projection{Column: "id", Aggregate: AggregateCountDistinct, Alias: "n"}, expected: "COUNT(DISTINCT `id`) AS `n`"},
+		{name: "avg", projection: Projection{Column: "o.total", Aggregate: AggregateAvg}, expected: "AVG(`o`.`total`) AS `avg_total`"},
+		{name: "missing column", projection: Projection{}, expectError: true},
+		{name: "sum star", projection: Projection{Column: "*", Aggregate: AggregateSum}, expectError: true},
+		{name: "star alias", projection: Projection{Column: "*", Alias: "all"}, expectError: true},
+		{name: "qualified alias", projection: Projection{Column: "id", Alias: "a.b"}, expectError: true},
+		{name: "unknown aggregate", projection: Projection{Column: "id", Aggregate: "median"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MySQLIdentifiers.QuoteProjection(tt.projection)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
+	"time"
 )
 
 // ConditionType defines the type of condition operator
@@ -17,27 +20,65 @@ const (
 	ConditionTypeLessThanOrEqual    ConditionType = "less_than_or_equal"
 	ConditionTypeLike               ConditionType = "like"
 	ConditionTypeIn                 ConditionType = "in"
+	ConditionTypeNotIn              ConditionType = "not_in"
 	ConditionTypeIsNull             ConditionType = "is_null"
 	ConditionTypeIsNotNull          ConditionType = "is_not_null"
+	ConditionTypeBetween            ConditionType = "between"
+	ConditionTypeNotLike            ConditionType = "not_like"
+	ConditionTypeILike              ConditionType = "ilike"
+	ConditionTypeStartsWith         ConditionType = "starts_with"
+	ConditionTypeEndsWith           ConditionType = "ends_with"
+	ConditionTypeContains           ConditionType = "contains"
+	ConditionTypeRegex              ConditionType = "regex"
 )
 
-// Condition represents a WHERE or HAVING clause condition
+// MaxConditionDepth limits how deeply condition groups may be nested
+const MaxConditionDepth = 16
+
+// Condition represents a WHERE or HAVING clause condition. Aggregate is
+// only allowed in HAVING, where it applies to Column (or * for count).
+//
+// A condition is either a comparison on Column or a group: All is true when
+// every nested condition holds, Any when at least one does, and Not negates
+// a single nested condition. A group sets exactly one of All, Any and Not
+// and none of the comparison fields.
 type Condition struct {
-	Column string        `json:"column"`
-	Type   ConditionType `json:"type"`
-	Value  interface{}   `json:"value,omitempty"`
+	Column    string        `json:"column,omitempty"`
+	Aggregate AggregateFunc `json:"aggregate,omitempty"`
+	Type      ConditionType `json:"type,omitempty"`
+	Value     interface{}   `json:"value,omitempty"`
+	All       []Condition   `json:"all,omitempty"`
+	Any       []Condition   `json:"any,omitempty"`
+	Not       *Condition    `json:"not,omitempty"`
+}
+
+// IsGroup reports whether the condition combines nested conditions instead
+// of comparing a column
+func (c Condition) IsGroup() bool {
+	return c.All != nil || c.Any != nil || c.Not != nil
 }
 
-// QueryParams represents the DSL query structure matching the Ruby contract
+// QueryParams represents the DSL query structure matching the Ruby contract.
+// Projections is the structured select list. Select is the legacy comma
+// separated form: it is read as a list of column names, or pasted into the
+// query verbatim when the agent is configured with DSL_ALLOW_RAW_SELECT.
+// Offset skips rows from the start of the result; Cursor resumes from the
+// NextCursor of a previous page of the same query.
 type QueryParams struct {
 	DatabaseType string       `json:"database_type,omitempty"`
 	SchemaName   *string      `json:"schema_name,omitempty"`
 	Table        string       `json:"table"`
+	Distinct     bool         `json:"distinct,omitempty"`
+	Projections  []Projection `json:"projections,omitempty"`
 	Select       *string      `json:"select,omitempty"`
 	Conditions   []Condition  `json:"conditions,omitempty"`
 	Limit        *int         `json:"limit,omitempty"`
 	GroupBy      []string     `json:"group_by,omitempty"`
 	Having       []Condition  `json:"having,omitempty"`
+	OrderBy      []OrderTerm  `json:"order_by,omitempty"`
+	Offset       *int         `json:"offset,omitempty"`
+	Cursor       string       `json:"cursor,omitempty"`
+	ResultFormat ResultFormat `json:"result_format,omitempty"`
 }
 
 // Validate checks if the query parameters are valid
@@ -46,27 +87,304 @@ func (q *QueryParams) Validate() error {
 		return fmt.Errorf("table is required")
 	}
 
+	if q.SchemaName != nil && *q.SchemaName != "" {
+		if err := q.validateIdentifier(*q.SchemaName, 1); err != nil {
+			return fmt.Errorf("schema_name: %w", err)
+		}
+		if err := q.validateIdentifier(q.Table, 1); err != nil {
+			return fmt.Errorf("table: %w", err)
+		}
+	} else if err := q.validateIdentifier(q.Table, 2); err != nil {
+		return fmt.Errorf("table: %w", err)
+	}
+
+	if len(q.Projections) > 0 && q.Select != nil && *q.Select != "" {
+		return fmt.Errorf("projections and select cannot be combined")
+	}
+
+	for i, p := range q.Projections {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("projections[%d]: %w", i, err)
+		}
+		if p.Column != "" && p.Column != "*" {
+			if err := q.validateIdentifier(p.Column, 3); err != nil {
+				return fmt.Errorf("projections[%d]: %w", i, err)
+			}
+		}
+		if p.Alias != "" || p.Aggregate != "" {
+			if err := q.validateIdentifier(p.OutputName(), 1); err != nil {
+				return fmt.Errorf("projections[%d]: alias: %w", i, err)
+			}
+		}
+	}
+
+	// The select string is not split here: with DSL_ALLOW_RAW_SELECT it is
+	// an SQL expression list checked by sqlguard, otherwise the engine
+	// quotes each item as a column name
+
 	for i, cond := range q.Conditions {
-		if cond.Column == "" {
-			return fmt.Errorf("condition[%d]: column is required", i)
+		if err := q.validateCondition(cond, false, 1); err != nil {
+			return fmt.Errorf("condition[%d]: %w", i, err)
 		}
-		if cond.Type == "" {
-			return fmt.Errorf("condition[%d]: type is required", i)
+	}
+
+	for i, col := range q.GroupBy {
+		if err := q.validateIdentifier(col, 3); err != nil {
+			return fmt.Errorf("group_by[%d]: %w", i, err)
 		}
 	}
 
 	for i, cond := range q.Having {
-		if cond.Column == "" {
-			return fmt.Errorf("having[%d]: column is required", i)
+		if err := q.validateCondition(cond, true, 1); err != nil {
+			return fmt.Errorf("having[%d]: %w", i, err)
+		}
+	}
+
+	for i, term := range q.OrderBy {
+		if err := q.validateOrderTerm(term); err != nil {
+			return fmt.Errorf("order_by[%d]: %w", i, err)
+		}
+	}
+
+	if q.Offset != nil && *q.Offset < 0 {
+		return fmt.Errorf("offset cannot be negative")
+	}
+	if q.Cursor != "" {
+		if q.Offset != nil {
+			return fmt.Errorf("offset and cursor cannot be combined")
+		}
+		if _, err := q.PageCursor(); err != nil {
+			return fmt.Errorf("cursor: %w", err)
+		}
+	}
+
+	if err := q.ResultFormat.Validate(); err != nil {
+		return fmt.Errorf("result_format: %w", err)
+	}
+
+	return nil
+}
+
+// validateCondition checks a single condition or, recursively, a condition
+// group. Aggregates are only accepted when having is set.
+func (q *QueryParams) validateCondition(cond Condition, having bool, depth int) error {
+	if depth > MaxConditionDepth {
+		return fmt.Errorf("conditions are nested more than %d levels deep", MaxConditionDepth)
+	}
+
+	if cond.IsGroup() {
+		return q.validateConditionGroup(cond, having, depth)
+	}
+
+	if cond.Aggregate != "" && !having {
+		return fmt.Errorf("aggregates are only allowed in having")
+	}
+	if cond.Column == "" && !(having && cond.Aggregate == AggregateCount) {
+		return fmt.Errorf("column is required")
+	}
+	if cond.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+	if err := validateAggregate(cond.Aggregate, cond.Column); err != nil {
+		return err
+	}
+	if cond.Column == "*" && cond.Aggregate == "" {
+		return fmt.Errorf("* requires the count aggregate")
+	}
+	if cond.Column != "" && cond.Column != "*" {
+		if err := q.validateIdentifier(cond.Column, 3); err != nil {
+			return err
+		}
+	}
+	if err := validateConditionValue(cond); err != nil {
+		return fmt.Errorf("%s: %w", cond.Type, err)
+	}
+
+	return nil
+}
+
+// validateConditionValue checks that the value has the shape the operator
+// expects
+func validateConditionValue(cond Condition) error {
+	switch cond.Type {
+	case ConditionTypeEqual, ConditionTypeNotEqual,
+		ConditionTypeGreaterThan, ConditionTypeGreaterThanOrEqual,
+		ConditionTypeLessThan, ConditionTypeLessThanOrEqual:
+		if !isScalar(cond.Value) {
+			return fmt.Errorf("value must be a string, number or boolean")
+		}
+
+	case ConditionTypeLike, ConditionTypeNotLike, ConditionTypeILike,
+		ConditionTypeStartsWith, ConditionTypeEndsWith, ConditionTypeContains:
+		if _, ok := cond.Value.(string); !ok {
+			return fmt.Errorf("value must be a string")
+		}
+
+	case ConditionTypeRegex:
+		if pattern, ok := cond.Value.(string); !ok || pattern == "" {
+			return fmt.Errorf("value must be a non-empty string")
+		}
+
+	case ConditionTypeIn, ConditionTypeNotIn:
+		if _, err := ListValues(cond.Value); err != nil {
+			return err
+		}
+
+	case ConditionTypeBetween:
+		if _, _, err := BetweenValues(cond.Value); err != nil {
+			return err
+		}
+
+	case ConditionTypeIsNull, ConditionTypeIsNotNull:
+		if cond.Value != nil {
+			return fmt.Errorf("value is not allowed")
+		}
+
+	default:
+		return fmt.Errorf("unsupported condition type")
+	}
+
+	return nil
+}
+
+// BetweenValues returns the inclusive bounds of a between condition, given
+// as a two element array
+func BetweenValues(value interface{}) (interface{}, interface{}, error) {
+	values, err := ListValues(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(values) != 2 {
+		return nil, nil, fmt.Errorf("value must have exactly 2 elements, got %d", len(values))
+	}
+	return values[0], values[1], nil
+}
+
+// LikePattern builds the LIKE pattern of a starts_with, ends_with or
+// contains condition, escaping the wildcards in the value
+func LikePattern(cond Condition) (string, error) {
+	value, ok := cond.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("value must be a string")
+	}
+
+	escaped := EscapeLike(value)
+	switch cond.Type {
+	case ConditionTypeStartsWith:
+		return escaped + "%", nil
+	case ConditionTypeEndsWith:
+		return "%" + escaped, nil
+	case ConditionTypeContains:
+		return "%" + escaped + "%", nil
+	}
+	return "", fmt.Errorf("%s is not a pattern condition", cond.Type)
+}
+
+// EscapeLike escapes %, _ and the escape character itself so value matches
+// literally in a LIKE pattern. The engines name backslash as the escape
+// character with an explicit ESCAPE clause.
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListValues returns the elements of an in or not_in condition value. The
+// value must be an array of non-null scalars: JSON payloads decode arrays to
+// []interface{}, while Go callers may pass any slice such as []string.
+func ListValues(value interface{}) ([]interface{}, error) {
+	if value == nil {
+		return nil, fmt.Errorf("value must be an array")
+	}
+
+	v := reflect.ValueOf(value)
+	if (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, fmt.Errorf("value must be an array")
+	}
+
+	values := make([]interface{}, v.Len())
+	for i := range values {
+		elem := v.Index(i).Interface()
+		if !isScalar(elem) {
+			return nil, fmt.Errorf("value[%d] must be a string, number or boolean", i)
 		}
-		if cond.Type == "" {
-			return fmt.Errorf("having[%d]: type is required", i)
+		values[i] = elem
+	}
+
+	return values, nil
+}
+
+// isScalar reports whether v is a non-null string, number, boolean or time
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case nil:
+		return false
+	case json.Number, time.Time:
+		return true
+	}
+
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// validateConditionGroup checks that exactly one of all, any and not is set
+// and validates the nested conditions
+func (q *QueryParams) validateConditionGroup(cond Condition, having bool, depth int) error {
+	if cond.Column != "" || cond.Aggregate != "" || cond.Type != "" || cond.Value != nil {
+		return fmt.Errorf("condition groups cannot also set column, aggregate, type or value")
+	}
+
+	set := 0
+	for _, ok := range []bool{cond.All != nil, cond.Any != nil, cond.Not != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("condition groups must set exactly one of all, any and not")
+	}
+
+	if cond.Not != nil {
+		if err := q.validateCondition(*cond.Not, having, depth+1); err != nil {
+			return fmt.Errorf("not: %w", err)
+		}
+		return nil
+	}
+
+	name, children := "all", cond.All
+	if cond.Any != nil {
+		name, children = "any", cond.Any
+	}
+	if len(children) == 0 {
+		return fmt.Errorf("%s requires at least one condition", name)
+	}
+	for i, child := range children {
+		if err := q.validateCondition(child, having, depth+1); err != nil {
+			return fmt.Errorf("%s[%d]: %w", name, i, err)
 		}
 	}
 
 	return nil
 }
 
+// validateIdentifier applies the dialect independent identifier checks and,
+// when the database type is known, that dialect's own limits
+func (q *QueryParams) validateIdentifier(name string, maxParts int) error {
+	if err := ValidateIdentifier(name, maxParts); err != nil {
+		return err
+	}
+	if rules, ok := IdentifierRulesFor(q.DatabaseType); ok {
+		return rules.Validate(name, maxParts)
+	}
+	return nil
+}
+
 // ParseQueryParams parses JSON payload into QueryParams
 func ParseQueryParams(payloadJSON string) (*QueryParams, error) {
 	var params QueryParams
@@ -84,8 +402,17 @@ func ParseQueryParams(payloadJSON string) (*QueryParams, error) {
 // QueryResult represents a single row result
 type QueryResult map[string]interface{}
 
-// QueryResponse represents the full query response
+// QueryResponse represents the full query response. NextCursor is set when
+// a DSL query filled its limit and can be resumed by passing it back as
+// QueryParams.Cursor. Columns are only sent in ResultFormatColumnar.
 type QueryResponse struct {
-	Rows    []QueryResult `json:"rows"`
-	RowCount int          `json:"row_count"`
+	Columns    []ResultColumn `json:"-"`
+	Rows       []QueryResult  `json:"rows"`
+	RowCount   int            `json:"row_count"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+
+	// Values holds the rows in column order, which unlike Rows keeps every
+	// column when several share a name. It is nil for responses built from
+	// Rows alone.
+	Values [][]interface{} `json:"-"`
 }
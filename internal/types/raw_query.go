@@ -0,0 +1,40 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RawQueryParams represents a hand-written read-only SQL query with
+// positional parameters ($1.. on Postgres, ? on MySQL)
+type RawQueryParams struct {
+	SQL          string        `json:"sql"`
+	Params       []interface{} `json:"params,omitempty"`
+	ResultFormat ResultFormat  `json:"result_format,omitempty"`
+}
+
+// Validate checks if the raw query parameters are valid. Statement
+// classification is dialect specific and left to the engine.
+func (p *RawQueryParams) Validate() error {
+	if p.SQL == "" {
+		return fmt.Errorf("sql is required")
+	}
+	if err := p.ResultFormat.Validate(); err != nil {
+		return fmt.Errorf("result_format: %w", err)
+	}
+	return nil
+}
+
+// ParseRawQueryParams parses JSON payload into RawQueryParams
+func ParseRawQueryParams(payloadJSON string) (*RawQueryParams, error) {
+	var params RawQueryParams
+	if err := json.Unmarshal([]byte(payloadJSON), &params); err != nil {
+		return nil, fmt.Errorf("failed to parse raw query params: %w", err)
+	}
+
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid raw query params: %w", err)
+	}
+
+	return &params, nil
+}
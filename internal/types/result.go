@@ -0,0 +1,103 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// ResultFormat selects how the rows of a query result are encoded
+type ResultFormat string
+
+const (
+	// ResultFormatRows encodes each row as an object keyed by column name.
+	// It is the default.
+	ResultFormatRows ResultFormat = "rows"
+	// ResultFormatColumnar sends the ordered result columns once, followed
+	// by each row as an array of values in column order
+	ResultFormatColumnar ResultFormat = "columnar"
+	// ResultFormatArrow encodes the result as an Apache Arrow IPC stream,
+	// which is binary and only delivered through result uploads
+	ResultFormatArrow ResultFormat = "arrow"
+)
+
+// Validate checks that f is a known format or empty
+func (f ResultFormat) Validate() error {
+	switch f {
+	case "", ResultFormatRows, ResultFormatColumnar, ResultFormatArrow:
+		return nil
+	default:
+		return fmt.Errorf("unknown result format %q", f)
+	}
+}
+
+// ResultColumn describes a column of a query result, as reported by the
+// driver
+type ResultColumn struct {
+	Name string `json:"name"`
+	// NativeType is the database type name, such as INT4 or VARCHAR
+	NativeType  string      `json:"native_type"`
+	LogicalType LogicalType `json:"logical_type"`
+	// Nullable is nil when the driver does not know
+	Nullable *bool `json:"nullable,omitempty"`
+
+	// Kind selects how values are normalized, and ElemKind how the
+	// elements of arrays are
+	Kind     ValueKind `json:"-"`
+	ElemKind ValueKind `json:"-"`
+	// Location is the zone of timestamps returned as text without one,
+	// UTC when nil
+	Location *time.Location `json:"-"`
+}
+
+// ColumnarResponse is a query result in ResultFormatColumnar
+type ColumnarResponse struct {
+	Columns    []ResultColumn  `json:"columns"`
+	Rows       [][]interface{} `json:"rows"`
+	RowCount   int             `json:"row_count"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// RowObject returns a row in ResultFormatRows, keyed by column name. Of the
+// columns sharing a name, the last one is kept.
+func RowObject(columns []ResultColumn, values []interface{}) QueryResult {
+	row := make(QueryResult, len(columns))
+	for i, col := range columns {
+		if i < len(values) {
+			row[col.Name] = values[i]
+		}
+	}
+	return row
+}
+
+// RowValues returns the values of row in the order of columns. Columns
+// sharing a name all get the same value.
+func RowValues(row QueryResult, columns []ResultColumn) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, col := range columns {
+		values[i] = row[col.Name]
+	}
+	return values
+}
+
+// Columnar returns r in ResultFormatColumnar
+func (r *QueryResponse) Columnar() *ColumnarResponse {
+	columns := r.Columns
+	if columns == nil {
+		columns = []ResultColumn{}
+	}
+
+	rows := r.Values
+	if rows == nil {
+		rows = make([][]interface{}, len(r.Rows))
+		for i, row := range r.Rows {
+			rows[i] = RowValues(row, columns)
+		}
+	}
+
+	return &ColumnarResponse{
+		Columns:    columns,
+		Rows:       rows,
+		RowCount:   r.RowCount,
+		NextCursor: r.NextCursor,
+	}
+}
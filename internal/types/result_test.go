@@ -0,0 +1,65 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestColumnar(t *testing.T) {
+	resp := &QueryResponse{
+		Columns:  []ResultColumn{{Name: "name"}, {Name: "id"}},
+		Rows:     []QueryResult{{"id": 1, "name": "a"}, {"id": 2, "name": nil}},
+		RowCount: 2,
+	}
+
+	columnar := resp.Columnar()
+	if columnar.RowCount != 2 || len(columnar.Rows) != 2 {
+		t.Fatalf("unexpected result: %+v", columnar)
+	}
+	if columnar.Rows[0][0] != "a" || columnar.Rows[0][1] != 1 || columnar.Rows[1][0] != nil {
+		t.Errorf("unexpected rows: %v", columnar.Rows)
+	}
+
+	// The rows format is unchanged
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"rows":[{"id":1,"name":"a"},{"id":2,"name":null}],"row_count":2}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestColumnarDuplicateNames(t *testing.T) {
+	collector := &RowCollector{}
+	if err := collector.WriteHeader([]ResultColumn{{Name: "id"}, {Name: "id"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := collector.WriteRow([]interface{}{1, 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp := collector.Response()
+
+	// Every column keeps its own value in the columnar format
+	columnar := resp.Columnar()
+	if len(columnar.Rows) != 1 || columnar.Rows[0][0] != 1 || columnar.Rows[0][1] != 2 {
+		t.Errorf("unexpected rows: %v", columnar.Rows)
+	}
+
+	// Row objects can only hold one of them
+	if len(resp.Rows) != 1 || resp.Rows[0]["id"] != 2 {
+		t.Errorf("unexpected row objects: %v", resp.Rows)
+	}
+}
+
+func TestResultFormatValidate(t *testing.T) {
+	params := &RawQueryParams{SQL: "SELECT 1", ResultFormat: ResultFormatColumnar}
+	if err := params.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	params.ResultFormat = "parquet"
+	if err := params.Validate(); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
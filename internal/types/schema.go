@@ -0,0 +1,172 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TableType defines the kind of relation reported by a schema refresh
+type TableType string
+
+const (
+	TableTypeTable            TableType = "table"
+	TableTypeView             TableType = "view"
+	TableTypeMaterializedView TableType = "materialized_view"
+	TableTypeForeignTable     TableType = "foreign_table"
+)
+
+// ColumnDescription describes a single column of a table or view
+type ColumnDescription struct {
+	Name     string  `json:"name"`
+	DataType string  `json:"data_type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default"`
+	Comment  *string `json:"comment"`
+}
+
+// TableDescription describes a table or view and its columns
+type TableDescription struct {
+	Name    string              `json:"name"`
+	Type    TableType           `json:"type"`
+	Comment *string             `json:"comment"`
+	Columns []ColumnDescription `json:"columns"`
+}
+
+// SchemaDescription describes a schema and the relations it contains
+type SchemaDescription struct {
+	Name    string             `json:"name"`
+	Comment *string            `json:"comment"`
+	Tables  []TableDescription `json:"tables"`
+}
+
+// SchemaResponse represents the full result of a schema refresh
+type SchemaResponse struct {
+	Schemas []SchemaDescription `json:"schemas"`
+}
+
+// SchemaBuilder assembles flat catalog rows into a SchemaResponse.
+// Rows may arrive in any order; schemas, tables and columns keep the
+// order in which they were first seen.
+type SchemaBuilder struct {
+	schemas     []SchemaDescription
+	schemaIndex map[string]int
+	tableIndex  map[string]map[string]int
+}
+
+// NewSchemaBuilder creates an empty SchemaBuilder
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{
+		schemas:     []SchemaDescription{},
+		schemaIndex: make(map[string]int),
+		tableIndex:  make(map[string]map[string]int),
+	}
+}
+
+// AddSchema registers a schema, even if it turns out to contain no tables
+func (b *SchemaBuilder) AddSchema(name string, comment *string) {
+	if i, ok := b.schemaIndex[name]; ok {
+		if comment != nil {
+			b.schemas[i].Comment = comment
+		}
+		return
+	}
+	b.schemaIndex[name] = len(b.schemas)
+	b.tableIndex[name] = make(map[string]int)
+	b.schemas = append(b.schemas, SchemaDescription{
+		Name:    name,
+		Comment: comment,
+		Tables:  []TableDescription{},
+	})
+}
+
+// AddTable registers a table or view within a schema
+func (b *SchemaBuilder) AddTable(schema string, table TableDescription) {
+	b.AddSchema(schema, nil)
+	s := &b.schemas[b.schemaIndex[schema]]
+	if _, ok := b.tableIndex[schema][table.Name]; ok {
+		return
+	}
+	if table.Columns == nil {
+		table.Columns = []ColumnDescription{}
+	}
+	b.tableIndex[schema][table.Name] = len(s.Tables)
+	s.Tables = append(s.Tables, table)
+}
+
+// AddColumn appends a column to a previously registered table
+func (b *SchemaBuilder) AddColumn(schema, table string, column ColumnDescription) {
+	b.AddTable(schema, TableDescription{Name: table, Type: TableTypeTable})
+	s := &b.schemas[b.schemaIndex[schema]]
+	t := &s.Tables[b.tableIndex[schema][table]]
+	t.Columns = append(t.Columns, column)
+}
+
+// Response returns the assembled SchemaResponse
+func (b *SchemaBuilder) Response() *SchemaResponse {
+	return &SchemaResponse{Schemas: b.schemas}
+}
+
+// LogicalType is an engine independent classification of a column type
+type LogicalType string
+
+const (
+	LogicalTypeString  LogicalType = "string"
+	LogicalTypeInt     LogicalType = "int"
+	LogicalTypeDecimal LogicalType = "decimal"
+	LogicalTypeTime    LogicalType = "time"
+	LogicalTypeBool    LogicalType = "bool"
+	LogicalTypeJSON    LogicalType = "json"
+	LogicalTypeBinary  LogicalType = "binary"
+)
+
+// FetchColumnsParams represents the payload of a fetch columns job
+type FetchColumnsParams struct {
+	SchemaName *string `json:"schema_name,omitempty"`
+	Table      string  `json:"table"`
+}
+
+// Validate checks if the fetch columns parameters are valid
+func (p *FetchColumnsParams) Validate() error {
+	if p.Table == "" {
+		return fmt.Errorf("table is required")
+	}
+	if p.SchemaName != nil && *p.SchemaName == "" {
+		return fmt.Errorf("schema_name must not be empty")
+	}
+	return nil
+}
+
+// ParseFetchColumnsParams parses JSON payload into FetchColumnsParams
+func ParseFetchColumnsParams(payloadJSON string) (*FetchColumnsParams, error) {
+	var params FetchColumnsParams
+	if err := json.Unmarshal([]byte(payloadJSON), &params); err != nil {
+		return nil, fmt.Errorf("failed to parse fetch columns params: %w", err)
+	}
+
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fetch columns params: %w", err)
+	}
+
+	return &params, nil
+}
+
+// ColumnDetail describes a column with the metadata needed to build filters
+type ColumnDetail struct {
+	Name               string      `json:"name"`
+	Position           int         `json:"position"`
+	NativeType         string      `json:"native_type"`
+	LogicalType        LogicalType `json:"logical_type"`
+	Nullable           bool        `json:"nullable"`
+	Default            *string     `json:"default"`
+	PrimaryKey         bool        `json:"primary_key"`
+	CharacterMaxLength *int64      `json:"character_max_length"`
+	NumericPrecision   *int64      `json:"numeric_precision"`
+	NumericScale       *int64      `json:"numeric_scale"`
+}
+
+// ColumnsResponse represents the ordered columns of a single table
+type ColumnsResponse struct {
+	SchemaName string         `json:"schema_name"`
+	Table      string         `json:"table"`
+	Columns    []ColumnDetail `json:"columns"`
+}
@@ -0,0 +1,86 @@
+package types
+
+import "testing"
+
+func TestSchemaBuilder(t *testing.T) {
+	comment := "main schema"
+
+	b := NewSchemaBuilder()
+	b.AddSchema("public", &comment)
+	b.AddSchema("empty", nil)
+	b.AddTable("public", TableDescription{Name: "users", Type: TableTypeTable})
+	b.AddColumn("public", "users", ColumnDescription{Name: "id", DataType: "integer"})
+	b.AddColumn("public", "users", ColumnDescription{Name: "email", DataType: "text", Nullable: true})
+	b.AddColumn("sales", "orders", ColumnDescription{Name: "id", DataType: "bigint"})
+	b.AddTable("public", TableDescription{Name: "users", Type: TableTypeView})
+
+	resp := b.Response()
+
+	if len(resp.Schemas) != 3 {
+		t.Fatalf("expected 3 schemas, got %d", len(resp.Schemas))
+	}
+
+	names := []string{resp.Schemas[0].Name, resp.Schemas[1].Name, resp.Schemas[2].Name}
+	expected := []string{"public", "empty", "sales"}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("schema order mismatch: expected %v, got %v", expected, names)
+			break
+		}
+	}
+
+	public := resp.Schemas[0]
+	if public.Comment == nil || *public.Comment != comment {
+		t.Errorf("expected schema comment %q, got %v", comment, public.Comment)
+	}
+	if len(public.Tables) != 1 {
+		t.Fatalf("expected 1 table, got %d", len(public.Tables))
+	}
+	if public.Tables[0].Type != TableTypeTable {
+		t.Errorf("re-adding a table should not change it, got type %s", public.Tables[0].Type)
+	}
+	if len(public.Tables[0].Columns) != 2 {
+		t.Errorf("expected 2 columns, got %d", len(public.Tables[0].Columns))
+	}
+
+	if resp.Schemas[1].Tables == nil {
+		t.Error("expected empty schema to have a non-nil table list")
+	}
+
+	sales := resp.Schemas[2]
+	if len(sales.Tables) != 1 || len(sales.Tables[0].Columns) != 1 {
+		t.Errorf("expected implicitly created table with one column, got %+v", sales.Tables)
+	}
+}
+
+func TestParseFetchColumnsParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		expectError bool
+	}{
+		{name: "table only", payload: `{"table": "users"}`},
+		{name: "with schema", payload: `{"schema_name": "public", "table": "users"}`},
+		{name: "missing table", payload: `{"schema_name": "public"}`, expectError: true},
+		{name: "empty schema", payload: `{"schema_name": "", "table": "users"}`, expectError: true},
+		{name: "invalid json", payload: `{"table": `, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := ParseFetchColumnsParams(tt.payload)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if params.Table != "users" {
+				t.Errorf("expected table=users, got %s", params.Table)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package types
+
+// RowWriter receives a query result one row at a time, so results can be
+// forwarded without being held in memory. WriteHeader is called once with
+// the result columns before any row, and each row has one value per column
+// in the same order, so columns sharing a name keep their own values.
+type RowWriter interface {
+	WriteHeader(columns []ResultColumn) error
+	WriteRow(values []interface{}) error
+}
+
+// StreamSummary describes a result that was written to a RowWriter
+type StreamSummary struct {
+	RowCount   int    `json:"row_count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// RowCollector is a RowWriter that keeps every row, for callers that need
+// the whole result as a QueryResponse
+type RowCollector struct {
+	Columns []ResultColumn
+	Rows    [][]interface{}
+}
+
+// WriteHeader implements RowWriter
+func (c *RowCollector) WriteHeader(columns []ResultColumn) error {
+	c.Columns = columns
+	return nil
+}
+
+// WriteRow implements RowWriter
+func (c *RowCollector) WriteRow(values []interface{}) error {
+	c.Rows = append(c.Rows, values)
+	return nil
+}
+
+// Response returns the collected rows as a QueryResponse
+func (c *RowCollector) Response() *QueryResponse {
+	rows := make([]QueryResult, len(c.Rows))
+	for i, values := range c.Rows {
+		rows[i] = RowObject(c.Columns, values)
+	}
+
+	values := c.Rows
+	if values == nil {
+		values = [][]interface{}{}
+	}
+	return &QueryResponse{
+		Columns:  c.Columns,
+		Rows:     rows,
+		Values:   values,
+		RowCount: len(rows),
+	}
+}
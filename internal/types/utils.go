@@ -12,6 +12,18 @@ func JoinWithAnd(clauses []string) string {
 	return result
 }
 
+// JoinWithOr joins SQL clauses with OR operator
+func JoinWithOr(clauses []string) string {
+	result := ""
+	for i, clause := range clauses {
+		if i > 0 {
+			result += " OR "
+		}
+		result += clause
+	}
+	return result
+}
+
 // JoinColumns joins column names with commas
 func JoinColumns(columns []string) string {
 	result := ""
@@ -40,6 +40,39 @@ func TestJoinWithAnd(t *testing.T) {
 	}
 }
 
+func TestJoinWithOr(t *testing.T) {
+	tests := []struct {
+		name     string
+		clauses  []string
+		expected string
+	}{
+		{
+			name:     "empty clauses",
+			clauses:  []string{},
+			expected: "",
+		},
+		{
+			name:     "single clause",
+			clauses:  []string{"id = 1"},
+			expected: "id = 1",
+		},
+		{
+			name:     "multiple clauses",
+			clauses:  []string{"status = 'a'", "(status = 'b' AND total > 10)"},
+			expected: "status = 'a' OR (status = 'b' AND total > 10)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := JoinWithOr(tt.clauses)
+			if result != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestJoinColumns(t *testing.T) {
 	tests := []struct {
 		name     string
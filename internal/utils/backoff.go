@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff computes retry delays that double from Base up to Max after each
+// failed attempt. Each delay is randomized between half and all of its
+// value so that agents failing together do not retry together.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	attempt int
+}
+
+// Next returns the delay before the next attempt
+func (b *Backoff) Next() time.Duration {
+	d := b.Base
+	for i := 0; i < b.attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	b.attempt++
+
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// Reset starts over from the base delay after a successful attempt
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
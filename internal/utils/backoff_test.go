@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &Backoff{Base: time.Second, Max: 5 * time.Second}
+
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		d := b.Next()
+		if d < expected/2 || d > expected {
+			t.Errorf("delay %s outside of [%s, %s]", d, expected/2, expected)
+		}
+	}
+
+	b.Reset()
+	if d := b.Next(); d > time.Second {
+		t.Errorf("delay %s after reset exceeds the base delay", d)
+	}
+}
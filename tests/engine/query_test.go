@@ -158,6 +158,375 @@ func TestQueryParamsValidate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "type is required",
 		},
+		{
+			name: "qualified identifiers",
+			params: types.QueryParams{
+				Table:   "sales.orders",
+				Select:  stringPtr("orders.*, customers.name"),
+				GroupBy: []string{"orders.status"},
+			},
+			expectError: false,
+		},
+		{
+			name: "table with control character",
+			params: types.QueryParams{
+				Table: "users\n; DROP TABLE users",
+			},
+			expectError: true,
+			errorMsg:    "control characters",
+		},
+		{
+			name: "qualified table with schema",
+			params: types.QueryParams{
+				Table:      "public.users",
+				SchemaName: stringPtr("public"),
+			},
+			expectError: true,
+			errorMsg:    "too many parts",
+		},
+		{
+			name: "select expressions left to the engine",
+			params: types.QueryParams{
+				Table:  "users",
+				Select: stringPtr("COALESCE(nickname, name), replace(note, ',,', ',')"),
+			},
+			expectError: false,
+		},
+		{
+			name: "condition column with empty part",
+			params: types.QueryParams{
+				Table: "users",
+				Conditions: []types.Condition{
+					{Column: "users..id", Type: types.ConditionTypeEqual, Value: 1},
+				},
+			},
+			expectError: true,
+			errorMsg:    "empty name part",
+		},
+		{
+			name: "mysql rejects trailing space",
+			params: types.QueryParams{
+				DatabaseType: "mysql",
+				Table:        "users",
+				GroupBy:      []string{"status "},
+			},
+			expectError: true,
+			errorMsg:    "ends with a space",
+		},
+		{
+			name: "structured projections",
+			params: types.QueryParams{
+				Table: "orders",
+				Projections: []types.Projection{
+					{Column: "status"},
+					{Aggregate: types.AggregateCount, Alias: "orders"},
+				},
+				GroupBy: []string{"status"},
+				Having: []types.Condition{
+					{Aggregate: types.AggregateCount, Type: types.ConditionTypeGreaterThan, Value: 1},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "projections combined with select",
+			params: types.QueryParams{
+				Table:       "orders",
+				Select:      stringPtr("id"),
+				Projections: []types.Projection{{Column: "status"}},
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined",
+		},
+		{
+			name: "aggregate in where condition",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "total", Aggregate: types.AggregateSum, Type: types.ConditionTypeGreaterThan, Value: 1},
+				},
+			},
+			expectError: true,
+			errorMsg:    "only allowed in having",
+		},
+		{
+			name: "having star without aggregate",
+			params: types.QueryParams{
+				Table: "orders",
+				Having: []types.Condition{
+					{Column: "*", Type: types.ConditionTypeGreaterThan, Value: 1},
+				},
+			},
+			expectError: true,
+			errorMsg:    "requires the count aggregate",
+		},
+		{
+			name: "nested condition groups",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Any: []types.Condition{
+						{Column: "status", Type: types.ConditionTypeEqual, Value: "a"},
+						{Not: &types.Condition{Column: "total", Type: types.ConditionTypeLessThan, Value: 10}},
+					}},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "empty condition group",
+			params: types.QueryParams{
+				Table:      "orders",
+				Conditions: []types.Condition{{All: []types.Condition{}}},
+			},
+			expectError: true,
+			errorMsg:    "all requires at least one condition",
+		},
+		{
+			name: "group with column",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "status", Any: []types.Condition{{Column: "id", Type: types.ConditionTypeIsNull}}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "cannot also set column",
+		},
+		{
+			name: "group with all and any",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{{
+					All: []types.Condition{{Column: "id", Type: types.ConditionTypeIsNull}},
+					Any: []types.Condition{{Column: "id", Type: types.ConditionTypeIsNotNull}},
+				}},
+			},
+			expectError: true,
+			errorMsg:    "exactly one of all, any and not",
+		},
+		{
+			name: "invalid condition inside group",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Any: []types.Condition{
+						{Column: "status", Type: types.ConditionTypeEqual, Value: "a"},
+						{Column: "status"},
+					}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "condition[0]: any[1]: type is required",
+		},
+		{
+			name: "aggregate inside where group",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Not: &types.Condition{Aggregate: types.AggregateCount, Type: types.ConditionTypeGreaterThan, Value: 1}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "only allowed in having",
+		},
+		{
+			name: "order by columns",
+			params: types.QueryParams{
+				Table: "users",
+				OrderBy: []types.OrderTerm{
+					{Column: "created_at", Direction: types.SortDesc, Nulls: types.NullsLast},
+					{Column: "id"},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "order by unsupported nulls ordering",
+			params: types.QueryParams{
+				Table:   "users",
+				OrderBy: []types.OrderTerm{{Column: "id", Nulls: "middle"}},
+			},
+			expectError: true,
+			errorMsg:    "unsupported nulls ordering",
+		},
+		{
+			name: "order by column outside group by",
+			params: types.QueryParams{
+				Table:       "orders",
+				Projections: []types.Projection{{Column: "status"}, {Aggregate: types.AggregateCount}},
+				GroupBy:     []string{"status"},
+				OrderBy:     []types.OrderTerm{{Column: "total"}},
+			},
+			expectError: true,
+			errorMsg:    "order_by[0]: total must appear in group_by",
+		},
+		{
+			name: "order by aggregate from projections",
+			params: types.QueryParams{
+				Table:       "orders",
+				Projections: []types.Projection{{Column: "status"}, {Aggregate: types.AggregateCount}},
+				GroupBy:     []string{"status"},
+				OrderBy:     []types.OrderTerm{{Column: "*", Aggregate: types.AggregateCount, Direction: types.SortDesc}},
+			},
+			expectError: false,
+		},
+		{
+			name: "order by aggregate missing from projections",
+			params: types.QueryParams{
+				Table:       "orders",
+				Projections: []types.Projection{{Column: "status"}, {Aggregate: types.AggregateCount}},
+				GroupBy:     []string{"status"},
+				OrderBy:     []types.OrderTerm{{Column: "total", Aggregate: types.AggregateSum}},
+			},
+			expectError: true,
+			errorMsg:    "sum_total must appear in projections",
+		},
+		{
+			name: "negative offset",
+			params: types.QueryParams{
+				Table:  "users",
+				Offset: intPtr(-1),
+			},
+			expectError: true,
+			errorMsg:    "offset cannot be negative",
+		},
+		{
+			name: "offset and cursor",
+			params: types.QueryParams{
+				Table:  "users",
+				Offset: intPtr(10),
+				Cursor: "eyJxIjoiIn0",
+			},
+			expectError: true,
+			errorMsg:    "offset and cursor cannot be combined",
+		},
+		{
+			name: "malformed cursor",
+			params: types.QueryParams{
+				Table:  "users",
+				Cursor: "not a cursor",
+			},
+			expectError: true,
+			errorMsg:    "malformed cursor",
+		},
+		{
+			name: "in with array value",
+			params: types.QueryParams{
+				Table: "users",
+				Conditions: []types.Condition{
+					{Column: "id", Type: types.ConditionTypeNotIn, Value: []interface{}{float64(1), "2"}},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "in with scalar value",
+			params: types.QueryParams{
+				Table: "users",
+				Conditions: []types.Condition{
+					{Column: "id", Type: types.ConditionTypeIn, Value: float64(1)},
+				},
+			},
+			expectError: true,
+			errorMsg:    "in: value must be an array",
+		},
+		{
+			name: "not in with null element",
+			params: types.QueryParams{
+				Table: "users",
+				Conditions: []types.Condition{
+					{Column: "id", Type: types.ConditionTypeNotIn, Value: []interface{}{float64(1), nil}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "value[1] must be a string, number or boolean",
+		},
+		{
+			name: "between with two bounds",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "total", Type: types.ConditionTypeBetween, Value: []interface{}{float64(1), float64(5)}},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "between with three bounds",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "total", Type: types.ConditionTypeBetween, Value: []interface{}{float64(1), float64(2), float64(3)}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "between: value must have exactly 2 elements",
+		},
+		{
+			name: "starts with non string",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "code", Type: types.ConditionTypeStartsWith, Value: true},
+				},
+			},
+			expectError: true,
+			errorMsg:    "starts_with: value must be a string",
+		},
+		{
+			name: "empty regex",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "code", Type: types.ConditionTypeRegex, Value: ""},
+				},
+			},
+			expectError: true,
+			errorMsg:    "regex: value must be a non-empty string",
+		},
+		{
+			name: "equal with array",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "id", Type: types.ConditionTypeEqual, Value: []interface{}{float64(1)}},
+				},
+			},
+			expectError: true,
+			errorMsg:    "equal: value must be a string, number or boolean",
+		},
+		{
+			name: "is null with value",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "id", Type: types.ConditionTypeIsNull, Value: float64(1)},
+				},
+			},
+			expectError: true,
+			errorMsg:    "is_null: value is not allowed",
+		},
+		{
+			name: "unknown operator",
+			params: types.QueryParams{
+				Table: "orders",
+				Conditions: []types.Condition{
+					{Column: "id", Type: "similar_to", Value: "x"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "similar_to: unsupported condition type",
+		},
+		{
+			name: "postgres rejects names it would truncate",
+			params: types.QueryParams{
+				DatabaseType: "postgres",
+				Table:        "a_table_name_that_is_exactly_sixty_four_characters_long_abcdefgh",
+			},
+			expectError: true,
+			errorMsg:    "longer than 63 bytes",
+		},
 	}
 
 	for _, tt := range tests {
@@ -179,6 +548,66 @@ func TestQueryParamsValidate(t *testing.T) {
 	}
 }
 
+func TestParseStructuredProjections(t *testing.T) {
+	params, err := types.ParseQueryParams(`{
+		"table": "orders",
+		"distinct": true,
+		"projections": [
+			{"column": "status"},
+			{"aggregate": "count_distinct", "column": "customer_id", "alias": "customers"}
+		],
+		"group_by": ["status"],
+		"having": [{"aggregate": "count", "type": "greater_than", "value": 10}]
+	}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !params.Distinct {
+		t.Error("expected distinct")
+	}
+	if len(params.Projections) != 2 || params.Projections[1].Aggregate != types.AggregateCountDistinct {
+		t.Errorf("unexpected projections: %+v", params.Projections)
+	}
+	if params.Having[0].Aggregate != types.AggregateCount {
+		t.Errorf("unexpected having: %+v", params.Having)
+	}
+}
+
+func TestParseConditionGroups(t *testing.T) {
+	params, err := types.ParseQueryParams(`{
+		"table": "orders",
+		"conditions": [
+			{"any": [
+				{"column": "status", "type": "equal", "value": "a"},
+				{"all": [
+					{"column": "status", "type": "equal", "value": "b"},
+					{"column": "total", "type": "greater_than", "value": 10}
+				]}
+			]}
+		]
+	}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	group := params.Conditions[0]
+	if !group.IsGroup() || len(group.Any) != 2 {
+		t.Fatalf("expected an any group with 2 conditions, got %+v", group)
+	}
+	if len(group.Any[1].All) != 2 || group.Any[1].All[1].Column != "total" {
+		t.Errorf("unexpected nested group: %+v", group.Any[1])
+	}
+
+	nested := `{"column": "id", "type": "is_null"}`
+	for i := 0; i <= types.MaxConditionDepth; i++ {
+		nested = `{"not": ` + nested + `}`
+	}
+	if _, err := types.ParseQueryParams(`{"table": "orders", "conditions": [` + nested + `]}`); err == nil {
+		t.Error("expected error for conditions nested too deeply")
+	}
+}
+
 func TestQueryResponse(t *testing.T) {
 	response := types.QueryResponse{
 		Rows: []types.QueryResult{
@@ -219,8 +648,16 @@ func TestConditionTypes(t *testing.T) {
 		types.ConditionTypeLessThanOrEqual,
 		types.ConditionTypeLike,
 		types.ConditionTypeIn,
+		types.ConditionTypeNotIn,
 		types.ConditionTypeIsNull,
 		types.ConditionTypeIsNotNull,
+		types.ConditionTypeBetween,
+		types.ConditionTypeNotLike,
+		types.ConditionTypeILike,
+		types.ConditionTypeStartsWith,
+		types.ConditionTypeEndsWith,
+		types.ConditionTypeContains,
+		types.ConditionTypeRegex,
 	}
 
 	for _, ct := range validTypes {
@@ -230,6 +667,33 @@ func TestConditionTypes(t *testing.T) {
 	}
 }
 
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "plain", expected: "plain"},
+		{value: "50%", expected: `50\%`},
+		{value: "snake_case", expected: `snake\_case`},
+		{value: `C:\dir`, expected: `C:\\dir`},
+		{value: `\%_`, expected: `\\\%\_`},
+	}
+
+	for _, tt := range tests {
+		if got := types.EscapeLike(tt.value); got != tt.expected {
+			t.Errorf("EscapeLike(%q): expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
 		(len(s) > 0 && len(substr) > 0 && stringContains(s, substr)))
@@ -243,3 +707,17 @@ func stringContains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestParseRawQueryParams(t *testing.T) {
+	params, err := types.ParseRawQueryParams(`{"sql": "SELECT * FROM users WHERE id = $1", "params": [42]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(params.Params) != 1 || params.Params[0] != float64(42) {
+		t.Errorf("unexpected params: %v", params.Params)
+	}
+
+	if _, err := types.ParseRawQueryParams(`{"params": [1]}`); err == nil {
+		t.Error("expected error for missing sql")
+	}
+}